@@ -0,0 +1,130 @@
+package main
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/security"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+const (
+	minPasswordLen = 6
+	maxPasswordLen = 25
+	minUsernameLen = 3
+	maxUsernameLen = 20
+)
+
+func runCreateAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "管理员用户名")
+	password := fs.String("password", "", "管理员密码，不填则从标准输入读取")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(*username) < minUsernameLen || len(*username) > maxUsernameLen {
+		return fmt.Errorf("用户名长度需在%d~%d之间", minUsernameLen, maxUsernameLen)
+	}
+	pwd, err := resolvePassword(*password)
+	if err != nil {
+		return err
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	userRepository := repository.NewUserRepository(appState.DB)
+	passwordHasher := security.NewBcryptPasswordHasher()
+
+	_, existed, err := userRepository.FindByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+	if existed {
+		return fmt.Errorf("用户%s已存在", *username)
+	}
+
+	hashed, err := passwordHasher.Hash(pwd)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+	admin := models.User{
+		Username:      *username,
+		Password:      hashed,
+		Group:         "admin",
+		HeadImagePath: config.DefaultHeadImagePath,
+	}
+	if err = userRepository.Create(ctx, &admin); err != nil {
+		return fmt.Errorf("创建管理员失败: %w", err)
+	}
+
+	fmt.Printf("已创建管理员 %s (user_id:%d)\n", admin.Username, admin.ID)
+	return nil
+}
+
+func runResetPassword(args []string) error {
+	fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	password := fs.String("password", "", "新密码，不填则从标准输入读取")
+	username, rest := splitPositional(fs, args)
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	if username == "" {
+		return errors.New("缺少用户名，用法: reset-password <username> [-password <pwd>]")
+	}
+	pwd, err := resolvePassword(*password)
+	if err != nil {
+		return err
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	userRepository := repository.NewUserRepository(appState.DB)
+	passwordHasher := security.NewBcryptPasswordHasher()
+
+	user, existed, err := userRepository.FindByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	if !existed || user == nil {
+		return fmt.Errorf("用户%s不存在", username)
+	}
+
+	hashed, err := passwordHasher.Hash(pwd)
+	if err != nil {
+		return fmt.Errorf("密码哈希失败: %w", err)
+	}
+	if err = userRepository.UpdatePassword(ctx, user.ID, hashed, time.Now(), user.Version); err != nil {
+		return fmt.Errorf("重置密码失败: %w", err)
+	}
+	//和用户自己改密码一样，旧token全部作废
+	if err = userRepository.IncrementTokenVersion(ctx, user.ID); err != nil {
+		return fmt.Errorf("吊销旧token失败: %w", err)
+	}
+
+	fmt.Printf("已重置 %s (user_id:%d) 的密码\n", user.Username, user.ID)
+	return nil
+}
+
+func resolvePassword(flagValue string) (string, error) {
+	pwd := flagValue
+	if pwd == "" {
+		var err error
+		if pwd, err = readPasswordFromStdin(); err != nil {
+			return "", err
+		}
+	}
+	if len(pwd) < minPasswordLen || len(pwd) > maxPasswordLen {
+		return "", fmt.Errorf("密码长度需在%d~%d之间", minPasswordLen, maxPasswordLen)
+	}
+	return pwd, nil
+}
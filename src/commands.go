@@ -0,0 +1,138 @@
+package main
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+)
+
+type command struct {
+	args string
+	desc string
+	run  func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	//放到init里是因为help命令本身要读commands
+	commands = map[string]command{
+		"serve":          {desc: "启动HTTP服务(不带子命令时的默认行为)", run: runServe},
+		"migrate":        {args: "<up|down [n]|status|to <version>>", desc: "数据库迁移", run: runMigrate},
+		"create-admin":   {args: "-username <name> [-password <pwd>]", desc: "创建管理员，未给密码时从标准输入读取", run: runCreateAdmin},
+		"reset-password": {args: "<username> [-password <pwd>]", desc: "重置用户密码并使其已有token失效", run: runResetPassword},
+		"seed":           {args: "<file> [-dry-run]", desc: "按名称upsert内容文件(JSON/YAML)中的游戏资源", run: runSeed},
+		"export-user":    {args: "<user_id> [-o <file>]", desc: "导出用户信息和全部关联数据(JSON)", run: runExportUser},
+		"gc-files":       {args: "[-dry-run] [-grace 1h]", desc: "对账上传文件的引用计数，删除无引用和孤儿文件", run: runGCFiles},
+		"help":           {desc: "查看帮助", run: runHelp},
+	}
+}
+
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return runServe(nil)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("未知子命令: %s\n%s", args[0], commandsUsage())
+	}
+	if err := cmd.run(args[1:]); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+func runHelp(args []string) error {
+	fmt.Println(commandsUsage())
+	return nil
+}
+
+func commandsUsage() string {
+	names := []string{"serve", "migrate", "create-admin", "reset-password", "seed", "export-user", "gc-files", "help"}
+	var builder strings.Builder
+	builder.WriteString("用法: main <子命令> [参数]\n")
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s %s\t%s\n", name, commands[name].args, commands[name].desc)
+	}
+	w.Flush()
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// bootstrapApp 各子命令共用的初始化：读配置、连库、检查迁移版本
+func bootstrapApp() (*config.AppState, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	appState, err := config.Bootstrap(settings)
+	if err != nil {
+		return nil, fmt.Errorf("应用初始化失败: %w", err)
+	}
+	return appState, nil
+}
+
+// splitPositional 把子命令的第一个位置参数取出来，剩下的交给fs解析
+// 这样 reset-password alice -password xxx 和 reset-password -password xxx alice 都能用
+// 带值的flag写成 -name value 时会连着跳过两个参数，bool flag和 -name=value 只跳过一个
+func splitPositional(fs *flag.FlagSet, args []string) (string, []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				rest := append(append([]string{}, args[:i]...), args[i+2:]...)
+				return args[i+1], rest
+			}
+			return "", args
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			if !strings.Contains(arg, "=") && flagTakesValue(fs, strings.TrimLeft(arg, "-")) {
+				i++
+			}
+			continue
+		}
+		rest := append(append([]string{}, args[:i]...), args[i+1:]...)
+		return arg, rest
+	}
+	return "", args
+}
+
+// flagTakesValue 未定义的flag按不带值处理，留给fs.Parse报错
+func flagTakesValue(fs *flag.FlagSet, name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	if boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && boolFlag.IsBoolFlag() {
+		return false
+	}
+	return true
+}
+
+// readPasswordFromStdin 标准输入是终端时关闭回显，管道输入时按行读取
+func readPasswordFromStdin() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "请输入密码: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("读取密码失败: %w", err)
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("读取密码失败")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
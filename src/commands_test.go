@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestSplitPositional(t *testing.T) {
+	newFlagSet := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String("password", "", "")
+		fs.Bool("dry-run", false, "")
+		return fs
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantPos  string
+		wantRest []string
+	}{
+		{"只有位置参数", []string{"alice"}, "alice", []string{}},
+		{"位置参数在前", []string{"alice", "-password", "secret"}, "alice", []string{"-password", "secret"}},
+		{"带值flag在前", []string{"-password", "secret", "alice"}, "alice", []string{"-password", "secret"}},
+		{"等号写法", []string{"-password=secret", "alice"}, "alice", []string{"-password=secret"}},
+		{"bool flag不吃下一个参数", []string{"-dry-run", "content.yaml"}, "content.yaml", []string{"-dry-run"}},
+		{"双横线bool flag", []string{"--dry-run", "content.yaml"}, "content.yaml", []string{"--dry-run"}},
+		{"bool flag显式给值", []string{"-dry-run=false", "content.yaml"}, "content.yaml", []string{"-dry-run=false"}},
+		{"未知flag留给Parse", []string{"-unknown", "alice"}, "alice", []string{"-unknown"}},
+		{"--之后是位置参数", []string{"-dry-run", "--", "-file.yaml"}, "-file.yaml", []string{"-dry-run"}},
+		{"没有位置参数", []string{"-password", "secret"}, "", []string{"-password", "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet()
+			pos, rest := splitPositional(fs, tt.args)
+			if pos != tt.wantPos || !slices.Equal(rest, tt.wantRest) {
+				t.Fatalf("got %q %q, want %q %q", pos, rest, tt.wantPos, tt.wantRest)
+			}
+			if err := fs.Parse(rest); err != nil && tt.name != "未知flag留给Parse" {
+				t.Fatalf("Parse: %v", err)
+			}
+		})
+	}
+}
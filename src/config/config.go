@@ -1,28 +1,39 @@
 package config
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/logging"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/migration"
 	models "MuXi/2026-MuxiShooter-Backend/models"
 	utils "MuXi/2026-MuxiShooter-Backend/utils"
 )
 
+// 可调的上限和间隔在Settings里，这里只留协议和存储格式相关的常量
 const (
-	NumLimter                = 20
-	DefaultHeadImagePath     = "static/DefaultHeadImg.jpeg"
-	PasswordUpdatedInterval  = 30 * time.Minute
-	UsernameUpdatedInterval  = 24 * time.Hour
-	HeadImageUpdatedInterval = 24 * time.Hour
-	PrefixHeadImg            = "HeadImg"
-	DefaultPage              = 1
-	DefaultPageSize          = 20
-	MaxPageSize              = 100
+	DefaultHeadImagePath    = "static/DefaultHeadImg.jpeg"
+	PrefixHeadImg           = "HeadImg"
+	DefaultPage             = 1
+	MaxIdempotencyKeyLength = 128
+	//事件流的心跳间隔，要比反向代理的空闲超时短
+	EventStreamHeartbeat = 25 * time.Second
+	//上传文件在存储里的key前缀，和以前本地uploads目录下的相对路径保持一致
+	UploadKeyPrefix = "uploads/"
+	//就绪检查里每项依赖检查的超时，要比反向代理健康检查的超时短
+	ReadinessCheckTimeout = 2 * time.Second
+	//存储检查要真的写读删一个文件，结果缓存这么久，探测再频繁也不会每次都打到存储
+	ReadinessStorageCacheTTL = 10 * time.Second
 )
 
 var (
@@ -30,41 +41,27 @@ var (
 	ErrJWTSecretGenerate     = errors.New("JWT密钥生成失败")
 )
 
-type Settings struct {
-	DBUser        string
-	DBPassword    string
-	DBHost        string
-	DBPort        string
-	DBName        string
-	AdminUsername string
-	AdminPassword string
-	JWTSecret     string
-}
-
 type AppState struct {
 	DB        *gorm.DB
 	JWTSecret []byte
-}
-
-func LoadSettings() Settings {
-	return Settings{
-		DBUser:        utils.GetEnv("DB_USER", "adminuser"),
-		DBPassword:    utils.GetEnv("DB_PASSWORD", ""),
-		DBHost:        utils.GetEnv("DB_HOST", ""),
-		DBPort:        utils.GetEnv("DB_PORT", "3306"),
-		DBName:        utils.GetEnv("DB_NAME", "mini"),
-		AdminUsername: utils.GetEnv("ADMIN_USERNAME", "adminuser"),
-		AdminPassword: utils.GetEnv("ADMIN_PASSWORD", ""),
-		JWTSecret:     utils.GetEnv("JWT_SECRET", ""),
-	}
+	//分页游标的签名密钥，和JWT密钥分开
+	CursorSecret []byte
+	Settings     Settings
+	Logger       *slog.Logger
 }
 
 func Bootstrap(settings Settings) (*AppState, error) {
-	db, err := connectDB(settings)
+	logger := NewLogger(settings.Log)
+
+	db, err := OpenDB(settings, logger)
 	if err != nil {
 		return nil, err
 	}
 
+	if err = ensureSchema(db, settings); err != nil {
+		return nil, err
+	}
+
 	if err = initAdmin(db, settings); err != nil {
 		return nil, err
 	}
@@ -74,10 +71,49 @@ func Bootstrap(settings Settings) (*AppState, error) {
 		return nil, err
 	}
 
-	return &AppState{DB: db, JWTSecret: jwtSecret}, nil
+	cursorSecret, err := initCursorSecret(settings, jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	if settings.CursorSecret == "" && settings.JWTSecret == "" {
+		logger.Warn("未配置jwt_secret和cursor_secret，重启后已发出的令牌和分页游标都会失效")
+	}
+
+	return &AppState{DB: db, JWTSecret: jwtSecret, CursorSecret: cursorSecret, Settings: settings, Logger: logger}, nil
+}
+
+// NewLogger 按配置创建输出到stderr的日志，并设为slog和log包的默认日志，没改造的log.Printf也会按同样格式输出
+// settings已经校验过，level不会解析失败
+func NewLogger(settings LogSettings) *slog.Logger {
+	level, _ := settings.SlogLevel()
+	logger := logging.New(os.Stderr, level, settings.Format)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// newGormConfig 让GORM的慢查询和错误日志也走slog，查询用WithContext带上请求的ctx时会带上请求ID
+func newGormConfig(logger *slog.Logger) *gorm.Config {
+	return &gorm.Config{
+		Logger: gormlogger.NewSlogLogger(logger, gormlogger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  gormlogger.Warn,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true,
+		}),
+	}
 }
 
-func connectDB(settings Settings) (*gorm.DB, error) {
+// Close 关闭数据库连接池，退出前调用
+func (s *AppState) Close() error {
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// OpenDB 只负责连接(必要时建库)，不检查表结构版本，migrate子命令也用它
+func OpenDB(settings Settings, logger *slog.Logger) (*gorm.DB, error) {
 	if settings.DBPassword == "" {
 		return nil, errors.New("数据库管理用户密码环境变量(DB_PASSWORD)为空,请配置")
 	}
@@ -92,7 +128,7 @@ func connectDB(settings Settings) (*gorm.DB, error) {
 	var err error
 	maxRetries := 15
 	for i := 0; i < maxRetries; i++ {
-		rootDB, err = gorm.Open(mysql.Open(dsnRoot), &gorm.Config{})
+		rootDB, err = gorm.Open(mysql.Open(dsnRoot), newGormConfig(logger))
 		if err == nil {
 			break
 		}
@@ -109,29 +145,59 @@ func connectDB(settings Settings) (*gorm.DB, error) {
 
 	dsnDB := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		settings.DBUser, settings.DBPassword, settings.DBHost, settings.DBPort, settings.DBName)
-	db, err := gorm.Open(mysql.Open(dsnDB), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsnDB), newGormConfig(logger))
 	if err != nil {
 		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
 
-	err = db.AutoMigrate(&models.Achievement{}, &models.User{}, &models.Skill{}, &models.Card{}, &models.Item{}, &models.UserAchievement{}, &models.UserCard{}, &models.UserItem{}, &models.UserSkill{})
+	return db, nil
+}
+
+func ensureSchema(db *gorm.DB, settings Settings) error {
+	migrator, err := migration.NewMigrator(db)
 	if err != nil {
-		return nil, fmt.Errorf("数据迁移失败: %w", err)
+		return fmt.Errorf("加载迁移文件失败: %w", err)
 	}
 
-	return db, nil
+	err = migrator.EnsureCurrent()
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, migration.ErrSchemaOutdated) {
+		return fmt.Errorf("检查数据库版本失败: %w", err)
+	}
+	if !settings.AutoMigrate {
+		return fmt.Errorf("%w，请先执行 migrate up 或设置AUTO_MIGRATE=true", err)
+	}
+
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		slog.Info("已执行迁移", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("数据迁移失败: %w", err)
+	}
+	return nil
 }
 
 func initAdmin(db *gorm.DB, settings Settings) error {
 	admin := settings.AdminUsername
 	adminPsw := settings.AdminPassword
 	var count int64
-	db.Model(&models.User{}).Where("username = ?", admin).Count(&count)
+	if err := db.Model(&models.User{}).Where("username = ?", admin).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询管理员失败: %w", err)
+	}
 
 	if count > 0 {
 		return nil
 	}
 
+	if adminPsw == "" {
+		//不再用空密码建管理员，需要的话用 create-admin 子命令手动建
+		slog.Warn("未设置ADMIN_PASSWORD，跳过初始化管理员，可使用 create-admin 子命令创建", "admin", admin)
+		return nil
+	}
+
 	hashedPsw, err := utils.Hashtool(adminPsw)
 	if err != nil {
 		return fmt.Errorf("管理员密码哈希失败: %w", err)
@@ -168,3 +234,19 @@ func initJWTSecret(settings Settings) ([]byte, error) {
 	}
 	return decoded, nil
 }
+
+// initCursorSecret 配置了cursor_secret时直接使用，否则用HKDF从JWT密钥派生，不直接复用JWT密钥
+func initCursorSecret(settings Settings, jwtSecret []byte) ([]byte, error) {
+	if settings.CursorSecret == "" {
+		return hkdf.Key(sha256.New, jwtSecret, nil, "page-cursor", 32)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(settings.CursorSecret)
+	if err != nil {
+		return nil, fmt.Errorf("base64解码游标密钥失败:%w", err)
+	}
+	if len(decoded) < 32 {
+		return nil, errors.New("游标密钥长度不足32字节")
+	}
+	return decoded, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/logging"
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormLogRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo, "json")
+	db, err := gorm.Open(sqlite.Open("file::memory:"), newGormConfig(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		db   *gorm.DB
+		want bool
+	}{
+		{"带请求的ctx", db.WithContext(logging.WithRequestID(context.Background(), "req-sql")), true},
+		{"没有ctx", db, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			//查不存在的表，GORM按错误级别记一条SQL日志
+			var count int64
+			if err := tt.db.Table("missing_table").Count(&count).Error; err == nil {
+				t.Fatal("应该报错")
+			}
+			if buf.Len() == 0 {
+				t.Fatal("没有SQL日志")
+			}
+			if got := strings.Contains(buf.String(), `"request_id":"req-sql"`); got != tt.want {
+				t.Fatalf("日志里有request_id = %v, want %v: %s", got, tt.want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	utils "MuXi/2026-MuxiShooter-Backend/utils"
+)
+
+// DefaultConfigFile 没有设置CONFIG_FILE时尝试读取的配置文件，不存在时只用默认值和环境变量
+const DefaultConfigFile = "config.yaml"
+
+// Settings 启动配置，优先级：环境变量 > 配置文件 > 默认值
+// 环境变量为空字符串时视为未设置，docker-compose里 ${VAR:-} 这种写法不会把文件里的值覆盖掉
+type Settings struct {
+	DBUser        string `yaml:"db_user"`
+	DBPassword    string `yaml:"db_password"`
+	DBHost        string `yaml:"db_host"`
+	DBPort        string `yaml:"db_port"`
+	DBName        string `yaml:"db_name"`
+	AdminUsername string `yaml:"admin_username"`
+	AdminPassword string `yaml:"admin_password"`
+	JWTSecret     string `yaml:"jwt_secret"`
+	//分页游标的签名密钥，为空时从JWT密钥派生，多副本部署时各实例要一致
+	CursorSecret string `yaml:"cursor_secret"`
+	//为true时启动时自动执行未完成的迁移，否则数据库版本落后会拒绝启动
+	AutoMigrate bool                `yaml:"auto_migrate"`
+	HTTP        HTTPSettings        `yaml:"http"`
+	Auth        AuthSettings        `yaml:"auth"`
+	Profile     ProfileSettings     `yaml:"profile"`
+	HeadImage   HeadImageSettings   `yaml:"head_image"`
+	Friend      FriendSettings      `yaml:"friend"`
+	Sync        SyncSettings        `yaml:"sync"`
+	Bulk        BulkSettings        `yaml:"bulk"`
+	Pagination  PaginationSettings  `yaml:"pagination"`
+	Storage     StorageSettings     `yaml:"storage"`
+	Moderation  ModerationSettings  `yaml:"moderation"`
+	Idempotency IdempotencySettings `yaml:"idempotency"`
+	Metrics     MetricsSettings     `yaml:"metrics"`
+	Log         LogSettings         `yaml:"log"`
+}
+
+type HTTPSettings struct {
+	//监听地址
+	Addr string `yaml:"addr"`
+	//读完整个请求(含body)的超时，0表示不限制
+	ReadTimeout time.Duration `yaml:"read_timeout"`
+	//读请求头的超时，防止慢速客户端一直占着连接
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	//写响应的超时，事件流(SSE)连接会自己取消这个限制
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	//keep-alive连接的空闲超时
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+	//收到SIGINT/SIGTERM后等待处理中请求完成的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	//允许跨域的前端地址
+	CORSAllowOrigins []string `yaml:"cors_allow_origins"`
+	//每秒请求数上限，目前限流由Caddy负责，Limiter中间件默认不挂
+	RateLimit int `yaml:"rate_limit"`
+}
+
+type AuthSettings struct {
+	//登录/注册签发的token有效期
+	TokenTTL time.Duration `yaml:"token_ttl"`
+}
+
+// ProfileSettings 用户自己修改资料的最小间隔
+type ProfileSettings struct {
+	PasswordUpdateInterval  time.Duration `yaml:"password_update_interval"`
+	UsernameUpdateInterval  time.Duration `yaml:"username_update_interval"`
+	HeadImageUpdateInterval time.Duration `yaml:"head_image_update_interval"`
+}
+
+// HeadImageSettings 头像和头像预设的上传限制，上传的图片都会重新编码成jpeg
+type HeadImageSettings struct {
+	//原文件最大字节数
+	MaxBytes int `yaml:"max_bytes"`
+	//原图宽高上限，解码前按文件头检查
+	MaxDimension int `yaml:"max_dimension"`
+	//保存的主图最长边，超过时等比缩小
+	MaxSide int `yaml:"max_side"`
+	//缩略图边长
+	ThumbnailSizes []int `yaml:"thumbnail_sizes"`
+	JPEGQuality    int   `yaml:"jpeg_quality"`
+}
+
+// ProcessOptions 转成图片处理的参数
+func (s HeadImageSettings) ProcessOptions() utils.ImageProcessOptions {
+	return utils.ImageProcessOptions{
+		MaxBytes:       int64(s.MaxBytes),
+		MaxDimension:   s.MaxDimension,
+		MaxSide:        s.MaxSide,
+		ThumbnailSizes: append([]int{}, s.ThumbnailSizes...),
+		JPEGQuality:    s.JPEGQuality,
+	}
+}
+
+type FriendSettings struct {
+	//每个玩家的好友上限
+	MaxFriends int `yaml:"max_friends"`
+}
+
+type SyncSettings struct {
+	//增量同步一次最多返回的实体数，超过时让客户端重新拉完整存档
+	MaxChangeFeedEntities int `yaml:"max_change_feed_entities"`
+}
+
+// BulkSettings 管理员批量接口
+type BulkSettings struct {
+	//批量创建/更新/删除一次最多的行数
+	MaxRows int `yaml:"max_rows"`
+	//批量发放/回收每批处理的用户数，只用来切分SQL，整个操作仍在一个事务里
+	GrantBatchSize int `yaml:"grant_batch_size"`
+}
+
+type PaginationSettings struct {
+	//没传page_size时的每页数量
+	DefaultPageSize int `yaml:"default_page_size"`
+	//page_size的上限，超过时按上限处理
+	MaxPageSize int `yaml:"max_page_size"`
+}
+
+// StorageSettings 上传文件的存储
+type StorageSettings struct {
+	//local或s3
+	Backend string `yaml:"backend"`
+	//local: 对象key相对的根目录
+	LocalDir string `yaml:"local_dir"`
+	//对外访问的URL前缀，为空时local直接返回key(由Caddy按相对路径提供)，s3用endpoint和bucket拼出对象地址
+	PublicBaseURL string `yaml:"public_base_url"`
+	S3Endpoint    string `yaml:"s3_endpoint"`
+	S3Region      string `yaml:"s3_region"`
+	S3Bucket      string `yaml:"s3_bucket"`
+	S3AccessKey   string `yaml:"s3_access_key_id"`
+	S3SecretKey   string `yaml:"s3_secret_access_key"`
+	//为true时用endpoint/bucket/key的路径形式访问，MinIO等自建服务一般需要
+	S3PathStyle bool `yaml:"s3_force_path_style"`
+	//为true时返回预签名的临时URL，桶不允许公开读时使用
+	S3Presign    bool          `yaml:"s3_presign"`
+	S3PresignTTL time.Duration `yaml:"s3_presign_ttl"`
+	//上传文件垃圾回收的间隔
+	GCInterval time.Duration `yaml:"gc_interval"`
+	//垃圾回收不碰这段时间内变动过的引用计数和新写入的文件，给进行中的上传留出时间
+	GCGracePeriod time.Duration `yaml:"gc_grace_period"`
+}
+
+// ModerationSettings 用户名/头像审核和用户名敏感词
+type ModerationSettings struct {
+	//为true时改用户名、上传头像后进入待审核队列，修改先生效，管理员驳回时恢复原值
+	Enabled bool `yaml:"enabled"`
+	//注册和改名时检查的用户名敏感词
+	BannedWords []string `yaml:"banned_words"`
+	//每行一个敏感词的文件，#开头的行忽略，和BannedWords合并
+	BannedWordsFile string `yaml:"banned_words_file"`
+}
+
+// LoadBannedWords 合并配置里和文件里的敏感词
+func (s ModerationSettings) LoadBannedWords() ([]string, error) {
+	words := append([]string{}, s.BannedWords...)
+	if s.BannedWordsFile == "" {
+		return words, nil
+	}
+	data, err := os.ReadFile(s.BannedWordsFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取敏感词文件失败: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, nil
+}
+
+// IdempotencySettings 带Idempotency-Key的写请求
+type IdempotencySettings struct {
+	//中间件要把请求体读进内存算哈希，超过时直接返回413；要比头像和内容文件的上限大
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+	//回放有效期
+	TTL time.Duration `yaml:"ttl"`
+	//第一次请求处理中时占住key的最长时间，超时后视为处理失败，允许重试
+	LockTimeout time.Duration `yaml:"lock_timeout"`
+}
+
+// MetricsSettings Prometheus指标
+type MetricsSettings struct {
+	Enabled bool `yaml:"enabled"`
+	//单独监听的地址，不对外暴露；为空时挂在主端口的/metrics上，此时必须设置Token
+	Addr string `yaml:"addr"`
+	//挂在主端口上时抓取需要带 Authorization: Bearer <token>
+	Token string `yaml:"token"`
+}
+
+type LogSettings struct {
+	//debug/info/warn/error
+	Level string `yaml:"level"`
+	//json或text，text方便本地开发时看
+	Format string `yaml:"format"`
+}
+
+// SlogLevel 解析Level，大小写不敏感
+func (s LogSettings) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(s.Level))
+	return level, err
+}
+
+// DefaultSettings 没有配置文件和环境变量时的值
+func DefaultSettings() Settings {
+	return Settings{
+		DBUser:        "adminuser",
+		DBPort:        "3306",
+		DBName:        "mini",
+		AdminUsername: "adminuser",
+		HTTP: HTTPSettings{
+			Addr:              ":8080",
+			ReadTimeout:       30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+			CORSAllowOrigins: []string{
+				"http://localhost:5173", // 前端vite的默认启动地址
+				"http://localhost:3000", // 前端自己定义的启动地址
+			},
+			RateLimit: 20,
+		},
+		Auth: AuthSettings{TokenTTL: 24 * time.Hour},
+		Profile: ProfileSettings{
+			PasswordUpdateInterval:  30 * time.Minute,
+			UsernameUpdateInterval:  24 * time.Hour,
+			HeadImageUpdateInterval: 24 * time.Hour,
+		},
+		HeadImage: HeadImageSettings{
+			MaxBytes:       5 << 20,
+			MaxDimension:   4096,
+			MaxSide:        512,
+			ThumbnailSizes: []int{64, 160},
+			JPEGQuality:    88,
+		},
+		Friend:     FriendSettings{MaxFriends: 200},
+		Sync:       SyncSettings{MaxChangeFeedEntities: 1000},
+		Bulk:       BulkSettings{MaxRows: 500, GrantBatchSize: 5000},
+		Pagination: PaginationSettings{DefaultPageSize: 20, MaxPageSize: 100},
+		Storage: StorageSettings{
+			Backend:       "local",
+			LocalDir:      ".",
+			S3Region:      "us-east-1",
+			S3PathStyle:   true,
+			S3PresignTTL:  time.Hour,
+			GCInterval:    time.Hour,
+			GCGracePeriod: time.Hour,
+		},
+		Idempotency: IdempotencySettings{MaxBodyBytes: 6 << 20, TTL: 24 * time.Hour, LockTimeout: time.Minute},
+		Metrics:     MetricsSettings{Enabled: true, Addr: "127.0.0.1:9090"},
+		Log:         LogSettings{Level: "info", Format: "json"},
+	}
+}
+
+// LoadSettings 依次读取默认值、配置文件(CONFIG_FILE，未设置时为config.yaml，不存在则跳过)和环境变量，再整体校验
+func LoadSettings() (Settings, error) {
+	settings := DefaultSettings()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit || path == "" {
+		path, explicit = DefaultConfigFile, false
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		//写错的key直接报错，不然拼错的配置项会被悄悄忽略
+		if err = yaml.UnmarshalWithOptions(data, &settings, yaml.Strict()); err != nil {
+			return Settings{}, fmt.Errorf("解析配置文件%s失败:\n%s", path, yaml.FormatError(err, false, true))
+		}
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+	default:
+		return Settings{}, fmt.Errorf("读取配置文件%s失败: %w", path, err)
+	}
+
+	if err = applyEnv(&settings); err != nil {
+		return Settings{}, err
+	}
+	if err = settings.Validate(); err != nil {
+		return Settings{}, err
+	}
+	return settings, nil
+}
+
+func applyEnv(s *Settings) error {
+	env := &envOverlay{}
+	env.string("DB_USER", &s.DBUser)
+	env.string("DB_PASSWORD", &s.DBPassword)
+	env.string("DB_HOST", &s.DBHost)
+	env.string("DB_PORT", &s.DBPort)
+	env.string("DB_NAME", &s.DBName)
+	env.string("ADMIN_USERNAME", &s.AdminUsername)
+	env.string("ADMIN_PASSWORD", &s.AdminPassword)
+	env.string("JWT_SECRET", &s.JWTSecret)
+	env.string("CURSOR_SECRET", &s.CursorSecret)
+	env.bool("AUTO_MIGRATE", &s.AutoMigrate)
+
+	env.string("HTTP_ADDR", &s.HTTP.Addr)
+	env.duration("HTTP_READ_TIMEOUT", &s.HTTP.ReadTimeout)
+	env.duration("HTTP_READ_HEADER_TIMEOUT", &s.HTTP.ReadHeaderTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &s.HTTP.WriteTimeout)
+	env.duration("HTTP_IDLE_TIMEOUT", &s.HTTP.IdleTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &s.HTTP.ShutdownTimeout)
+	env.list("CORS_ALLOW_ORIGINS", &s.HTTP.CORSAllowOrigins)
+	env.int("RATE_LIMIT", &s.HTTP.RateLimit)
+	env.duration("TOKEN_TTL", &s.Auth.TokenTTL)
+	env.duration("PASSWORD_UPDATE_INTERVAL", &s.Profile.PasswordUpdateInterval)
+	env.duration("USERNAME_UPDATE_INTERVAL", &s.Profile.UsernameUpdateInterval)
+	env.duration("HEAD_IMAGE_UPDATE_INTERVAL", &s.Profile.HeadImageUpdateInterval)
+	env.int("HEAD_IMAGE_MAX_BYTES", &s.HeadImage.MaxBytes)
+	env.int("HEAD_IMAGE_MAX_DIMENSION", &s.HeadImage.MaxDimension)
+	env.int("HEAD_IMAGE_MAX_SIDE", &s.HeadImage.MaxSide)
+	env.intList("HEAD_IMAGE_THUMBNAIL_SIZES", &s.HeadImage.ThumbnailSizes)
+	env.int("HEAD_IMAGE_JPEG_QUALITY", &s.HeadImage.JPEGQuality)
+	env.int("MAX_FRIENDS", &s.Friend.MaxFriends)
+	env.int("MAX_CHANGE_FEED_ENTITIES", &s.Sync.MaxChangeFeedEntities)
+	env.int("BULK_MAX_ROWS", &s.Bulk.MaxRows)
+	env.int("BULK_GRANT_BATCH_SIZE", &s.Bulk.GrantBatchSize)
+	env.int("DEFAULT_PAGE_SIZE", &s.Pagination.DefaultPageSize)
+	env.int("MAX_PAGE_SIZE", &s.Pagination.MaxPageSize)
+
+	env.string("STORAGE_BACKEND", &s.Storage.Backend)
+	env.string("STORAGE_LOCAL_DIR", &s.Storage.LocalDir)
+	env.string("STORAGE_PUBLIC_BASE_URL", &s.Storage.PublicBaseURL)
+	env.string("S3_ENDPOINT", &s.Storage.S3Endpoint)
+	env.string("S3_REGION", &s.Storage.S3Region)
+	env.string("S3_BUCKET", &s.Storage.S3Bucket)
+	env.string("S3_ACCESS_KEY_ID", &s.Storage.S3AccessKey)
+	env.string("S3_SECRET_ACCESS_KEY", &s.Storage.S3SecretKey)
+	env.bool("S3_FORCE_PATH_STYLE", &s.Storage.S3PathStyle)
+	env.bool("S3_PRESIGN", &s.Storage.S3Presign)
+	env.duration("S3_PRESIGN_TTL", &s.Storage.S3PresignTTL)
+	env.duration("FILE_GC_INTERVAL", &s.Storage.GCInterval)
+	env.duration("FILE_GC_GRACE_PERIOD", &s.Storage.GCGracePeriod)
+
+	env.bool("MODERATION_ENABLED", &s.Moderation.Enabled)
+	env.list("USERNAME_BANNED_WORDS", &s.Moderation.BannedWords)
+	env.string("USERNAME_BANNED_WORDS_FILE", &s.Moderation.BannedWordsFile)
+
+	env.int("IDEMPOTENCY_MAX_BODY_BYTES", &s.Idempotency.MaxBodyBytes)
+	env.duration("IDEMPOTENCY_TTL", &s.Idempotency.TTL)
+	env.duration("IDEMPOTENCY_LOCK_TIMEOUT", &s.Idempotency.LockTimeout)
+
+	env.string("LOG_LEVEL", &s.Log.Level)
+	env.string("LOG_FORMAT", &s.Log.Format)
+
+	env.bool("METRICS_ENABLED", &s.Metrics.Enabled)
+	env.string("METRICS_ADDR", &s.Metrics.Addr)
+	env.string("METRICS_TOKEN", &s.Metrics.Token)
+	return errors.Join(env.errs...)
+}
+
+// Validate 一次报出所有不合法的配置项，括号里是对应的环境变量
+func (s Settings) Validate() error {
+	var errs []error
+	check := func(ok bool, key, env, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s(%s)%s", key, env, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(s.HTTP.Addr != "", "http.addr", "HTTP_ADDR", "不能为空")
+	check(s.HTTP.ReadTimeout >= 0, "http.read_timeout", "HTTP_READ_TIMEOUT", "不能为负数")
+	check(s.HTTP.ReadHeaderTimeout >= 0, "http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", "不能为负数")
+	check(s.HTTP.WriteTimeout >= 0, "http.write_timeout", "HTTP_WRITE_TIMEOUT", "不能为负数")
+	check(s.HTTP.IdleTimeout >= 0, "http.idle_timeout", "HTTP_IDLE_TIMEOUT", "不能为负数")
+	check(s.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "SHUTDOWN_TIMEOUT", "必须大于0")
+	for _, origin := range s.HTTP.CORSAllowOrigins {
+		u, err := url.Parse(origin)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/"),
+			"http.cors_allow_origins", "CORS_ALLOW_ORIGINS", "中的%q不是合法的源，格式为http(s)://host[:port]", origin)
+	}
+	check(s.HTTP.RateLimit > 0, "http.rate_limit", "RATE_LIMIT", "必须大于0，当前为%d", s.HTTP.RateLimit)
+	check(s.Auth.TokenTTL >= time.Minute, "auth.token_ttl", "TOKEN_TTL", "不能小于1m，当前为%s", s.Auth.TokenTTL)
+	check(s.Profile.PasswordUpdateInterval >= 0, "profile.password_update_interval", "PASSWORD_UPDATE_INTERVAL", "不能为负数")
+	check(s.Profile.UsernameUpdateInterval >= 0, "profile.username_update_interval", "USERNAME_UPDATE_INTERVAL", "不能为负数")
+	check(s.Profile.HeadImageUpdateInterval >= 0, "profile.head_image_update_interval", "HEAD_IMAGE_UPDATE_INTERVAL", "不能为负数")
+	check(s.HeadImage.MaxBytes > 0, "head_image.max_bytes", "HEAD_IMAGE_MAX_BYTES", "必须大于0，当前为%d", s.HeadImage.MaxBytes)
+	check(s.HeadImage.MaxDimension > 0, "head_image.max_dimension", "HEAD_IMAGE_MAX_DIMENSION", "必须大于0，当前为%d", s.HeadImage.MaxDimension)
+	check(s.HeadImage.MaxSide > 0 && s.HeadImage.MaxSide <= s.HeadImage.MaxDimension,
+		"head_image.max_side", "HEAD_IMAGE_MAX_SIDE", "必须在1到max_dimension(%d)之间，当前为%d", s.HeadImage.MaxDimension, s.HeadImage.MaxSide)
+	for _, size := range s.HeadImage.ThumbnailSizes {
+		check(size > 0 && size <= s.HeadImage.MaxSide, "head_image.thumbnail_sizes", "HEAD_IMAGE_THUMBNAIL_SIZES", "中的%d必须在1到max_side(%d)之间", size, s.HeadImage.MaxSide)
+	}
+	check(s.HeadImage.JPEGQuality >= 1 && s.HeadImage.JPEGQuality <= 100, "head_image.jpeg_quality", "HEAD_IMAGE_JPEG_QUALITY", "必须在1到100之间，当前为%d", s.HeadImage.JPEGQuality)
+	check(s.Friend.MaxFriends > 0, "friend.max_friends", "MAX_FRIENDS", "必须大于0，当前为%d", s.Friend.MaxFriends)
+	check(s.Sync.MaxChangeFeedEntities > 0, "sync.max_change_feed_entities", "MAX_CHANGE_FEED_ENTITIES", "必须大于0，当前为%d", s.Sync.MaxChangeFeedEntities)
+	check(s.Bulk.MaxRows > 0, "bulk.max_rows", "BULK_MAX_ROWS", "必须大于0，当前为%d", s.Bulk.MaxRows)
+	//批量发放时要拿一批用户ID拼IN条件，不能超过MySQL的占位符上限
+	check(s.Bulk.GrantBatchSize > 0 && s.Bulk.GrantBatchSize <= 50000, "bulk.grant_batch_size", "BULK_GRANT_BATCH_SIZE", "必须在1到50000之间，当前为%d", s.Bulk.GrantBatchSize)
+	check(s.Pagination.MaxPageSize > 0, "pagination.max_page_size", "MAX_PAGE_SIZE", "必须大于0，当前为%d", s.Pagination.MaxPageSize)
+	check(s.Pagination.DefaultPageSize > 0 && s.Pagination.DefaultPageSize <= s.Pagination.MaxPageSize,
+		"pagination.default_page_size", "DEFAULT_PAGE_SIZE", "必须在1到max_page_size(%d)之间，当前为%d", s.Pagination.MaxPageSize, s.Pagination.DefaultPageSize)
+	check(s.Storage.Backend == "local" || s.Storage.Backend == "s3", "storage.backend", "STORAGE_BACKEND", "仅支持local/s3，当前为%q", s.Storage.Backend)
+	check(s.Idempotency.MaxBodyBytes > s.HeadImage.MaxBytes, "idempotency.max_body_bytes", "IDEMPOTENCY_MAX_BODY_BYTES", "必须大于head_image.max_bytes(%d)，当前为%d", s.HeadImage.MaxBytes, s.Idempotency.MaxBodyBytes)
+	check(s.Idempotency.TTL > 0, "idempotency.ttl", "IDEMPOTENCY_TTL", "必须大于0")
+	check(s.Idempotency.LockTimeout > 0, "idempotency.lock_timeout", "IDEMPOTENCY_LOCK_TIMEOUT", "必须大于0")
+	_, levelErr := s.Log.SlogLevel()
+	check(levelErr == nil, "log.level", "LOG_LEVEL", "仅支持debug/info/warn/error，当前为%q", s.Log.Level)
+	check(s.Log.Format == "json" || s.Log.Format == "text", "log.format", "LOG_FORMAT", "仅支持json/text，当前为%q", s.Log.Format)
+	check(!s.Metrics.Enabled || s.Metrics.Addr != "" || s.Metrics.Token != "", "metrics.token", "METRICS_TOKEN", "在metrics.addr为空(挂在主端口)时必须设置")
+	check(!s.Metrics.Enabled || s.Metrics.Addr != s.HTTP.Addr, "metrics.addr", "METRICS_ADDR", "不能和http.addr相同，要挂在主端口上请把metrics.addr设为空")
+	check(s.Storage.S3PresignTTL > 0, "storage.s3_presign_ttl", "S3_PRESIGN_TTL", "必须大于0")
+	check(s.Storage.GCInterval > 0, "storage.gc_interval", "FILE_GC_INTERVAL", "必须大于0")
+	check(s.Storage.GCGracePeriod >= 0, "storage.gc_grace_period", "FILE_GC_GRACE_PERIOD", "不能为负数")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("配置校验失败:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// envOverlay 用环境变量覆盖配置，格式错误收集起来一起返回
+type envOverlay struct {
+	errs []error
+}
+
+func (e *envOverlay) lookup(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (e *envOverlay) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envOverlay) bool(key string, dst *bool) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("环境变量%s=%q不是合法的布尔值(true/false)", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envOverlay) int(key string, dst *int) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("环境变量%s=%q不是合法的整数", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envOverlay) duration(key string, dst *time.Duration) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("环境变量%s=%q不是合法的时长(如30m、24h)", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+// intList 逗号分隔的整数，去掉空项
+func (e *envOverlay) intList(key string, dst *[]int) {
+	var items []string
+	e.list(key, &items)
+	if items == nil {
+		return
+	}
+	list := make([]int, 0, len(items))
+	for _, item := range items {
+		parsed, err := strconv.Atoi(item)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("环境变量%s中的%q不是合法的整数", key, item))
+			return
+		}
+		list = append(list, parsed)
+	}
+	*dst = list
+}
+
+// list 逗号分隔，去掉空项
+func (e *envOverlay) list(key string, dst *[]string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
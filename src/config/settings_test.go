@@ -0,0 +1,74 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTunables(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(s *Settings)
+		wantKey string
+	}{
+		{"默认配置", func(s *Settings) {}, ""},
+		{"头像上限为0", func(s *Settings) { s.HeadImage.MaxBytes = 0 }, "head_image.max_bytes"},
+		{"长边超过解码上限", func(s *Settings) { s.HeadImage.MaxSide = s.HeadImage.MaxDimension + 1 }, "head_image.max_side"},
+		{"缩略图比长边大", func(s *Settings) { s.HeadImage.ThumbnailSizes = []int{64, 1024} }, "head_image.thumbnail_sizes"},
+		{"JPEG质量越界", func(s *Settings) { s.HeadImage.JPEGQuality = 101 }, "head_image.jpeg_quality"},
+		{"好友上限为0", func(s *Settings) { s.Friend.MaxFriends = 0 }, "friend.max_friends"},
+		{"增量同步上限为0", func(s *Settings) { s.Sync.MaxChangeFeedEntities = 0 }, "sync.max_change_feed_entities"},
+		{"批量行数为0", func(s *Settings) { s.Bulk.MaxRows = 0 }, "bulk.max_rows"},
+		{"每批用户数超过占位符上限", func(s *Settings) { s.Bulk.GrantBatchSize = 60000 }, "bulk.grant_batch_size"},
+		{"幂等请求体不大于头像上限", func(s *Settings) { s.Idempotency.MaxBodyBytes = s.HeadImage.MaxBytes }, "idempotency.max_body_bytes"},
+		{"幂等TTL为0", func(s *Settings) { s.Idempotency.TTL = 0 }, "idempotency.ttl"},
+		{"幂等锁超时为0", func(s *Settings) { s.Idempotency.LockTimeout = 0 }, "idempotency.lock_timeout"},
+		{"清理间隔为0", func(s *Settings) { s.Storage.GCInterval = 0 }, "storage.gc_interval"},
+		{"宽限期为负", func(s *Settings) { s.Storage.GCGracePeriod = -time.Second }, "storage.gc_grace_period"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := DefaultSettings()
+			tt.modify(&settings)
+			err := settings.Validate()
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantKey) {
+				t.Fatalf("got %v, want error about %s", err, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestEnvOverlayIntList(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{"逗号分隔", "64,160", []int{64, 160}, false},
+		{"带空格和空项", " 32 , ,96 ", []int{32, 96}, false},
+		{"不是数字", "64,abc", []int{1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HEAD_IMAGE_THUMBNAIL_SIZES", tt.value)
+			env := &envOverlay{}
+			got := []int{1}
+			env.intList("HEAD_IMAGE_THUMBNAIL_SIZES", &got)
+			if (len(env.errs) > 0) != tt.wantErr {
+				t.Fatalf("errs = %v, wantErr %v", env.errs, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/security"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "只输出差异，不写入数据库")
+	file, rest := splitPositional(fs, args)
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	//-dry-run是bool flag，写成 -dry-run false 时false会被当成多余的参数
+	if file == "" || fs.NArg() > 0 {
+		return errors.New("用法: seed <file> [-dry-run]，关闭dry-run请写 -dry-run=false")
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("读取内容文件失败: %w", err)
+	}
+	//按扩展名判断格式，.yaml/.yml之外都按JSON解析
+	format := service.ContentFormatJSON
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		format = service.ContentFormatYAML
+	}
+	content, err := service.DecodeContentFile(raw, format)
+	if err != nil {
+		return err
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	contentService := service.NewContentService(repository.NewContentRepository(appState.DB), appState.Logger)
+	result, err := contentService.Import(ctx, content, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range result.Entries {
+		if entry.Action == service.ContentActionUnchanged {
+			continue
+		}
+		fmt.Printf("%-9s %-12s %s\n", entry.Action, entry.Type, entry.Name)
+		for _, change := range entry.Changes {
+			fmt.Printf("          %s: %q -> %q\n", change.Field, change.From, change.To)
+		}
+	}
+	fmt.Printf("新建%d 更新%d 未变%d\n", result.Created, result.Updated, result.Unchanged)
+	if result.DryRun {
+		fmt.Println("dry-run模式，未写入数据库")
+	}
+	return nil
+}
+
+func runExportUser(args []string) error {
+	fs := flag.NewFlagSet("export-user", flag.ContinueOnError)
+	output := fs.String("o", "", "输出文件，不填则输出到标准输出")
+	idStr, rest := splitPositional(fs, args)
+	if err := fs.Parse(rest); err != nil {
+		return err
+	}
+	userID, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil || userID == 0 {
+		return errors.New("用法: export-user <user_id> [-o <file>]")
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	profileService := service.NewProfileService(
+		repository.NewUserRepository(appState.DB),
+		repository.NewRelationRepository(appState.DB),
+		security.NewBcryptPasswordHasher(),
+		service.ProfileServiceOptions{},
+		appState.Logger,
+	)
+	data, err := profileService.ExportUserData(ctx, uint(userID))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
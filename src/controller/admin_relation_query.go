@@ -3,9 +3,10 @@ package controller
 import (
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	models "MuXi/2026-MuxiShooter-Backend/models"
+	utils "MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
 	"errors"
-
-	"gorm.io/gorm"
+	"net/url"
 )
 
 var (
@@ -14,7 +15,7 @@ var (
 )
 
 type UserRelationType string
-type relationQueryHandler func(userID uint, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error)
+type relationQueryHandler func(ctx context.Context, userID uint, values url.Values, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error)
 
 const (
 	UserRelationAchievement UserRelationType = "achievements"
@@ -23,11 +24,40 @@ const (
 	UserRelationCard        UserRelationType = "cards"
 )
 
+// relationListFilters 四类关联列表共用的筛选条件
+var relationListFilters = []utils.QueryFilter{
+	{Param: "is_complete", Column: "is_complete", Kind: utils.FilterBool},
+	{Param: "claimed", Column: "claimed", Kind: utils.FilterBool},
+	{Param: "created_at", Column: "created_at", Kind: utils.FilterTimeRange},
+	{Param: "updated_at", Column: "updated_at", Kind: utils.FilterTimeRange},
+}
+
+var relationListSorts = []utils.QuerySort{
+	{Param: "created_at", Column: "created_at", Field: "CreatedAt"},
+	{Param: "updated_at", Column: "updated_at", Field: "UpdatedAt"},
+}
+
 var relationQueryHandlers = map[UserRelationType]relationQueryHandler{
-	UserRelationAchievement: queryUserAchievements,
-	UserRelationSkill:       queryUserSkills,
-	UserRelationItem:        queryUserItems,
-	UserRelationCard:        queryUserCards,
+	UserRelationAchievement: newRelationQueryHandler(utils.ListQuerySpec{
+		Filters: relationListFilters,
+		Sorts:   relationListSorts,
+		Key:     utils.KeysetKey{Column: "achievement_id", Field: "AchievementID"},
+	}, "Achievement", dto.BuildCommonUserAchievementRelationList),
+	UserRelationSkill: newRelationQueryHandler(utils.ListQuerySpec{
+		Filters: append([]utils.QueryFilter{{Param: "skill_grade", Column: "skill_grade", Kind: utils.FilterUintRange}}, relationListFilters...),
+		Sorts:   append([]utils.QuerySort{{Param: "skill_grade", Column: "skill_grade", Field: "SkillGrade"}}, relationListSorts...),
+		Key:     utils.KeysetKey{Column: "skill_id", Field: "SkillID"},
+	}, "Skill", dto.BuildCommonUserSkillRelationList),
+	UserRelationItem: newRelationQueryHandler(utils.ListQuerySpec{
+		Filters: relationListFilters,
+		Sorts:   relationListSorts,
+		Key:     utils.KeysetKey{Column: "item_id", Field: "ItemID"},
+	}, "Item", dto.BuildCommonUserItemRelationList),
+	UserRelationCard: newRelationQueryHandler(utils.ListQuerySpec{
+		Filters: relationListFilters,
+		Sorts:   relationListSorts,
+		Key:     utils.KeysetKey{Column: "card_id", Field: "CardID"},
+	}, "Card", dto.BuildCommonUserCardRelationList),
 }
 
 func ParseUserRelationType(val string) (UserRelationType, error) {
@@ -38,84 +68,38 @@ func ParseUserRelationType(val string) (UserRelationType, error) {
 	return "", ErrUnsupportedRelationType
 }
 
-func QueryUserRelationByTypeWithUserID(userID uint, relationType UserRelationType, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
+// QueryUserRelationByTypeWithUserID values里的筛选和排序参数见relationQueryHandlers里各类型的白名单
+func QueryUserRelationByTypeWithUserID(ctx context.Context, userID uint, relationType UserRelationType, values url.Values, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error) {
 	if userID == 0 {
-		return nil, 0, ErrUserIDTypeInvalid
+		return nil, models.PageInfo{}, ErrUserIDTypeInvalid
 	}
 
 	handler, exists := relationQueryHandlers[relationType]
 	if !exists {
-		return nil, 0, ErrUnsupportedRelationType
-	}
-
-	return handler(userID, pagination)
-}
-
-func queryUserAchievements(userID uint, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
-	var records []models.UserAchievement
-	baseQuery := currentDB().Model(&models.UserAchievement{}).
-		Where("user_id = ?", userID).
-		Preload("Achievement")
-
-	total, err := executePaginatedQuery(baseQuery, pagination, &records)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return dto.BuildCommonUserAchievementRelationList(records), total, nil
-}
-
-func queryUserSkills(userID uint, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
-	var records []models.UserSkill
-	baseQuery := currentDB().Model(&models.UserSkill{}).
-		Where("user_id = ?", userID).
-		Preload("Skill")
-
-	total, err := executePaginatedQuery(baseQuery, pagination, &records)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return dto.BuildCommonUserSkillRelationList(records), total, nil
-}
-
-func queryUserItems(userID uint, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
-	var records []models.UserItem
-	baseQuery := currentDB().Model(&models.UserItem{}).
-		Where("user_id = ?", userID).
-		Preload("Item")
-
-	total, err := executePaginatedQuery(baseQuery, pagination, &records)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	return dto.BuildCommonUserItemRelationList(records), total, nil
-}
-
-func queryUserCards(userID uint, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
-	var records []models.UserCard
-	baseQuery := currentDB().Model(&models.UserCard{}).
-		Where("user_id = ?", userID).
-		Preload("Card")
-
-	total, err := executePaginatedQuery(baseQuery, pagination, &records)
-	if err != nil {
-		return nil, 0, err
+		return nil, models.PageInfo{}, ErrUnsupportedRelationType
 	}
 
-	return dto.BuildCommonUserCardRelationList(records), total, nil
+	return handler(ctx, userID, values, pagination)
 }
 
-func executePaginatedQuery(baseQuery *gorm.DB, pagination models.Pagination, dest interface{}) (int64, error) {
-	var total int64
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return 0, err
+// newRelationQueryHandler preload为关联的资源，结果里要带上资源名称等信息
+func newRelationQueryHandler[T any](spec utils.ListQuerySpec, preload string, buildList func([]T) []dto.CommonUserRelationData) relationQueryHandler {
+	return func(ctx context.Context, userID uint, values url.Values, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error) {
+		query, err := spec.Parse(values)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+
+		var records []T
+		baseQuery := currentDB(ctx).Model(new(T)).
+			Where("user_id = ?", userID).
+			Preload(preload)
+
+		info, err := query.Paginate(baseQuery, pagination, &records)
+		if err != nil {
+			return nil, models.PageInfo{}, err
+		}
+
+		return buildList(records), info, nil
 	}
-
-	if err := baseQuery.Limit(pagination.Limit).Offset(pagination.Offset).Find(dest).Error; err != nil {
-		return 0, err
-	}
-
-	return total, nil
 }
@@ -3,6 +3,7 @@ package controller
 import (
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	"MuXi/2026-MuxiShooter-Backend/middleware"
+	utils "MuXi/2026-MuxiShooter-Backend/utils"
 	"errors"
 	"net/http"
 	"strconv"
@@ -13,14 +14,24 @@ import (
 
 // @Summary		管理员按类型查询基础资源
 // @Description	通过query参数type查询skills/achievements/items/cards；支持分页与可选id精确查询
+// @Description	sort可选name/created_at/updated_at/id，前面加-表示倒序，默认按id正序
+// @Description	时间范围参数格式为RFC3339或2006-01-02，只写日期的_to包含当天
 // @Tags			admin-resource
 // @Produce		json
 // @Param			type		query		string												true	"资源类型(achievements/skills/items/cards)"
 // @Param			id			query		int													false	"资源ID，传入后优先精确查询"
 // @Param			name		query		string												false	"名称模糊搜索"
 // @Param			skill_group	query		string												false	"技能组模糊搜索(type=skills有效)"
+// @Param			prq_skill_id	query		int													false	"前置技能ID精确匹配(type=skills有效)"
+// @Param			created_at_from	query		string												false	"创建时间下限"
+// @Param			created_at_to	query		string												false	"创建时间上限"
+// @Param			updated_at_from	query		string												false	"更新时间下限"
+// @Param			updated_at_to	query		string												false	"更新时间上限"
+// @Param			sort		query		string												false	"排序，例如-created_at"
 // @Param			page		query		int													false	"页码，默认1"
 // @Param			page_size	query		int													false	"每页多少，默认20，最大100"
+// @Param			cursor		query		string												false	"游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param			with_total	query		bool												false	"是否返回total，页码模式默认true，游标模式默认false"
 // @Success		200			{object}	dto.Response{data=dto.CommonAdminResourcePageData}	"查询成功"
 // @Failure		400			{object}	dto.Response										"请求参数错误"
 // @Failure		401			{object}	dto.Response										"登录状态异常"
@@ -39,10 +50,10 @@ func GetResourcesByTypeForAdmin(c *gin.Context) {
 		return
 	}
 
-	pagination := middleware.GetPagination(c)
-	list, total, err := handler(c, pagination)
+	pagination := middleware.GetPagination(c, appPagination)
+	list, info, err := handler(c, pagination)
 	if err != nil {
-		if errors.Is(err, ErrResourceIDInvalid) {
+		if errors.Is(err, ErrResourceIDInvalid) || utils.IsListQueryError(err) {
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 			return
 		}
@@ -53,7 +64,7 @@ func GetResourcesByTypeForAdmin(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    http.StatusOK,
 		Message: "查询成功",
-		Data:    dto.CommonAdminResourcePageData{List: list, Total: total, Page: pagination.Page, PageSize: pagination.PageSize},
+		Data:    dto.CommonAdminResourcePageData{List: list, Total: info.Total, Page: pagination.Page, PageSize: pagination.PageSize, NextCursor: utils.EncodeCursor(info.Next)},
 	})
 }
 
@@ -186,7 +197,7 @@ func DeleteResourceByTypeForAdmin(c *gin.Context) {
 		return
 	}
 
-	if err = handler(req.ID); err != nil {
+	if err = handler(c.Request.Context(), req.ID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "目标资源不存在"})
 			return
@@ -201,13 +212,25 @@ func DeleteResourceByTypeForAdmin(c *gin.Context) {
 // @Summary		管理员按类型查询任意用户关联数据
 // @Description	通过query参数user_id和type查询指定用户在achievements/skills/items/cards中的关联数据
 // @Description	skills会返回skill_grade，其他类型没有这个字段
+// @Description	sort可选created_at/updated_at/id，skills额外支持skill_grade，前面加-表示倒序，默认按资源id正序
 // @Description	data.list: []dto.CommonUserRelationData
 // @Tags			admin-resource
 // @Produce		json
 // @Param			user_id		query		int													true	"用户ID"
 // @Param			type		query		string												true	"关联类型(achievements/skills/items/cards)"
+// @Param			is_complete	query		bool												false	"是否完成"
+// @Param			claimed		query		bool												false	"是否已领取"
+// @Param			skill_grade_min	query		int													false	"技能等级下限(type=skills有效)"
+// @Param			skill_grade_max	query		int													false	"技能等级上限(type=skills有效)"
+// @Param			created_at_from	query		string												false	"获得时间下限，格式为RFC3339或2006-01-02"
+// @Param			created_at_to	query		string												false	"获得时间上限，只写日期时包含当天"
+// @Param			updated_at_from	query		string												false	"更新时间下限"
+// @Param			updated_at_to	query		string												false	"更新时间上限"
+// @Param			sort		query		string												false	"排序，例如-created_at"
 // @Param			page		query		int													false	"页码，默认1"
 // @Param			page_size	query		int													false	"每页多少，默认20，最大100"
+// @Param			cursor		query		string												false	"游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param			with_total	query		bool												false	"是否返回total，页码模式默认true，游标模式默认false"
 // @Success		200			{object}	dto.Response{data=dto.CommonUserRelationPageData}	"查询成功"
 // @Failure		400			{object}	dto.Response										"请求参数错误"
 // @Failure		401			{object}	dto.Response										"登录状态异常"
@@ -238,10 +261,10 @@ func GetUserRelationsByTypeForAdmin(c *gin.Context) {
 		return
 	}
 
-	pagination := middleware.GetPagination(c)
-	list, total, err := QueryUserRelationByTypeWithUserID(uint(parsedID), relationType, pagination)
+	pagination := middleware.GetPagination(c, appPagination)
+	list, info, err := QueryUserRelationByTypeWithUserID(c.Request.Context(), uint(parsedID), relationType, c.Request.URL.Query(), pagination)
 	if err != nil {
-		if errors.Is(err, ErrUnsupportedRelationType) || errors.Is(err, ErrUserIDTypeInvalid) {
+		if errors.Is(err, ErrUnsupportedRelationType) || errors.Is(err, ErrUserIDTypeInvalid) || utils.IsListQueryError(err) {
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 			return
 		}
@@ -252,6 +275,6 @@ func GetUserRelationsByTypeForAdmin(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    http.StatusOK,
 		Message: "查询成功",
-		Data:    dto.CommonUserRelationPageData{List: list, Total: total, Page: pagination.Page, PageSize: pagination.PageSize},
+		Data:    dto.CommonUserRelationPageData{List: list, Total: info.Total, Page: pagination.Page, PageSize: pagination.PageSize, NextCursor: utils.EncodeCursor(info.Next)},
 	})
 }
@@ -2,8 +2,11 @@ package controller
 
 import (
 	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
 	models "MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
 	utils "MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -20,16 +23,41 @@ var (
 	ErrInvalidRequestBody   = errors.New("请求参数错误")
 )
 
-type adminResourceQueryHandler func(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, int64, error)
+type adminResourceQueryHandler func(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, models.PageInfo, error)
 type adminResourceCreateHandler func(c *gin.Context) (dto.CommonAdminResourceData, error)
 type adminResourceUpdateHandler func(c *gin.Context) (dto.CommonAdminResourceData, error)
-type adminResourceDeleteHandler func(id uint) error
+type adminResourceDeleteHandler func(ctx context.Context, id uint) error
+
+// adminResourceListSpec 四类资源列表共用的筛选/排序白名单
+var adminResourceListSpec = utils.ListQuerySpec{
+	Filters: []utils.QueryFilter{
+		{Param: "name", Column: "name", Kind: utils.FilterLike},
+		{Param: "created_at", Column: "created_at", Kind: utils.FilterTimeRange},
+		{Param: "updated_at", Column: "updated_at", Kind: utils.FilterTimeRange},
+	},
+	Sorts: []utils.QuerySort{
+		{Param: "name", Column: "name", Field: "Name"},
+		{Param: "created_at", Column: "created_at", Field: "CreatedAt"},
+		{Param: "updated_at", Column: "updated_at", Field: "UpdatedAt"},
+	},
+	Key: utils.KeysetKey{Column: "id", Field: "ID"},
+}
+
+// adminSkillListSpec 技能额外支持按技能组和前置技能筛选
+var adminSkillListSpec = utils.ListQuerySpec{
+	Filters: append([]utils.QueryFilter{
+		{Param: "skill_group", Column: "skill_group", Kind: utils.FilterLike},
+		{Param: "prq_skill_id", Column: "prq_skill_id", Kind: utils.FilterUint},
+	}, adminResourceListSpec.Filters...),
+	Sorts: adminResourceListSpec.Sorts,
+	Key:   adminResourceListSpec.Key,
+}
 
 var adminResourceQueryHandlers = map[UserRelationType]adminResourceQueryHandler{
-	UserRelationAchievement: adminQueryAchievements,
-	UserRelationSkill:       adminQuerySkills,
-	UserRelationItem:        adminQueryItems,
-	UserRelationCard:        adminQueryCards,
+	UserRelationAchievement: newAdminResourceQueryHandler(adminResourceListSpec, dto.BuildCommonAdminAchievementData, dto.BuildCommonAdminAchievementList),
+	UserRelationSkill:       newAdminResourceQueryHandler(adminSkillListSpec, dto.BuildCommonAdminSkillData, dto.BuildCommonAdminSkillList),
+	UserRelationItem:        newAdminResourceQueryHandler(adminResourceListSpec, dto.BuildCommonAdminItemData, dto.BuildCommonAdminItemList),
+	UserRelationCard:        newAdminResourceQueryHandler(adminResourceListSpec, dto.BuildCommonAdminCardData, dto.BuildCommonAdminCardList),
 }
 
 var adminResourceCreateHandlers = map[UserRelationType]adminResourceCreateHandler{
@@ -73,144 +101,42 @@ func parseOptionalID(c *gin.Context) (uint, bool, error) {
 	return uint(idValue), true, nil
 }
 
-func adminQueryAchievements(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, int64, error) {
-	id, hasID, err := parseOptionalID(c)
-	if err != nil {
-		return nil, 0, err
-	}
-	name := utils.SqlSafeLikeKeyword(c.Query("name"))
-
-	if hasID {
-		var record models.Achievement
-		err = currentDB().First(&record, id).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return []dto.CommonAdminResourceData{}, 0, nil
-		}
-		if err != nil {
-			return nil, 0, err
-		}
-		return []dto.CommonAdminResourceData{dto.BuildCommonAdminAchievementData(record)}, 1, nil
-	}
-
-	db := currentDB().Model(&models.Achievement{})
-	if name != "" {
-		db = db.Where("name LIKE ?", "%"+name+"%")
-	}
-	var total int64
-	if err = db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	var list []models.Achievement
-	if err = db.Limit(pagination.Limit).Offset(pagination.Offset).Find(&list).Error; err != nil {
-		return nil, 0, err
-	}
-	return dto.BuildCommonAdminAchievementList(list), total, nil
+// singleResultPageInfo 按id精确查询时的分页信息，总数就是查到的条数
+func singleResultPageInfo(total int64) models.PageInfo {
+	return models.PageInfo{Total: &total}
 }
 
-func adminQuerySkills(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, int64, error) {
-	id, hasID, err := parseOptionalID(c)
-	if err != nil {
-		return nil, 0, err
-	}
-	name := utils.SqlSafeLikeKeyword(c.Query("name"))
-	skillGroup := utils.SqlSafeLikeKeyword(c.Query("skill_group"))
-
-	if hasID {
-		var record models.Skill
-		err = currentDB().First(&record, id).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return []dto.CommonAdminResourceData{}, 0, nil
-		}
+// newAdminResourceQueryHandler 传了id时按id精确查询，否则按spec筛选排序后分页
+func newAdminResourceQueryHandler[T any](spec utils.ListQuerySpec, buildOne func(T) dto.CommonAdminResourceData, buildList func([]T) []dto.CommonAdminResourceData) adminResourceQueryHandler {
+	return func(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, models.PageInfo, error) {
+		id, hasID, err := parseOptionalID(c)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return []dto.CommonAdminResourceData{dto.BuildCommonAdminSkillData(record)}, 1, nil
-	}
-
-	db := currentDB().Model(&models.Skill{})
-	if name != "" {
-		db = db.Where("name LIKE ?", "%"+name+"%")
-	}
-	if skillGroup != "" {
-		db = db.Where("skill_group LIKE ?", "%"+skillGroup+"%")
-	}
-	var total int64
-	if err = db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	var list []models.Skill
-	if err = db.Limit(pagination.Limit).Offset(pagination.Offset).Find(&list).Error; err != nil {
-		return nil, 0, err
-	}
-	return dto.BuildCommonAdminSkillList(list), total, nil
-}
-
-func adminQueryItems(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, int64, error) {
-	id, hasID, err := parseOptionalID(c)
-	if err != nil {
-		return nil, 0, err
-	}
-	name := utils.SqlSafeLikeKeyword(c.Query("name"))
 
-	if hasID {
-		var record models.Item
-		err = currentDB().First(&record, id).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return []dto.CommonAdminResourceData{}, 0, nil
-		}
-		if err != nil {
-			return nil, 0, err
+		if hasID {
+			var record T
+			err = currentDB(c.Request.Context()).First(&record, id).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return []dto.CommonAdminResourceData{}, singleResultPageInfo(0), nil
+			}
+			if err != nil {
+				return nil, models.PageInfo{}, err
+			}
+			return []dto.CommonAdminResourceData{buildOne(record)}, singleResultPageInfo(1), nil
 		}
-		return []dto.CommonAdminResourceData{dto.BuildCommonAdminItemData(record)}, 1, nil
-	}
-
-	db := currentDB().Model(&models.Item{})
-	if name != "" {
-		db = db.Where("name LIKE ?", "%"+name+"%")
-	}
-	var total int64
-	if err = db.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-	var list []models.Item
-	if err = db.Limit(pagination.Limit).Offset(pagination.Offset).Find(&list).Error; err != nil {
-		return nil, 0, err
-	}
-	return dto.BuildCommonAdminItemList(list), total, nil
-}
-
-func adminQueryCards(c *gin.Context, pagination models.Pagination) ([]dto.CommonAdminResourceData, int64, error) {
-	id, hasID, err := parseOptionalID(c)
-	if err != nil {
-		return nil, 0, err
-	}
-	name := utils.SqlSafeLikeKeyword(c.Query("name"))
 
-	if hasID {
-		var record models.Card
-		err = currentDB().First(&record, id).Error
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return []dto.CommonAdminResourceData{}, 0, nil
+		query, err := spec.Parse(c.Request.URL.Query())
+		if err != nil {
+			return nil, models.PageInfo{}, err
 		}
+		var list []T
+		info, err := query.Paginate(currentDB(c.Request.Context()).Model(new(T)), pagination, &list)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return []dto.CommonAdminResourceData{dto.BuildCommonAdminCardData(record)}, 1, nil
-	}
-
-	db := currentDB().Model(&models.Card{})
-	if name != "" {
-		db = db.Where("name LIKE ?", "%"+name+"%")
-	}
-	var total int64
-	if err = db.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return buildList(list), info, nil
 	}
-	var list []models.Card
-	if err = db.Limit(pagination.Limit).Offset(pagination.Offset).Find(&list).Error; err != nil {
-		return nil, 0, err
-	}
-	return dto.BuildCommonAdminCardList(list), total, nil
 }
 
 func adminCreateAchievement(c *gin.Context) (dto.CommonAdminResourceData, error) {
@@ -218,11 +144,11 @@ func adminCreateAchievement(c *gin.Context) (dto.CommonAdminResourceData, error)
 	if err := c.ShouldBindJSON(&req); err != nil {
 		return dto.CommonAdminResourceData{}, fmt.Errorf("%w:%v", ErrInvalidRequestBody, err)
 	}
-	if err := ensureUniqueResourceName(UserRelationAchievement, req.Name, nil); err != nil {
+	if err := ensureUniqueResourceName(c.Request.Context(), UserRelationAchievement, req.Name, nil); err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	record := models.Achievement{Name: req.Name, Description: req.Description}
-	if err := currentDB().Create(&record).Error; err != nil {
+	if err := currentDB(c.Request.Context()).Create(&record).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminAchievementData(record), nil
@@ -233,11 +159,11 @@ func adminCreateSkill(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		return dto.CommonAdminResourceData{}, fmt.Errorf("%w:%v", ErrInvalidRequestBody, err)
 	}
-	if err := ensureUniqueResourceName(UserRelationSkill, req.Name, nil); err != nil {
+	if err := ensureUniqueResourceName(c.Request.Context(), UserRelationSkill, req.Name, nil); err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	record := models.Skill{Name: req.Name, Description: req.Description, SkillGroup: req.SkillGroup, PrqSkillId: req.PrqSkillID}
-	if err := currentDB().Create(&record).Error; err != nil {
+	if err := currentDB(c.Request.Context()).Create(&record).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminSkillData(record), nil
@@ -248,11 +174,11 @@ func adminCreateItem(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		return dto.CommonAdminResourceData{}, fmt.Errorf("%w:%v", ErrInvalidRequestBody, err)
 	}
-	if err := ensureUniqueResourceName(UserRelationItem, req.Name, nil); err != nil {
+	if err := ensureUniqueResourceName(c.Request.Context(), UserRelationItem, req.Name, nil); err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	record := models.Item{Name: req.Name, Description: req.Description}
-	if err := currentDB().Create(&record).Error; err != nil {
+	if err := currentDB(c.Request.Context()).Create(&record).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminItemData(record), nil
@@ -263,11 +189,11 @@ func adminCreateCard(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		return dto.CommonAdminResourceData{}, fmt.Errorf("%w:%v", ErrInvalidRequestBody, err)
 	}
-	if err := ensureUniqueResourceName(UserRelationCard, req.Name, nil); err != nil {
+	if err := ensureUniqueResourceName(c.Request.Context(), UserRelationCard, req.Name, nil); err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	record := models.Card{Name: req.Name, Description: req.Description}
-	if err := currentDB().Create(&record).Error; err != nil {
+	if err := currentDB(c.Request.Context()).Create(&record).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminCardData(record), nil
@@ -280,7 +206,7 @@ func adminUpdateAchievement(c *gin.Context) (dto.CommonAdminResourceData, error)
 	}
 	updates := map[string]interface{}{}
 	if req.Name != nil {
-		if err := ensureUniqueResourceName(UserRelationAchievement, *req.Name, &req.ID); err != nil {
+		if err := ensureUniqueResourceName(c.Request.Context(), UserRelationAchievement, *req.Name, &req.ID); err != nil {
 			return dto.CommonAdminResourceData{}, err
 		}
 		updates["name"] = *req.Name
@@ -291,7 +217,7 @@ func adminUpdateAchievement(c *gin.Context) (dto.CommonAdminResourceData, error)
 	if len(updates) == 0 {
 		return dto.CommonAdminResourceData{}, ErrNoUpdateFields
 	}
-	return doUpdateAchievement(req.ID, updates)
+	return doUpdateAchievement(c.Request.Context(), req.ID, updates)
 }
 
 func adminUpdateSkill(c *gin.Context) (dto.CommonAdminResourceData, error) {
@@ -301,7 +227,7 @@ func adminUpdateSkill(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	}
 	updates := map[string]interface{}{}
 	if req.Name != nil {
-		if err := ensureUniqueResourceName(UserRelationSkill, *req.Name, &req.ID); err != nil {
+		if err := ensureUniqueResourceName(c.Request.Context(), UserRelationSkill, *req.Name, &req.ID); err != nil {
 			return dto.CommonAdminResourceData{}, err
 		}
 		updates["name"] = *req.Name
@@ -318,7 +244,7 @@ func adminUpdateSkill(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if len(updates) == 0 {
 		return dto.CommonAdminResourceData{}, ErrNoUpdateFields
 	}
-	return doUpdateSkill(req.ID, updates)
+	return doUpdateSkill(c.Request.Context(), req.ID, updates)
 }
 
 func adminUpdateItem(c *gin.Context) (dto.CommonAdminResourceData, error) {
@@ -328,7 +254,7 @@ func adminUpdateItem(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	}
 	updates := map[string]interface{}{}
 	if req.Name != nil {
-		if err := ensureUniqueResourceName(UserRelationItem, *req.Name, &req.ID); err != nil {
+		if err := ensureUniqueResourceName(c.Request.Context(), UserRelationItem, *req.Name, &req.ID); err != nil {
 			return dto.CommonAdminResourceData{}, err
 		}
 		updates["name"] = *req.Name
@@ -339,7 +265,7 @@ func adminUpdateItem(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if len(updates) == 0 {
 		return dto.CommonAdminResourceData{}, ErrNoUpdateFields
 	}
-	return doUpdateItem(req.ID, updates)
+	return doUpdateItem(c.Request.Context(), req.ID, updates)
 }
 
 func adminUpdateCard(c *gin.Context) (dto.CommonAdminResourceData, error) {
@@ -349,7 +275,7 @@ func adminUpdateCard(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	}
 	updates := map[string]interface{}{}
 	if req.Name != nil {
-		if err := ensureUniqueResourceName(UserRelationCard, *req.Name, &req.ID); err != nil {
+		if err := ensureUniqueResourceName(c.Request.Context(), UserRelationCard, *req.Name, &req.ID); err != nil {
 			return dto.CommonAdminResourceData{}, err
 		}
 		updates["name"] = *req.Name
@@ -360,89 +286,89 @@ func adminUpdateCard(c *gin.Context) (dto.CommonAdminResourceData, error) {
 	if len(updates) == 0 {
 		return dto.CommonAdminResourceData{}, ErrNoUpdateFields
 	}
-	return doUpdateCard(req.ID, updates)
+	return doUpdateCard(c.Request.Context(), req.ID, updates)
 }
 
-func doUpdateAchievement(id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
+func doUpdateAchievement(ctx context.Context, id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
 	var record models.Achievement
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().Model(&record).Updates(updates).Error; err != nil {
+	if err := currentDB(ctx).Model(&record).Updates(updates).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminAchievementData(record), nil
 }
 
-func doUpdateSkill(id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
+func doUpdateSkill(ctx context.Context, id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
 	var record models.Skill
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().Model(&record).Updates(updates).Error; err != nil {
+	if err := currentDB(ctx).Model(&record).Updates(updates).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminSkillData(record), nil
 }
 
-func doUpdateItem(id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
+func doUpdateItem(ctx context.Context, id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
 	var record models.Item
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().Model(&record).Updates(updates).Error; err != nil {
+	if err := currentDB(ctx).Model(&record).Updates(updates).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminItemData(record), nil
 }
 
-func doUpdateCard(id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
+func doUpdateCard(ctx context.Context, id uint, updates map[string]interface{}) (dto.CommonAdminResourceData, error) {
 	var record models.Card
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().Model(&record).Updates(updates).Error; err != nil {
+	if err := currentDB(ctx).Model(&record).Updates(updates).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
-	if err := currentDB().First(&record, id).Error; err != nil {
+	if err := currentDB(ctx).First(&record, id).Error; err != nil {
 		return dto.CommonAdminResourceData{}, err
 	}
 	return dto.BuildCommonAdminCardData(record), nil
 }
 
-func adminDeleteAchievement(id uint) error {
-	return doDeleteByID(&models.Achievement{}, id)
+func adminDeleteAchievement(ctx context.Context, id uint) error {
+	return doDeleteByID(ctx, &models.Achievement{}, UserRelationAchievement, id)
 }
 
-func adminDeleteSkill(id uint) error {
-	return doDeleteByID(&models.Skill{}, id)
+func adminDeleteSkill(ctx context.Context, id uint) error {
+	return doDeleteByID(ctx, &models.Skill{}, UserRelationSkill, id)
 }
 
-func adminDeleteItem(id uint) error {
-	return doDeleteByID(&models.Item{}, id)
+func adminDeleteItem(ctx context.Context, id uint) error {
+	return doDeleteByID(ctx, &models.Item{}, UserRelationItem, id)
 }
 
-func adminDeleteCard(id uint) error {
-	return doDeleteByID(&models.Card{}, id)
+func adminDeleteCard(ctx context.Context, id uint) error {
+	return doDeleteByID(ctx, &models.Card{}, UserRelationCard, id)
 }
 
-func ensureUniqueResourceName(resourceType UserRelationType, name string, excludeID *uint) error {
+func ensureUniqueResourceName(ctx context.Context, resourceType UserRelationType, name string, excludeID *uint) error {
 	if name == "" {
 		return nil
 	}
 
 	var count int64
 	queryByModel := func(model interface{}) error {
-		db := currentDB().Model(model).Where("name = ?", name)
+		db := currentDB(ctx).Model(model).Where("name = ?", name)
 		if excludeID != nil && *excludeID > 0 {
 			db = db.Where("id <> ?", *excludeID)
 		}
@@ -471,13 +397,20 @@ func ensureUniqueResourceName(resourceType UserRelationType, name string, exclud
 	return nil
 }
 
-func doDeleteByID(model interface{}, id uint) error {
-	result := currentDB().Delete(model, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
+// doDeleteByID 资源删除后用户的关联会被级联删除，所以先给拥有者记删除变更
+func doDeleteByID(ctx context.Context, model interface{}, relationType UserRelationType, id uint) error {
+	return currentDB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repository.RecordResourceDeleted(tx, service.UserRelationType(relationType), id); err != nil {
+			return err
+		}
+
+		result := tx.Delete(model, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
 }
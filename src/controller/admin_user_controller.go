@@ -3,12 +3,14 @@ package controller
 import (
 	config "MuXi/2026-MuxiShooter-Backend/config"
 	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
 	"MuXi/2026-MuxiShooter-Backend/middleware"
 	models "MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
 	utils "MuXi/2026-MuxiShooter-Backend/utils"
 	"errors"
-	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,8 +18,29 @@ import (
 
 const initialAdminUserID uint = 1
 
+// userListSpec 用户列表允许的筛选和排序，coin相关的列既能按范围筛也能排序
+var userListSpec = utils.ListQuerySpec{
+	Filters: []utils.QueryFilter{
+		{Param: "username", Column: "username", Kind: utils.FilterLike},
+		{Param: "group", Column: "group", Kind: utils.FilterLike},
+		{Param: "group_exact", Column: "group", Kind: utils.FilterExact},
+		{Param: "created_at", Column: "created_at", Kind: utils.FilterTimeRange},
+		{Param: "strength_coin", Column: "strength_coin", Kind: utils.FilterUintRange},
+		{Param: "select_coin", Column: "select_coin", Kind: utils.FilterUintRange},
+	},
+	Sorts: []utils.QuerySort{
+		{Param: "username", Column: "username", Field: "Username"},
+		{Param: "created_at", Column: "created_at", Field: "CreatedAt"},
+		{Param: "updated_at", Column: "updated_at", Field: "UpdatedAt"},
+		{Param: "strength_coin", Column: "strength_coin", Field: "StrengthCoin"},
+		{Param: "select_coin", Column: "select_coin", Field: "SelectCoin"},
+	},
+	Key: utils.KeysetKey{Column: "id", Field: "ID"},
+}
+
 // @Summary		获取用户列表
-// @Description	注: 管理员可用，查询结果是多重模糊搜索叠加的效果
+// @Description	注: 管理员可用，查询结果是多个筛选条件叠加的效果，username和group是模糊搜索
+// @Description	sort可选username/created_at/updated_at/strength_coin/select_coin/id，前面加-表示倒序，默认按id正序
 // @Description	以及页码不输入或不合规范自动为第一页，每页多少不输入默认20，最多100
 // @Description	如果查询结果不存在则返回切片为空
 // @Description	用了id查询的话就一定只是一个确定的，而不是模糊搜索，其他参数就没用了（分页也是）
@@ -25,29 +48,39 @@ const initialAdminUserID uint = 1
 // @Produce		json
 // @Param			user_id		query		int										false	"用户id"
 // @Param			username	query		string									false	"用户名"
-// @Param			group		query		string									false	"权限组(user/admin)"
+// @Param			group		query		string									false	"权限组(user/admin)，模糊匹配"
+// @Param			group_exact	query		string									false	"权限组，精确匹配"
+// @Param			created_at_from	query		string									false	"注册时间下限，格式为RFC3339或2006-01-02"
+// @Param			created_at_to	query		string									false	"注册时间上限，只写日期时包含当天"
+// @Param			strength_coin_min	query		int										false	"strength_coin下限"
+// @Param			strength_coin_max	query		int										false	"strength_coin上限"
+// @Param			select_coin_min	query		int										false	"select_coin下限"
+// @Param			select_coin_max	query		int										false	"select_coin上限"
+// @Param			sort		query		string									false	"排序，例如-strength_coin"
 // @Param			page		query		int										false	"页码，默认1"
 // @Param			page_size	query		int										false	"每页多少，默认20，最大100"
+// @Param			cursor		query		string									false	"游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param			with_total	query		bool									false	"是否返回total，页码模式默认true，游标模式默认false"
 // @Success		200			{object}	dto.Response{data=dto.PaginatedData}	"查询成功"
+// @Failure		400			{object}	dto.Response							"请求参数错误"
 // @Failure		401			{object}	dto.Response							"登录状态异常"
 // @Failure		500			{object}	dto.Response							"数据库查询失败"
 // @Router			/api/admin/get/getusers [get]
 func GetUsers(c *gin.Context) {
 	var err error
-	pagination := middleware.GetPagination(c)
+	pagination := middleware.GetPagination(c, appPagination)
 
 	id := c.Query("user_id")
-	username := utils.SqlSafeLikeKeyword(c.Query("username"))
-	group := utils.SqlSafeLikeKeyword(c.Query("group"))
 
 	var users []models.User
-	var total int64
+	var info models.PageInfo
 
 	if id != "" {
 		var user models.User
-		pagination = models.Pagination{Page: config.DefaultPage, PageSize: config.DefaultPageSize, Limit: config.DefaultPageSize, Offset: 0}
-		result := currentDB().First(&user, id)
+		pagination = models.Pagination{Page: config.DefaultPage, PageSize: pagination.PageSize, Limit: pagination.PageSize, Offset: 0}
+		result := currentDB(c.Request.Context()).First(&user, id)
 		err = result.Error
+		var total int64
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				total = 0
@@ -61,19 +94,29 @@ func GetUsers(c *gin.Context) {
 		if total != 0 {
 			users = append(users, user)
 		}
+		info.Total = &total
 	} else {
-		result := currentDB().Model(&models.User{})
-		if username != "" {
-			result = result.Where("username LIKE ?", "%"+username+"%")
+		query, parseErr := userListSpec.Parse(c.Request.URL.Query())
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: parseErr.Error()})
+			return
 		}
-		if group != "" {
-			result = result.Where("group LIKE ?", "%"+group+"%")
+		info, err = query.Paginate(currentDB(c.Request.Context()).Model(&models.User{}), pagination, &users)
+		if err != nil {
+			if utils.IsListQueryError(err) {
+				c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+			return
 		}
-		result.Count(&total)
-		result.Limit(pagination.Limit).Offset(pagination.Offset).Find(&users)
 	}
 
-	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: dto.PaginatedData{List: users, Total: total, Page: pagination.Page, PageSize: pagination.PageSize}})
+	for i := range users {
+		users[i].HeadImagePath = dto.FileURL(users[i].HeadImagePath)
+		users[i].HeadImageThumbnails = dto.FileURLs(users[i].HeadImageThumbnails)
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: dto.PaginatedData{List: users, Total: info.Total, Page: pagination.Page, PageSize: pagination.PageSize, NextCursor: utils.EncodeCursor(info.Next)}})
 }
 
 // @Summary		管理员删除用户
@@ -113,7 +156,7 @@ func DeleteUserByAdmin(c *gin.Context) {
 	}
 
 	var targetUser models.User
-	if err := currentDB().First(&targetUser, req.UserID).Error; err != nil {
+	if err := currentDB(c.Request.Context()).First(&targetUser, req.UserID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "目标用户不存在"})
 			return
@@ -127,12 +170,18 @@ func DeleteUserByAdmin(c *gin.Context) {
 		return
 	}
 
-	tx := currentDB().Begin()
+	tx := currentDB(c.Request.Context()).Begin()
 	if tx.Error != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "开启事务失败：" + tx.Error.Error()})
 		return
 	}
 
+	//头像文件的引用在这里释放，文件由垃圾回收删除
+	if err := repository.ReleaseUserHeadImage(tx, &targetUser); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "删除用户失败：" + err.Error()})
+		return
+	}
 	if err := tx.Delete(&targetUser).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "删除用户失败：" + err.Error()})
@@ -144,12 +193,11 @@ func DeleteUserByAdmin(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "提交删除请求失败：" + err.Error()})
 		return
 	}
-
-	if targetUser.HeadImagePath != "" && targetUser.HeadImagePath != config.DefaultHeadImagePath {
-		if removeErr := utils.RemoveFile(targetUser.HeadImagePath); removeErr != nil {
-			log.Printf("删除用户头像文件失败(user_id:%d,path:%s): %v", targetUser.ID, targetUser.HeadImagePath, removeErr)
-		}
-	}
+	publishEvent(targetUser.ID, dto.PushEvent{
+		Type: service.EventBanned,
+		Data: dto.SessionEventData{Reason: "账号已被管理员删除"},
+		At:   time.Now(),
+	})
 
 	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "删除用户成功"})
 }
@@ -196,7 +244,7 @@ func UpdateUserGroupByAdmin(c *gin.Context) {
 	}
 
 	var targetUser models.User
-	tx := currentDB().Begin()
+	tx := currentDB(c.Request.Context()).Begin()
 	if tx.Error != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "开启事务失败：" + tx.Error.Error()})
 		return
@@ -212,11 +260,19 @@ func UpdateUserGroupByAdmin(c *gin.Context) {
 		return
 	}
 
-	if err := tx.Model(&targetUser).Update("group", req.NewGroup).Error; err != nil {
+	if err := tx.Model(&targetUser).Updates(map[string]interface{}{
+		"group":   req.NewGroup,
+		"version": gorm.Expr("version + 1"),
+	}).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "修改权限组失败：" + err.Error()})
 		return
 	}
+	if err := repository.RecordProfileChange(tx, targetUser.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "记录变更失败：" + err.Error()})
+		return
+	}
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
@@ -36,7 +36,7 @@ func Register(c *gin.Context) {
 	}
 
 	var searchedUser models.User
-	err = currentDB().Where("username = ?", req.UserName).First(&searchedUser).Error
+	err = currentDB(c.Request.Context()).Where("username = ?", req.UserName).First(&searchedUser).Error
 	if err == nil {
 		c.JSON(http.StatusConflict, dto.Response{
 			Code:    http.StatusConflict,
@@ -66,9 +66,10 @@ func Register(c *gin.Context) {
 		Password:      hashedPsw,
 		Group:         "user",
 		HeadImagePath: config.DefaultHeadImagePath,
+		Version:       1,
 	}
 
-	if err = currentDB().Create(&newUser).Error; err != nil {
+	if err = currentDB(c.Request.Context()).Create(&newUser).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    http.StatusInternalServerError,
 			Message: "注册用户失败：" + err.Error(),
@@ -76,7 +77,7 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	token, expirationTime, err := utils.GenerateToken(newUser, currentJWTSecret())
+	token, expirationTime, err := utils.GenerateToken(newUser, currentJWTSecret(), currentTokenTTL())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    http.StatusInternalServerError,
@@ -90,12 +91,14 @@ func Register(c *gin.Context) {
 		Message: "注册用户成功",
 		Data: dto.AuthData{
 			User: dto.CommonUserData{
-				UserID:        newUser.ID,
-				Username:      newUser.Username,
-				Group:         newUser.Group,
-				HeadImagePath: newUser.HeadImagePath,
-				StrengthCoin:  newUser.StrengthCoin,
-				SelectCoin:    newUser.SelectCoin,
+				UserID:              newUser.ID,
+				Username:            newUser.Username,
+				Group:               newUser.Group,
+				HeadImagePath:       dto.FileURL(newUser.HeadImagePath),
+				HeadImageThumbnails: dto.FileURLs(newUser.HeadImageThumbnails),
+				StrengthCoin:        newUser.StrengthCoin,
+				SelectCoin:          newUser.SelectCoin,
+				Version:             newUser.Version,
 			},
 			Token:     token,
 			ExpiresAt: expirationTime.Unix(),
@@ -127,7 +130,7 @@ func Login(c *gin.Context) {
 	}
 
 	var user models.User
-	err = currentDB().Where("username = ?", req.UserName).First(&user).Error
+	err = currentDB(c.Request.Context()).Where("username = ?", req.UserName).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusForbidden, dto.Response{
 			Code:    http.StatusForbidden,
@@ -152,7 +155,7 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	token, expirationTime, err := utils.GenerateToken(user, currentJWTSecret())
+	token, expirationTime, err := utils.GenerateToken(user, currentJWTSecret(), currentTokenTTL())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.Response{
 			Code:    http.StatusInternalServerError,
@@ -166,12 +169,14 @@ func Login(c *gin.Context) {
 		Message: "登录成功",
 		Data: dto.AuthData{
 			User: dto.CommonUserData{
-				UserID:        user.ID,
-				Username:      user.Username,
-				Group:         user.Group,
-				HeadImagePath: user.HeadImagePath,
-				StrengthCoin:  user.StrengthCoin,
-				SelectCoin:    user.SelectCoin,
+				UserID:              user.ID,
+				Username:            user.Username,
+				Group:               user.Group,
+				HeadImagePath:       dto.FileURL(user.HeadImagePath),
+				HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+				StrengthCoin:        user.StrengthCoin,
+				SelectCoin:          user.SelectCoin,
+				Version:             user.Version,
 			},
 			Token:     token,
 			ExpiresAt: expirationTime.Unix(),
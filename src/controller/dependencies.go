@@ -1,19 +1,29 @@
 package controller
 
 import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrControllerDBNotInitialized        = errors.New("controller db is not initialized")
-	ErrControllerJWTSecretNotInitialized = errors.New("controller jwt secret is not initialized")
+	ErrControllerDBNotInitialized         = errors.New("controller db is not initialized")
+	ErrControllerJWTSecretNotInitialized  = errors.New("controller jwt secret is not initialized")
+	ErrControllerTokenTTLNotInitialized   = errors.New("controller token ttl is not initialized")
+	ErrControllerPaginationNotInitialized = errors.New("controller pagination settings are not initialized")
 )
 
 var (
-	appDB        *gorm.DB
-	appJWTSecret []byte
+	appDB             *gorm.DB
+	appJWTSecret      []byte
+	appTokenTTL       time.Duration
+	appEventPublisher service.EventPublisher
+	appPagination     config.PaginationSettings
 )
 
 func SetDB(db *gorm.DB) {
@@ -24,6 +34,19 @@ func SetJWTSecret(secret []byte) {
 	appJWTSecret = secret
 }
 
+func SetTokenTTL(ttl time.Duration) {
+	appTokenTTL = ttl
+}
+
+func SetPaginationSettings(settings config.PaginationSettings) {
+	appPagination = settings
+}
+
+// SetEventPublisher 可选，不设置时不推送事件
+func SetEventPublisher(publisher service.EventPublisher) {
+	appEventPublisher = publisher
+}
+
 func ValidateDependencies() error {
 	if appDB == nil {
 		return ErrControllerDBNotInitialized
@@ -31,14 +54,21 @@ func ValidateDependencies() error {
 	if len(appJWTSecret) == 0 {
 		return ErrControllerJWTSecretNotInitialized
 	}
+	if appTokenTTL <= 0 {
+		return ErrControllerTokenTTLNotInitialized
+	}
+	if appPagination.DefaultPageSize <= 0 {
+		return ErrControllerPaginationNotInitialized
+	}
 	return nil
 }
 
-func currentDB() *gorm.DB {
+// currentDB 带上请求的ctx，SQL日志里才有request_id
+func currentDB(ctx context.Context) *gorm.DB {
 	if appDB == nil {
 		panic(ErrControllerDBNotInitialized.Error())
 	}
-	return appDB
+	return appDB.WithContext(ctx)
 }
 
 func currentJWTSecret() []byte {
@@ -47,3 +77,16 @@ func currentJWTSecret() []byte {
 	}
 	return appJWTSecret
 }
+
+func currentTokenTTL() time.Duration {
+	if appTokenTTL <= 0 {
+		panic(ErrControllerTokenTTLNotInitialized.Error())
+	}
+	return appTokenTTL
+}
+
+func publishEvent(userID uint, event dto.PushEvent) {
+	if appEventPublisher != nil {
+		appEventPublisher.Publish(userID, event)
+	}
+}
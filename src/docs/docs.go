@@ -23,9 +23,113 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/admin/get/avatar-presets": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "包括未启用的预设头像",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-avatar-preset"
+                ],
+                "summary": "管理员获取预设头像列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.AvatarPresetData"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/get/content": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "导出全部achievements/skills/items/cards，格式与导入接口一致，可直接提交到git\n返回的是文件本身而不是通用响应结构体",
+                "produces": [
+                    "application/json",
+                    "application/x-yaml"
+                ],
+                "tags": [
+                    "admin-content"
+                ],
+                "summary": "管理员导出游戏内容文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "文件格式(json/yaml)，默认json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "内容文件",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ContentFile"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/api/admin/get/getusers": {
             "get": {
-                "description": "注: 管理员可用，查询结果是多重模糊搜索叠加的效果\n以及页码不输入或不合规范自动为第一页，每页多少不输入默认20，最多100\n如果查询结果不存在则返回切片为空\n用了id查询的话就一定只是一个确定的，而不是模糊搜索，其他参数就没用了（分页也是）",
+                "description": "注: 管理员可用，查询结果是多个筛选条件叠加的效果，username和group是模糊搜索\nsort可选username/created_at/updated_at/strength_coin/select_coin/id，前面加-表示倒序，默认按id正序\n以及页码不输入或不合规范自动为第一页，每页多少不输入默认20，最多100\n如果查询结果不存在则返回切片为空\n用了id查询的话就一定只是一个确定的，而不是模糊搜索，其他参数就没用了（分页也是）",
                 "produces": [
                     "application/json"
                 ],
@@ -48,10 +152,58 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "权限组(user/admin)",
+                        "description": "权限组(user/admin)，模糊匹配",
                         "name": "group",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "权限组，精确匹配",
+                        "name": "group_exact",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "注册时间下限，格式为RFC3339或2006-01-02",
+                        "name": "created_at_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "注册时间上限，只写日期时包含当天",
+                        "name": "created_at_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "strength_coin下限",
+                        "name": "strength_coin_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "strength_coin上限",
+                        "name": "strength_coin_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "select_coin下限",
+                        "name": "select_coin_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "select_coin上限",
+                        "name": "select_coin_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序，例如-strength_coin",
+                        "name": "sort",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "description": "页码，默认1",
@@ -63,6 +215,18 @@ const docTemplate = `{
                         "description": "每页多少，默认20，最大100",
                         "name": "page_size",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -84,6 +248,12 @@ const docTemplate = `{
                             ]
                         }
                     },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
                     "401": {
                         "description": "登录状态异常",
                         "schema": {
@@ -99,40 +269,38 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/get/resources": {
+        "/api/admin/get/moderation": {
             "get": {
-                "description": "通过query参数type查询skills/achievements/items/cards；支持分页与可选id精确查询",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "开启审核(MODERATION_ENABLED)时用户改用户名、上传头像会生成审核记录，修改先生效\n同一用户同一字段最多一条待审核记录，previous_value是最后一次通过审核的值",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "admin-resource"
+                    "admin-moderation"
                 ],
-                "summary": "管理员按类型查询基础资源",
+                "summary": "管理员查看审核队列",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "资源类型(achievements/skills/items/cards)",
-                        "name": "type",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "资源ID，传入后优先精确查询",
-                        "name": "id",
+                        "description": "状态(pending/approved/rejected)，默认pending",
+                        "name": "status",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "名称模糊搜索",
-                        "name": "name",
+                        "description": "字段(username/head_image)，不传表示全部",
+                        "name": "field",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "技能组模糊搜索(type=skills有效)",
-                        "name": "skill_group",
+                        "type": "integer",
+                        "description": "只看某个用户",
+                        "name": "user_id",
                         "in": "query"
                     },
                     {
@@ -143,9 +311,21 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "每页多少，默认20，最大100",
+                        "description": "每页数量，默认20，最大100",
                         "name": "page_size",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -160,7 +340,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CommonAdminResourcePageData"
+                                            "$ref": "#/definitions/dto.ModerationPageData"
                                         }
                                     }
                                 }
@@ -180,7 +360,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "数据库查询失败",
+                        "description": "查询失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -188,30 +368,77 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/get/user-relations": {
+        "/api/admin/get/resources": {
             "get": {
-                "description": "通过query参数user_id和type查询指定用户在achievements/skills/items/cards中的关联数据\nskills会返回skill_grade，其他类型没有这个字段\ndata.list: []dto.CommonUserRelationData",
+                "description": "通过query参数type查询skills/achievements/items/cards；支持分页与可选id精确查询\nsort可选name/created_at/updated_at/id，前面加-表示倒序，默认按id正序\n时间范围参数格式为RFC3339或2006-01-02，只写日期的_to包含当天",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "admin-resource"
                 ],
-                "summary": "管理员按类型查询任意用户关联数据",
+                "summary": "管理员按类型查询基础资源",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "user_id",
+                        "type": "string",
+                        "description": "资源类型(achievements/skills/items/cards)",
+                        "name": "type",
                         "in": "query",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "资源ID，传入后优先精确查询",
+                        "name": "id",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "关联类型(achievements/skills/items/cards)",
-                        "name": "type",
-                        "in": "query",
-                        "required": true
+                        "description": "名称模糊搜索",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "技能组模糊搜索(type=skills有效)",
+                        "name": "skill_group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "前置技能ID精确匹配(type=skills有效)",
+                        "name": "prq_skill_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间下限",
+                        "name": "created_at_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "创建时间上限",
+                        "name": "created_at_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "更新时间下限",
+                        "name": "updated_at_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "更新时间上限",
+                        "name": "updated_at_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序，例如-created_at",
+                        "name": "sort",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
@@ -224,6 +451,18 @@ const docTemplate = `{
                         "description": "每页多少，默认20，最大100",
                         "name": "page_size",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -238,7 +477,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CommonUserRelationPageData"
+                                            "$ref": "#/definitions/dto.CommonAdminResourcePageData"
                                         }
                                     }
                                 }
@@ -266,63 +505,143 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/operation/deleteuser": {
-            "delete": {
-                "description": "管理员删除用户。ID=1(初始化管理员)不可删除；其他管理员仅可删除普通用户",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/admin/get/user-relations": {
+            "get": {
+                "description": "通过query参数user_id和type查询指定用户在achievements/skills/items/cards中的关联数据\nskills会返回skill_grade，其他类型没有这个字段\nsort可选created_at/updated_at/id，skills额外支持skill_grade，前面加-表示倒序，默认按资源id正序\ndata.list: []dto.CommonUserRelationData",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-resource"
                 ],
-                "summary": "管理员删除用户",
+                "summary": "管理员按类型查询任意用户关联数据",
                 "parameters": [
                     {
-                        "description": "删除用户请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.AdminDeleteUserRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "删除成功",
-                        "schema": {
-                            "$ref": "#/definitions/dto.Response"
-                        }
-                    },
-                    "400": {
-                        "description": "请求参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/dto.Response"
-                        }
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "user_id",
+                        "in": "query",
+                        "required": true
                     },
-                    "401": {
-                        "description": "登录状态异常",
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否完成",
+                        "name": "is_complete",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否已领取",
+                        "name": "claimed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "技能等级下限(type=skills有效)",
+                        "name": "skill_grade_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "技能等级上限(type=skills有效)",
+                        "name": "skill_grade_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "获得时间下限，格式为RFC3339或2006-01-02",
+                        "name": "created_at_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "获得时间上限，只写日期时包含当天",
+                        "name": "created_at_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "更新时间下限",
+                        "name": "updated_at_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "更新时间上限",
+                        "name": "updated_at_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "排序，例如-created_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页多少，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonUserRelationPageData"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "403": {
-                        "description": "权限不足",
+                    "400": {
+                        "description": "请求参数错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "用户不存在",
+                    "401": {
+                        "description": "登录状态异常",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "数据库错误",
+                        "description": "数据库查询失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -330,35 +649,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/operation/resources": {
+        "/api/admin/operation/avatar-presets": {
             "post": {
-                "description": "通过query参数type创建skills/achievements/items/cards中的一种资源\nskills需要额外参数skill_group和prq_skill_id，其他资源只需要公共请求体",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "上传图片新增预设头像，图片按头像的规则校验和生成缩略图；unlock_type为空时所有用户都可选",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "admin-resource"
+                    "admin-avatar-preset"
                 ],
-                "summary": "管理员按类型创建基础资源",
+                "summary": "管理员新增预设头像",
                 "parameters": [
+                    {
+                        "type": "file",
+                        "description": "预设头像图片(jpeg/png/gif/webp)",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
                     {
                         "type": "string",
-                        "description": "资源类型(achievements/skills/items/cards)",
-                        "name": "type",
-                        "in": "query",
+                        "description": "名称",
+                        "name": "name",
+                        "in": "formData",
                         "required": true
                     },
                     {
-                        "description": "创建请求体",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CommonResourceCreateRequest"
-                        }
+                        "type": "string",
+                        "description": "解锁条件类型(achievements/cards)",
+                        "name": "unlock_type",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "解锁条件对应的成就/卡牌id",
+                        "name": "unlock_resource_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "排序，越小越靠前",
+                        "name": "sort_order",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -373,7 +713,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CommonAdminResourceData"
+                                            "$ref": "#/definitions/dto.AvatarPresetData"
                                         }
                                     }
                                 }
@@ -381,7 +721,7 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "请求参数错误",
+                        "description": "请求参数错误、解锁条件无效或图片无法解析",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -393,13 +733,25 @@ const docTemplate = `{
                         }
                     },
                     "409": {
-                        "description": "名称冲突",
+                        "description": "名称已存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "图片过大",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "图片格式不支持",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "数据库错误",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -407,7 +759,12 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "通过query参数type删除skills/achievements/items/cards中的一种资源",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "已经在用这个预设的用户头像不受影响",
                 "consumes": [
                     "application/json"
                 ],
@@ -415,24 +772,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-resource"
+                    "admin-avatar-preset"
                 ],
-                "summary": "管理员按类型删除基础资源",
+                "summary": "管理员删除预设头像",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "资源类型(achievements/skills/items/cards)",
-                        "name": "type",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "删除请求体",
-                        "name": "request",
+                        "description": "删除预设头像请求体",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.AdminDeleteResourceByTypeRequest"
+                            "$ref": "#/definitions/dto.AdminAvatarPresetDeleteRequest"
                         }
                     }
                 ],
@@ -456,13 +806,13 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "目标资源不存在",
+                        "description": "预设头像不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "数据库错误",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -470,40 +820,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/update/resources": {
-            "put": {
-                "description": "通过query参数type更新skills/achievements/items/cards中的一种资源\nskills需要额外参数skill_group和prq_skill_id，其他资源只需要公共请求体",
+        "/api/admin/operation/content": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按名称upsert四类资源，整个文件在一个事务里执行，任意一条失败全部回滚\n技能的前置技能用prerequisite(名称)表示，可以引用同文件或库里已有的技能\ndry_run=true时只返回差异不写库；body为YAML时需format=yaml或Content-Type带yaml",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/x-yaml"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "admin-resource"
+                    "admin-content"
                 ],
-                "summary": "管理员按类型更新基础资源",
+                "summary": "管理员导入游戏内容文件",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "资源类型(achievements/skills/items/cards)",
-                        "name": "type",
-                        "in": "query",
-                        "required": true
+                        "description": "文件格式(json/yaml)，默认按Content-Type判断",
+                        "name": "format",
+                        "in": "query"
                     },
                     {
-                        "description": "更新请求体",
+                        "type": "boolean",
+                        "description": "只计算差异，不写入",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "内容文件",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CommonResourceUpdateRequest"
+                            "$ref": "#/definitions/dto.ContentFile"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "导入成功",
                         "schema": {
                             "allOf": [
                                 {
@@ -513,7 +874,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.CommonAdminResourceData"
+                                            "$ref": "#/definitions/dto.ContentImportResult"
                                         }
                                     }
                                 }
@@ -532,14 +893,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "目标资源不存在",
+                    "413": {
+                        "description": "文件过大",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "409": {
-                        "description": "名称冲突",
+                    "422": {
+                        "description": "内容校验失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -553,9 +914,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/admin/update/usergroup": {
-            "put": {
-                "description": "ID=1(初始化管理员)权限组不可修改；仅ID=1可修改其他用户权限组",
+        "/api/admin/operation/deleteuser": {
+            "delete": {
+                "description": "管理员删除用户。ID=1(初始化管理员)不可删除；其他管理员仅可删除普通用户",
                 "consumes": [
                     "application/json"
                 ],
@@ -565,21 +926,21 @@ const docTemplate = `{
                 "tags": [
                     "admin-user"
                 ],
-                "summary": "管理员修改用户权限组",
+                "summary": "管理员删除用户",
                 "parameters": [
                     {
-                        "description": "修改权限组请求",
+                        "description": "删除用户请求",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.AdminUpdateUserGroupRequest"
+                            "$ref": "#/definitions/dto.AdminDeleteUserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改成功",
+                        "description": "删除成功",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -617,9 +978,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/login": {
+        "/api/admin/operation/moderation/approve": {
             "post": {
-                "description": "用户登录",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过后不能再驳回，头像记录保留的旧头像引用随之释放",
                 "consumes": [
                     "application/json"
                 ],
@@ -627,23 +993,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin-moderation"
                 ],
-                "summary": "用户登录",
+                "summary": "管理员通过审核",
                 "parameters": [
                     {
-                        "description": "注册请求",
-                        "name": "request",
+                        "description": "通过审核请求体",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginRequest"
+                            "$ref": "#/definitions/dto.AdminModerationApproveRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "登录成功",
+                        "description": "已通过",
                         "schema": {
                             "allOf": [
                                 {
@@ -653,7 +1019,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.AuthData"
+                                            "$ref": "#/definitions/dto.ModerationRecordData"
                                         }
                                     }
                                 }
@@ -666,14 +1032,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "403": {
-                        "description": "认证失败",
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "审核记录不存在或已处理",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "服务器错误",
+                        "description": "处理失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -681,9 +1053,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/register": {
+        "/api/admin/operation/moderation/reject": {
             "post": {
-                "description": "注册用户",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "用户当前的值还是待审核的值时恢复为previous_value，原用户名已被别人占用时改为player_\u003cid\u003e\n用户在审核前又改成了别的值(如选用预设头像)时只标记驳回，不改动当前的值\n驳回后向用户推送moderation_rejected事件",
                 "consumes": [
                     "application/json"
                 ],
@@ -691,23 +1068,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin-moderation"
                 ],
-                "summary": "用户注册",
+                "summary": "管理员驳回审核",
                 "parameters": [
                     {
-                        "description": "注册请求",
-                        "name": "request",
+                        "description": "驳回审核请求体",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.RegisterRequest"
+                            "$ref": "#/definitions/dto.AdminModerationRejectRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "注册成功",
+                        "description": "已驳回",
                         "schema": {
                             "allOf": [
                                 {
@@ -717,7 +1094,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dto.AuthData"
+                                            "$ref": "#/definitions/dto.ModerationRejectData"
                                         }
                                     }
                                 }
@@ -730,14 +1107,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "409": {
-                        "description": "用户已存在",
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "审核记录不存在或已处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "原用户名和默认用户名都已被占用",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "服务器错误",
+                        "description": "处理失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -745,47 +1134,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/profile/get/relations": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+        "/api/admin/operation/resources": {
+            "post": {
+                "description": "通过query参数type创建skills/achievements/items/cards中的一种资源\nskills需要额外参数skill_group和prq_skill_id，其他资源只需要公共请求体",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "通过query参数type查询本人在achievements/skills/items/cards中的关联数据，支持分页",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile-relation"
+                    "admin-resource"
                 ],
-                "summary": "用户按类型查询自身资源关联",
+                "summary": "管理员按类型创建基础资源",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "关联类型(achievements/skills/items/cards)",
+                        "description": "资源类型(achievements/skills/items/cards)",
                         "name": "type",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "页码，默认1",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "每页数量，默认20，最大100",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "创建请求体",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CommonResourceCreateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "查询成功",
+                        "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonAdminResourceData"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -800,88 +1196,77 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
+                    "409": {
+                        "description": "名称冲突",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
                     "500": {
-                        "description": "查询失败",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     }
                 }
-            }
-        },
-        "/api/profile/get/self": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+            },
+            "delete": {
+                "description": "通过query参数type删除skills/achievements/items/cards中的一种资源",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "查询当前登录用户的基础信息",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "admin-resource"
                 ],
-                "summary": "获取当前用户信息",
-                "responses": {
-                    "200": {
-                        "description": "查询成功",
-                        "schema": {
-                            "$ref": "#/definitions/dto.Response"
-                        }
+                "summary": "管理员按类型删除基础资源",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "资源类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
                     },
-                    "401": {
-                        "description": "登录状态异常",
+                    {
+                        "description": "删除请求体",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "$ref": "#/definitions/dto.AdminDeleteResourceByTypeRequest"
                         }
-                    },
-                    "404": {
-                        "description": "用户不存在",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "500": {
-                        "description": "数据库错误",
+                    "400": {
+                        "description": "请求参数错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
-                    }
-                }
-            }
-        },
-        "/api/profile/operation/logout": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "当前登录用户登出（使现有token失效）",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "profile"
-                ],
-                "summary": "用户登出",
-                "responses": {
-                    "200": {
-                        "description": "登出成功",
+                    },
+                    "401": {
+                        "description": "登录状态异常",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "401": {
-                        "description": "登录状态异常或用户不存在",
+                    "404": {
+                        "description": "目标资源不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "服务器错误",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -889,14 +1274,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/profile/operation/relations": {
+        "/api/admin/operation/resources/bulk": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "通过query参数type(achievements/skills/items/cards)和body中的resource_id创建本人关联记录",
+                "description": "整个请求在一个事务里执行，每行单独成败并在data.rows里报告\natomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true",
                 "consumes": [
                     "application/json"
                 ],
@@ -904,32 +1289,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile-relation"
+                    "admin-resource"
                 ],
-                "summary": "用户按类型创建自身资源关联",
+                "summary": "管理员批量创建基础资源",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "关联类型(achievements/skills/items/cards)",
+                        "description": "资源类型(achievements/skills/items/cards)",
                         "name": "type",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "创建关联请求体",
-                        "name": "body",
+                        "type": "boolean",
+                        "description": "有失败行时是否整体回滚，默认false",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "批量创建请求体",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRelationCreateRequest"
+                            "$ref": "#/definitions/dto.AdminBulkResourceCreateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "创建成功",
+                        "description": "执行完成",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -944,20 +1347,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "目标资源不存在",
-                        "schema": {
-                            "$ref": "#/definitions/dto.Response"
-                        }
-                    },
-                    "409": {
-                        "description": "关联已存在",
+                    "422": {
+                        "description": "存在失败行，已整体回滚",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
-                        "description": "创建失败",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -970,7 +1379,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "通过query参数type(achievements/skills/items/cards)和body中的resource_id删除本人关联记录",
+                "description": "整个请求在一个事务里执行，每行单独成败并在data.rows里报告\natomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true",
                 "consumes": [
                     "application/json"
                 ],
@@ -978,32 +1387,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile-relation"
+                    "admin-resource"
                 ],
-                "summary": "用户按类型删除自身资源关联",
+                "summary": "管理员批量删除基础资源",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "关联类型(achievements/skills/items/cards)",
+                        "description": "资源类型(achievements/skills/items/cards)",
                         "name": "type",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "删除关联请求体",
-                        "name": "body",
+                        "type": "boolean",
+                        "description": "有失败行时是否整体回滚，默认false",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "批量删除请求体",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRelationDeleteRequest"
+                            "$ref": "#/definitions/dto.AdminBulkResourceDeleteRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "删除成功",
+                        "description": "执行完成",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1018,14 +1445,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "关联记录不存在",
+                    "422": {
+                        "description": "存在失败行，已整体回滚",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
-                        "description": "删除失败",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1033,14 +1472,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/profile/update/coin": {
-            "put": {
+        "/api/admin/operation/user-relations": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "通过query参数type(strength/select)修改对应金币",
+                "description": "通过query参数type(achievements/skills/items/cards)和body中的user_id/resource_id创建关联记录",
                 "consumes": [
                     "application/json"
                 ],
@@ -1048,32 +1487,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "admin-user"
                 ],
-                "summary": "用户按类型修改金币",
+                "summary": "管理员为任意用户创建资源关联",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "金币类型(strength/select)",
+                        "description": "关联类型(achievements/skills/items/cards)",
                         "name": "type",
                         "in": "query",
                         "required": true
                     },
                     {
-                        "description": "修改金币请求体",
+                        "description": "创建关联请求体",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateCoinByTypeRequest"
+                            "$ref": "#/definitions/dto.AdminUserRelationCreateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改成功",
+                        "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonUserRelationData"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1089,50 +1540,63 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "用户不存在",
+                        "description": "用户或目标资源不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "关联已存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "数据库错误",
+                        "description": "创建失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     }
                 }
-            }
-        },
-        "/api/profile/update/headimage": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "上传并更新当前用户头像",
+                "description": "通过query参数type和body中的user_id/resource_id删除关联记录",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "admin-user"
                 ],
-                "summary": "用户修改头像",
+                "summary": "管理员删除任意用户的资源关联",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "头像文件",
-                        "name": "new_head_image",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "description": "删除关联请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminUserRelationDeleteRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改成功",
+                        "description": "删除成功",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1149,20 +1613,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "403": {
-                        "description": "修改过于频繁",
-                        "schema": {
-                            "$ref": "#/definitions/dto.Response"
-                        }
-                    },
                     "404": {
-                        "description": "用户不存在",
+                        "description": "用户或关联记录不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "500": {
-                        "description": "服务器错误",
+                        "description": "删除失败",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1170,14 +1628,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/profile/update/password": {
-            "put": {
+        "/api/admin/operation/user-relations/bulk": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "通过旧密码校验后更新密码，成功后当前登录状态失效",
+                "description": "把一个资源发放给多个用户，用户可以用user_ids显式指定，也可以按注册时间/权限组筛选\n已拥有的用户记为skipped；atomic=true时有失败行(用户不存在等)就整体回滚\n整个操作在一个事务里完成，中途出错整体回滚；目标用户按bulk.grant_batch_size(默认5000)个一批分批写入，只用来控制单条SQL的大小",
                 "consumes": [
                     "application/json"
                 ],
@@ -1185,25 +1643,50 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "admin-resource"
                 ],
-                "summary": "用户修改密码",
+                "summary": "管理员批量发放资源",
                 "parameters": [
                     {
-                        "description": "修改密码请求体",
-                        "name": "body",
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "有失败行时是否整体回滚，默认false",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "批量发放请求体",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdatePasswordRequest"
+                            "$ref": "#/definitions/dto.AdminBulkRelationRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改密码成功",
+                        "description": "执行完成",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1218,35 +1701,45 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "403": {
-                        "description": "旧密码错误或修改过于频繁",
+                    "404": {
+                        "description": "目标资源不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "用户不存在",
+                    "422": {
+                        "description": "存在失败行，已整体回滚",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "500": {
-                        "description": "服务器错误",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     }
                 }
-            }
-        },
-        "/api/profile/update/relations": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "通过query参数type(achievements/skills/items/cards)和body更新本人关联记录，skills支持skill_grade",
+                "description": "从多个用户处回收一个资源，用户选择方式与批量发放相同\n未拥有的用户记为skipped；atomic=true时有失败行(用户不存在等)就整体回滚\n整个操作在一个事务里完成，中途出错整体回滚；目标用户按bulk.grant_batch_size(默认5000)个一批分批写入，只用来控制单条SQL的大小",
                 "consumes": [
                     "application/json"
                 ],
@@ -1254,9 +1747,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile-relation"
+                    "admin-resource"
                 ],
-                "summary": "用户按类型更新自身资源关联",
+                "summary": "管理员批量回收资源",
                 "parameters": [
                     {
                         "type": "string",
@@ -1266,20 +1759,38 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "更新关联请求体",
-                        "name": "body",
+                        "type": "boolean",
+                        "description": "有失败行时是否整体回滚，默认false",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "批量回收请求体",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRelationUpdateRequest"
+                            "$ref": "#/definitions/dto.AdminBulkRelationRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新成功",
+                        "description": "执行完成",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -1295,13 +1806,31 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "关联记录不存在",
+                        "description": "目标资源不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
+                    "422": {
+                        "description": "存在失败行，已整体回滚",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
                     "500": {
-                        "description": "更新失败",
+                        "description": "数据库错误",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1309,14 +1838,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/profile/update/username": {
+        "/api/admin/update/avatar-presets": {
             "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "修改当前用户用户名",
+                "description": "只修改传了的字段，unlock_type传空字符串表示取消解锁条件；图片不能修改",
                 "consumes": [
                     "application/json"
                 ],
@@ -1324,29 +1853,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "profile"
+                    "admin-avatar-preset"
                 ],
-                "summary": "用户修改用户名",
+                "summary": "管理员修改预设头像",
                 "parameters": [
                     {
-                        "description": "修改用户名请求体",
+                        "description": "修改预设头像请求体",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateUsernameRequest"
+                            "$ref": "#/definitions/dto.AdminAvatarPresetUpdateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "修改成功",
+                        "description": "更新成功",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.AvatarPresetData"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "请求参数错误",
+                        "description": "请求参数错误或解锁条件无效",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1357,14 +1898,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "403": {
-                        "description": "修改过于频繁",
+                    "404": {
+                        "description": "预设头像不存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
                     },
-                    "404": {
-                        "description": "用户不存在",
+                    "409": {
+                        "description": "名称已存在",
                         "schema": {
                             "$ref": "#/definitions/dto.Response"
                         }
@@ -1377,109 +1918,3874 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.AdminDeleteResourceByTypeRequest": {
-            "description": "用于skills/achievements/items/cards的删除（按ID）",
-            "type": "object",
-            "required": [
-                "id"
-            ],
-            "properties": {
-                "id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "dto.AdminDeleteUserRequest": {
-            "description": "按用户ID删除用户",
-            "type": "object",
-            "required": [
-                "user_id"
-            ],
-            "properties": {
-                "user_id": {
-                    "type": "integer"
-                }
-            }
         },
-        "dto.AdminUpdateUserGroupRequest": {
-            "description": "按用户ID修改权限组，仅支持user/admin",
+        "/api/admin/update/coin": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按增减量调整strength/select金币，调整后不能为负数；reason必填，每次调整都会写一条流水",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "管理员调整用户金币",
+                "parameters": [
+                    {
+                        "description": "调整金币请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminAdjustCoinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "调整成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.AdminCoinAdjustData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "余额不足",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/update/resources": {
+            "put": {
+                "description": "通过query参数type更新skills/achievements/items/cards中的一种资源\nskills需要额外参数skill_group和prq_skill_id，其他资源只需要公共请求体",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-resource"
+                ],
+                "summary": "管理员按类型更新基础资源",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "资源类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "更新请求体",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CommonResourceUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonAdminResourceData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "目标资源不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "名称冲突",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/update/resources/bulk": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "整个请求在一个事务里执行，每行单独成败并在data.rows里报告\natomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-resource"
+                ],
+                "summary": "管理员批量更新基础资源",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "资源类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "有失败行时是否整体回滚，默认false",
+                        "name": "atomic",
+                        "in": "query"
+                    },
+                    {
+                        "description": "批量更新请求体",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminBulkResourceUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "执行完成",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "存在失败行，已整体回滚",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BulkOperationResult"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/update/user-profile": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "用于处理违规昵称或头像；重置后会清掉对应的修改时间，用户可以立即重新修改",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "管理员重置用户名/头像",
+                "parameters": [
+                    {
+                        "description": "重置请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminResetUserProfileRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "重置成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonUserData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "用户名已被占用",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/update/user-relations": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type和body更新指定用户的关联记录，skills支持skill_grade\n带If-Match时记录在此期间被修改过则返回412",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "管理员更新任意用户的资源关联",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "关联记录的etag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "更新关联请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminUserRelationUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonUserRelationData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户或关联记录不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "记录已被修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "更新失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/admin/update/usergroup": {
+            "put": {
+                "description": "ID=1(初始化管理员)权限组不可修改；仅ID=1可修改其他用户权限组",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "管理员修改用户权限组",
+                "parameters": [
+                    {
+                        "description": "修改权限组请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AdminUpdateUserGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/login": {
+            "post": {
+                "description": "用户登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "注册请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.AuthData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "认证失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/register": {
+            "post": {
+                "description": "注册用户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "注册请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "注册成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.AuthData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "用户已存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/catalogue/{type}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "返回某类资源的全部定义，并合并当前用户的拥有/完成状态，owned=false的条目客户端显示为未解锁\n响应带弱ETag，客户端带If-None-Match请求且内容没变时返回304(无响应体)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "catalogue"
+                ],
+                "summary": "玩家查询资源图鉴",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "资源类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次响应的ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CatalogueData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "内容未变化"
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Server-Sent Events长连接，event为事件类型，data为dto.PushEvent的JSON：\ncoins_changed(金币变化)、relation_created/relation_updated/relation_deleted(关联变化)、banned(账号被删除)、forced_logout(token被吊销)\n收到banned/forced_logout后服务端会关闭连接；服务端每隔一段时间发送注释行作为心跳\n事件只是提醒，断线期间的事件不会补发，客户端重连后应调用/api/profile/changes增量同步",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "玩家订阅实时事件",
+                "responses": {
+                    "200": {
+                        "description": "事件流",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PushEvent"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/avatars": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "列出所有启用的预设头像，unlocked表示当前用户是否已满足解锁条件",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "获取预设头像列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dto.AvatarPresetData"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/blocks": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家查询黑名单",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.BlockedUserPageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "同时解除好友关系并取消双方之间待处理的好友申请；被拉黑的人不能再发送好友申请，也搜索不到自己",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家拉黑用户",
+                "parameters": [
+                    {
+                        "description": "要拉黑的用户ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.FriendTargetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "拉黑成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "拉黑失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "取消拉黑后不会恢复原来的好友关系",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家取消拉黑",
+                "parameters": [
+                    {
+                        "description": "要取消拉黑的用户ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.FriendTargetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已取消拉黑",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "没有拉黑该用户",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "取消拉黑失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "返回修订号since之后变化过的实体的当前状态，同一个实体只返回最终状态，被删除的关联返回deleted=true的墓碑\n客户端应用完变更后把返回的revision作为下次的since；full_resync=true时应改为读取完整存档",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-snapshot"
+                ],
+                "summary": "玩家增量同步存档",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "上次同步拿到的revision，首次同步传0",
+                        "name": "since",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ProfileChangesData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/friends": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按好友的用户ID排序，只返回公开信息(不含金币)，支持分页",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家查询好友列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendPageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "双方的好友关系同时解除",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家删除好友",
+                "parameters": [
+                    {
+                        "description": "好友的用户ID",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.FriendTargetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "对方不是好友",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "删除失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/friends/requests": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "direction=incoming(默认)为收到的申请，outgoing为自己发出的申请，新的在前，支持分页",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家查询待处理的好友申请",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "incoming/outgoing，默认incoming",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendRequestPageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "对方已经向自己发过待处理的申请时直接成为好友，返回的申请status为accepted\n被对方拒绝或自己取消过的申请可以重新发送；双方任意一方拉黑了对方时不能发送",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家发送好友申请",
+                "parameters": [
+                    {
+                        "description": "好友申请",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.FriendRequestCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已成为好友",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendRequestData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "201": {
+                        "description": "申请已发送",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendRequestData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "被拉黑或已拉黑对方",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "已是好友、申请待处理或好友数已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "发送失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/friends/requests/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家取消自己发出的好友申请",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "申请ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已取消",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "申请不存在或已处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "取消失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/friends/requests/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "只能处理发给自己的待处理申请，任意一方好友数已达上限时返回409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家同意好友申请",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "申请ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已同意",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendRequestData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "申请不存在或已处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "好友数已达上限",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "处理失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/friends/requests/{id}/decline": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "只能处理发给自己的待处理申请，拒绝后对方可以重新申请",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家拒绝好友申请",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "申请ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "已拒绝",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.FriendRequestData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "申请不存在或已处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "处理失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/get/relations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type查询本人在achievements/skills/items/cards中的关联数据，支持分页\n响应带弱ETag和Last-Modified，按关联数量和最后修改时间(包括删除)在查询前计算，带If-None-Match或If-Modified-Since且内容没变时直接返回304\n每条记录的etag可用于更新时的If-Match",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-relation"
+                ],
+                "summary": "用户按类型查询自身资源关联",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上次响应的ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次响应的Last-Modified",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.CommonUserRelationPageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "内容未变化"
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/get/self": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "查询当前登录用户的基础信息\n响应带弱ETag和Last-Modified，按版本号和更新时间在查询前计算，带If-None-Match或If-Modified-Since且内容没变时直接返回304(无响应体)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "获取当前用户信息",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上次响应的ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "上次响应的Last-Modified",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "304": {
+                        "description": "内容未变化"
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/operation/logout": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "当前登录用户登出（使现有token失效）",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "用户登出",
+                "responses": {
+                    "200": {
+                        "description": "登出成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常或用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/operation/relations": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type(achievements/skills/items/cards)和body中的resource_id创建本人关联记录\n带Idempotency-Key请求头时，网络重试会返回第一次的结果，而不是\"关联已存在\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-relation"
+                ],
+                "summary": "用户按类型创建自身资源关联",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "创建关联请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRelationCreateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "幂等键，客户端为每次操作生成的唯一值",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "目标资源不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "关联已存在或相同幂等键的请求正在处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "幂等键已用于其他请求",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "创建失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type(achievements/skills/items/cards)和body中的resource_id删除本人关联记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-relation"
+                ],
+                "summary": "用户按类型删除自身资源关联",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "删除关联请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRelationDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "关联记录不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "删除失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/snapshot": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "一次返回用户资料、金币和全部关联(成就/技能/物品/卡牌)，revision为存档修订号，保存存档时作为base_revision带回\n响应带弱ETag，客户端带If-None-Match请求且存档没变时返回304(无响应体)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-snapshot"
+                ],
+                "summary": "玩家读取完整存档",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "上次响应的ETag",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ProfileSnapshotData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "304": {
+                        "description": "存档未变化"
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "服务端把请求中的存档和当前存档比对，只写入有差异的部分，整个保存在一个事务里\n金币不传则不修改；relations只包含要同步的类型，出现的类型按列表整体覆盖，列表里没有的关联会被删除\nbase_revision和当前存档修订号不一致(期间在其他地方被修改过)时返回409，客户端应重新读取存档合并后再保存",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-snapshot"
+                ],
+                "summary": "玩家保存完整存档",
+                "parameters": [
+                    {
+                        "description": "存档",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ProfileSnapshotRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "保存成功，返回保存后的完整存档",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ProfileSnapshotData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户或资源不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "存档已在其他地方被修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "保存失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/coin": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type(strength/select)修改对应金币\n带Idempotency-Key请求头时，网络重试不会重复修改，重试直接返回第一次的结果",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "用户按类型修改金币",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "金币类型(strength/select)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "修改金币请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCoinByTypeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "幂等键，客户端为每次操作生成的唯一值",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改或相同幂等键的请求正在处理",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "422": {
+                        "description": "幂等键已用于其他请求",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "数据库错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/headimage": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "上传并更新当前用户头像，只接受jpeg/png/gif(按文件内容判断，不看扩展名)，gif只取第一帧\n服务端解码后重新编码为jpeg(去掉EXIF等元数据)，最长边超过512时等比缩小，并生成正方形缩略图\n开启审核时头像先生效并进入审核队列(pending_review为true)，管理员驳回后恢复原头像",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "用户修改头像",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "头像文件，最大5MB，宽高不超过4096",
+                        "name": "new_head_image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "读到的用户版本号，与当前不一致时返回409",
+                        "name": "version",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.HeadImageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误或图片无法解析",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "修改过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "413": {
+                        "description": "图片过大",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "415": {
+                        "description": "图片格式不支持",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/headimage/preset": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "把头像换成已解锁的预设头像，不上传文件，也不受头像修改间隔限制",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "选择预设头像",
+                "parameters": [
+                    {
+                        "description": "选择预设头像请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SelectAvatarPresetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.HeadImageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "尚未解锁该预设头像",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户或预设头像不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过旧密码校验后更新密码，成功后当前登录状态失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "用户修改密码",
+                "parameters": [
+                    {
+                        "description": "修改密码请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdatePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改密码成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "旧密码错误或修改过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/relations": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "通过query参数type(achievements/skills/items/cards)和body更新本人关联记录，skills支持skill_grade\n带If-Match(值为查询结果里的etag)时，记录在此期间被修改过则返回412，不带则直接覆盖",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-relation"
+                ],
+                "summary": "用户按类型更新自身资源关联",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "关联类型(achievements/skills/items/cards)",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "关联记录的etag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "更新关联请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRelationUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "关联记录不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "412": {
+                        "description": "记录已被修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "更新失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/update/username": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "修改当前用户用户名，用户名不能包含敏感词\n开启审核时修改先生效并进入审核队列，管理员驳回后恢复原用户名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile"
+                ],
+                "summary": "用户修改用户名",
+                "parameters": [
+                    {
+                        "description": "修改用户名请求体",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateUsernameRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "修改成功",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误或用户名包含敏感词",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "修改过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "409": {
+                        "description": "数据已被其他请求修改",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/profile/users/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "用户名模糊匹配(最多30字，%和_按普通字符处理)，结果不含自己和拉黑了自己的人，只返回公开信息，支持分页",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profile-friend"
+                ],
+                "summary": "玩家按用户名搜索用户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户名关键字",
+                        "name": "keyword",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否返回total，页码模式默认true，游标模式默认false",
+                        "name": "with_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "查询成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.PublicUserPageData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "登录状态异常",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "进程能处理请求就返回200，不检查数据库等依赖，依赖故障时不应该因此重启进程",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "存活检查",
+                "responses": {
+                    "200": {
+                        "description": "存活",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "检查数据库连通(带超时)、迁移是否为最新版本、上传存储是否可写(结果缓存10秒)，任意一项失败返回503\n反向代理用它判断是否把流量转发到这个实例",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "就绪检查",
+                "responses": {
+                    "200": {
+                        "description": "就绪",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ReadinessData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "503": {
+                        "description": "依赖异常",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/dto.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dto.ReadinessData"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AdminAdjustCoinRequest": {
+            "description": "delta为增减量(不能为0)，调整后不能为负数；reason必填，会记入流水",
+            "type": "object",
+            "required": [
+                "coin_type",
+                "delta",
+                "reason",
+                "user_id"
+            ],
+            "properties": {
+                "coin_type": {
+                    "type": "string",
+                    "enum": [
+                        "strength",
+                        "select"
+                    ]
+                },
+                "delta": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminAvatarPresetDeleteRequest": {
+            "description": "已经在用这个预设的用户头像不受影响",
+            "type": "object",
+            "required": [
+                "preset_id"
+            ],
+            "properties": {
+                "preset_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminAvatarPresetUpdateRequest": {
+            "description": "只修改传了的字段，unlock_type传空字符串表示取消解锁条件；图片不能修改，需要换图时删掉重建",
+            "type": "object",
+            "required": [
+                "preset_id"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "preset_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "unlock_resource_id": {
+                    "type": "integer"
+                },
+                "unlock_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AdminBulkRelationRequest": {
+            "description": "user_ids与筛选条件同时给出时取交集；都不给时需要显式设置all_users=true",
+            "type": "object",
+            "required": [
+                "resource_id"
+            ],
+            "properties": {
+                "all_users": {
+                    "type": "boolean"
+                },
+                "group": {
+                    "description": "只选某个权限组的用户",
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ]
+                },
+                "registered_after": {
+                    "description": "只选这个时间之后注册的用户(RFC3339)",
+                    "type": "string"
+                },
+                "registered_before": {
+                    "description": "只选这个时间之前注册的用户(RFC3339)",
+                    "type": "string"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "dto.AdminBulkResourceCreateRequest": {
+            "description": "rows里每一项与单个创建的请求体一致，条数上限见bulk.max_rows(默认500)",
+            "type": "object",
+            "required": [
+                "rows"
+            ],
+            "properties": {
+                "rows": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.CommonResourceCreateRequest"
+                    }
+                }
+            }
+        },
+        "dto.AdminBulkResourceDeleteRequest": {
+            "description": "按ID批量删除，条数上限见bulk.max_rows(默认500)",
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "dto.AdminBulkResourceUpdateRequest": {
+            "description": "rows里每一项与单个更新的请求体一致，条数上限见bulk.max_rows(默认500)",
+            "type": "object",
+            "required": [
+                "rows"
+            ],
+            "properties": {
+                "rows": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.CommonResourceUpdateRequest"
+                    }
+                }
+            }
+        },
+        "dto.AdminCoinAdjustData": {
+            "type": "object",
+            "properties": {
+                "adjustment": {
+                    "$ref": "#/definitions/dto.CoinAdjustmentData"
+                },
+                "user": {
+                    "description": "调整后的用户信息",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.CommonUserData"
+                        }
+                    ]
+                }
+            }
+        },
+        "dto.AdminDeleteResourceByTypeRequest": {
+            "description": "用于skills/achievements/items/cards的删除（按ID）",
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminDeleteUserRequest": {
+            "description": "按用户ID删除用户",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminModerationApproveRequest": {
+            "type": "object",
+            "required": [
+                "moderation_id"
+            ],
+            "properties": {
+                "moderation_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminModerationRejectRequest": {
+            "description": "驳回后恢复为修改前的值，reason会推送给用户",
+            "type": "object",
+            "required": [
+                "moderation_id"
+            ],
+            "properties": {
+                "moderation_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.AdminResetUserProfileRequest": {
+            "description": "reset_username为true时改成new_username，不填则改成player_\u003cuser_id\u003e；reset_head_image为true时恢复默认头像",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "new_username": {
+                    "type": "string",
+                    "maxLength": 20,
+                    "minLength": 3
+                },
+                "reset_head_image": {
+                    "type": "boolean"
+                },
+                "reset_username": {
+                    "type": "boolean"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminUpdateUserGroupRequest": {
+            "description": "按用户ID修改权限组，仅支持user/admin",
+            "type": "object",
+            "required": [
+                "new_group",
+                "user_id"
+            ],
+            "properties": {
+                "new_group": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminUserRelationCreateRequest": {
+            "description": "按用户ID和资源ID创建关联记录",
+            "type": "object",
+            "required": [
+                "resource_id",
+                "user_id"
+            ],
+            "properties": {
+                "resource_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminUserRelationDeleteRequest": {
+            "description": "按用户ID和资源ID删除关联记录",
+            "type": "object",
+            "required": [
+                "resource_id",
+                "user_id"
+            ],
+            "properties": {
+                "resource_id": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AdminUserRelationUpdateRequest": {
+            "description": "按用户ID和资源ID更新关联记录，skills可额外更新skill_grade",
+            "type": "object",
+            "required": [
+                "resource_id",
+                "user_id"
+            ],
+            "properties": {
+                "claimed": {
+                    "type": "boolean"
+                },
+                "is_complete": {
+                    "type": "boolean"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "skill_grade": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "可选，客户端读到的版本号，与当前不一致时返回409",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AuthData": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "有效期，24h",
+                    "type": "integer"
+                },
+                "token": {
+                    "description": "JWT Token",
+                    "type": "string"
+                },
+                "user": {
+                    "description": "用户",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.CommonUserData"
+                        }
+                    ]
+                }
+            }
+        },
+        "dto.AvatarPresetData": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "head_image_path": {
+                    "type": "string"
+                },
+                "head_image_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "preset_id": {
+                    "type": "integer"
+                },
+                "sort_order": {
+                    "type": "integer"
+                },
+                "unlock_resource_id": {
+                    "type": "integer"
+                },
+                "unlock_type": {
+                    "type": "string"
+                },
+                "unlocked": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.BlockedUserData": {
+            "type": "object",
+            "properties": {
+                "blocked_at": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/dto.PublicUserData"
+                }
+            }
+        },
+        "dto.BlockedUserPageData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BlockedUserData"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BulkOperationResult": {
+            "type": "object",
+            "properties": {
+                "batches": {
+                    "description": "发放/回收时分几批写入，所有批次在同一个事务里",
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "rolled_back": {
+                    "description": "是否整体回滚(atomic模式下有失败行时为true)",
+                    "type": "boolean"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BulkRowResult"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BulkRowResult": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "成功时的最新数据"
+                },
+                "error": {
+                    "description": "失败或跳过的原因",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "资源ID，发放/回收时为用户ID",
+                    "type": "integer"
+                },
+                "index": {
+                    "description": "请求中的下标，发放/回收时按匹配到的用户顺序",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "succeeded/failed/skipped",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CatalogueData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CatalogueEntryData"
+                    }
+                },
+                "owned": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CatalogueEntryData": {
+            "type": "object",
+            "properties": {
+                "acquired_at": {
+                    "type": "string"
+                },
+                "claimed": {
+                    "type": "boolean"
+                },
+                "claimed_at": {
+                    "type": "string"
+                },
+                "complete_at": {
+                    "type": "string"
+                },
+                "is_complete": {
+                    "type": "boolean"
+                },
+                "owned": {
+                    "type": "boolean"
+                },
+                "resource": {
+                    "$ref": "#/definitions/dto.CommonRelationResourceData"
+                },
+                "skill_grade": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ChangedRelationData": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "description": "为true表示关联已被删除(墓碑)，此时没有relation",
+                    "type": "boolean"
+                },
+                "relation": {
+                    "description": "关联的当前状态",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.CommonUserRelationData"
+                        }
+                    ]
+                },
+                "resource_id": {
+                    "description": "资源ID",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "关联类型(achievements/skills/items/cards)",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CoinAdjustmentData": {
+            "type": "object",
+            "properties": {
+                "admin_id": {
+                    "type": "integer"
+                },
+                "balance_after": {
+                    "type": "integer"
+                },
+                "balance_before": {
+                    "type": "integer"
+                },
+                "coin_type": {
+                    "description": "strength/select",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delta": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommonAdminResourceData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "prq_skill_id": {
+                    "type": "integer"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "resource_name": {
+                    "type": "string"
+                },
+                "skill_group": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CommonAdminResourcePageData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommonAdminResourceData"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommonRelationResourceData": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "prq_skill_id": {
+                    "type": "integer"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "resource_name": {
+                    "type": "string"
+                },
+                "skill_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CommonResourceCreateRequest": {
+            "description": "用于achievements/items/cards，skills可额外携带技能字段",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "prq_skill_id": {
+                    "type": "integer"
+                },
+                "skill_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CommonResourceUpdateRequest": {
+            "description": "用于achievements/items/cards，skills可额外携带技能字段",
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1
+                },
+                "prq_skill_id": {
+                    "type": "integer"
+                },
+                "skill_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CommonUserData": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "description": "权限组",
+                    "type": "string"
+                },
+                "head_image_path": {
+                    "description": "头像路径",
+                    "type": "string"
+                },
+                "head_image_thumbnails": {
+                    "description": "头像缩略图，key为边长，没有缩略图时客户端使用head_image_path",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "select_coin": {
+                    "description": "抽卡货币",
+                    "type": "integer"
+                },
+                "strength_coin": {
+                    "description": "强化货币",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "用户ID",
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "用户名",
+                    "type": "string"
+                },
+                "version": {
+                    "description": "乐观锁版本号，修改时可带上，期间被别人改过会返回409",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommonUserRelationData": {
+            "type": "object",
+            "properties": {
+                "claimed": {
+                    "type": "boolean"
+                },
+                "claimed_at": {
+                    "type": "string"
+                },
+                "complete_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "etag": {
+                    "description": "强ETag，更新时放进If-Match里，记录在这期间被改过则返回412",
+                    "type": "string"
+                },
+                "is_complete": {
+                    "type": "boolean"
+                },
+                "resource": {
+                    "$ref": "#/definitions/dto.CommonRelationResourceData"
+                },
+                "skill_grade": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "乐观锁版本号",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommonUserRelationPageData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommonUserRelationData"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ContentDiffEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "create/update/unchanged",
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentFieldChange"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "资源类型(achievements/skills/items/cards)",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ContentFieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ContentFile": {
+            "description": "一次性描述achievements/skills/items/cards四类资源，资源之间用名称关联 支持JSON和YAML两种格式，YAML的字段名与JSON一致",
+            "type": "object",
+            "properties": {
+                "achievements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentResource"
+                    }
+                },
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentResource"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentResource"
+                    }
+                },
+                "skills": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentSkill"
+                    }
+                }
+            }
+        },
+        "dto.ContentImportResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "为true时只计算差异，没有写入数据库",
+                    "type": "boolean"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ContentDiffEntry"
+                    }
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ContentResource": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ContentSkill": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prerequisite": {
+                    "description": "前置技能名称，可以引用同一文件或数据库中已有的技能",
+                    "type": "string"
+                },
+                "skill_group": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "ok或error",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FriendData": {
+            "type": "object",
+            "properties": {
+                "since": {
+                    "description": "成为好友的时间",
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/dto.PublicUserData"
+                }
+            }
+        },
+        "dto.FriendPageData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FriendData"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FriendRequestCreateRequest": {
+            "description": "对方已经向自己发过待处理的申请时直接成为好友",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FriendRequestData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "$ref": "#/definitions/dto.PublicUserData"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "integer"
+                },
+                "responded_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending/accepted/declined/cancelled",
+                    "type": "string"
+                },
+                "to": {
+                    "$ref": "#/definitions/dto.PublicUserData"
+                }
+            }
+        },
+        "dto.FriendRequestPageData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FriendRequestData"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FriendTargetRequest": {
+            "description": "删除好友、拉黑、取消拉黑时指定对方的用户ID",
             "type": "object",
             "required": [
-                "new_group",
                 "user_id"
             ],
             "properties": {
-                "new_group": {
-                    "type": "string",
-                    "enum": [
-                        "user",
-                        "admin"
-                    ]
-                },
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
-        "dto.AuthData": {
+        "dto.HeadImageData": {
             "type": "object",
             "properties": {
-                "expires_at": {
-                    "description": "有效期，24h",
-                    "type": "integer"
-                },
-                "token": {
-                    "description": "JWT Token",
+                "head_image_path": {
                     "type": "string"
                 },
-                "user": {
-                    "description": "用户",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/dto.CommonUserData"
-                        }
-                    ]
+                "head_image_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "pending_review": {
+                    "description": "开启审核时为true，头像已生效，管理员驳回时会改回原头像",
+                    "type": "boolean"
                 }
             }
         },
-        "dto.CommonAdminResourceData": {
+        "dto.LoginRequest": {
+            "description": "登录信息",
             "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "prq_skill_id": {
-                    "type": "integer"
-                },
-                "resource_id": {
-                    "type": "integer"
-                },
-                "resource_name": {
-                    "type": "string"
-                },
-                "skill_group": {
+                "password": {
                     "type": "string"
                 },
-                "updated_at": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "dto.CommonAdminResourcePageData": {
+        "dto.ModerationPageData": {
             "type": "object",
             "properties": {
                 "list": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.CommonAdminResourceData"
+                        "$ref": "#/definitions/dto.ModerationRecordData"
                     }
                 },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "page": {
                     "type": "integer"
                 },
@@ -1491,132 +5797,212 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.CommonRelationResourceData": {
+        "dto.ModerationRecordData": {
             "type": "object",
             "properties": {
-                "description": {
+                "created_at": {
                     "type": "string"
                 },
-                "prq_skill_id": {
-                    "type": "integer"
+                "field": {
+                    "type": "string"
                 },
-                "resource_id": {
+                "moderation_id": {
                     "type": "integer"
                 },
-                "resource_name": {
+                "new_head_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "new_value": {
                     "type": "string"
                 },
-                "skill_group": {
+                "previous_head_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "previous_value": {
+                    "description": "审核前最后一次通过的值，驳回时恢复成它",
                     "type": "string"
-                }
-            }
-        },
-        "dto.CommonResourceCreateRequest": {
-            "description": "用于achievements/items/cards，skills可额外携带技能字段",
-            "type": "object",
-            "properties": {
-                "description": {
+                },
+                "reason": {
                     "type": "string"
                 },
-                "name": {
+                "reviewed_at": {
                     "type": "string"
                 },
-                "prq_skill_id": {
+                "reviewer_id": {
                     "type": "integer"
                 },
-                "skill_group": {
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
                     "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.CommonResourceUpdateRequest": {
-            "description": "用于achievements/items/cards，skills可额外携带技能字段",
+        "dto.ModerationRejectData": {
             "type": "object",
             "properties": {
-                "description": {
-                    "type": "string"
+                "record": {
+                    "$ref": "#/definitions/dto.ModerationRecordData"
                 },
-                "id": {
-                    "type": "integer"
+                "reverted": {
+                    "description": "用户在审核前又改过时为false，此时不恢复原值",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.PaginatedData": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "description": "查询所得列表"
                 },
-                "name": {
+                "next_cursor": {
+                    "description": "游标模式下一页的游标，没有下一页时不返回",
                     "type": "string"
                 },
-                "prq_skill_id": {
+                "page": {
+                    "description": "页码",
                     "type": "integer"
                 },
-                "skill_group": {
-                    "type": "string"
+                "page_size": {
+                    "description": "每页多少",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "返回数据总数，游标模式默认不统计，此时不返回",
+                    "type": "integer"
                 }
             }
         },
-        "dto.CommonUserData": {
+        "dto.ProfileChangesData": {
             "type": "object",
             "properties": {
-                "group": {
-                    "description": "权限组",
-                    "type": "string"
+                "full_resync": {
+                    "description": "为true时增量无法覆盖(变更太多或since比当前修订号还新)，客户端应重新拉取完整存档",
+                    "type": "boolean"
                 },
-                "head_image_path": {
-                    "description": "头像路径",
-                    "type": "string"
+                "profile": {
+                    "description": "资料或金币变化时才返回",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.CommonUserData"
+                        }
+                    ]
                 },
-                "select_coin": {
-                    "description": "抽卡货币",
-                    "type": "integer"
+                "relations": {
+                    "description": "变化过的关联，同一个关联只返回最终状态",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ChangedRelationData"
+                    }
                 },
-                "strength_coin": {
-                    "description": "强化货币",
+                "revision": {
+                    "description": "当前存档修订号，下次增量同步作为since带上",
                     "type": "integer"
                 },
-                "user_id": {
-                    "description": "用户ID",
+                "since": {
+                    "description": "请求里的since",
                     "type": "integer"
+                }
+            }
+        },
+        "dto.ProfileSnapshotData": {
+            "type": "object",
+            "properties": {
+                "profile": {
+                    "description": "用户基础信息和金币",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.CommonUserData"
+                        }
+                    ]
                 },
-                "username": {
-                    "description": "用户名",
-                    "type": "string"
+                "relations": {
+                    "description": "按类型(achievements/skills/items/cards)分组的全部关联数据",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/dto.CommonUserRelationData"
+                        }
+                    }
+                },
+                "revision": {
+                    "description": "存档修订号，保存存档时作为base_revision带回",
+                    "type": "integer"
                 }
             }
         },
-        "dto.CommonUserRelationData": {
+        "dto.ProfileSnapshotRequest": {
+            "description": "base_revision为上次读取/保存存档时拿到的revision，期间存档被改过时返回409 金币不传则不修改；relations只包含要同步的类型，出现的类型按列表整体覆盖，列表里没有的关联会被删除",
             "type": "object",
+            "required": [
+                "base_revision"
+            ],
             "properties": {
-                "claimed": {
-                    "type": "boolean"
+                "base_revision": {
+                    "type": "integer"
                 },
-                "claimed_at": {
-                    "type": "string"
+                "relations": {
+                    "description": "key为关联类型(achievements/skills/items/cards)",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/dto.SnapshotRelationEntry"
+                        }
+                    }
                 },
-                "complete_at": {
-                    "type": "string"
+                "select_coin": {
+                    "type": "integer"
                 },
-                "created_at": {
+                "strength_coin": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.PublicUserData": {
+            "type": "object",
+            "properties": {
+                "head_image_path": {
                     "type": "string"
                 },
-                "is_complete": {
-                    "type": "boolean"
-                },
-                "resource": {
-                    "$ref": "#/definitions/dto.CommonRelationResourceData"
+                "head_image_thumbnails": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 },
-                "skill_grade": {
+                "user_id": {
                     "type": "integer"
                 },
-                "updated_at": {
+                "username": {
                     "type": "string"
                 }
             }
         },
-        "dto.CommonUserRelationPageData": {
+        "dto.PublicUserPageData": {
             "type": "object",
             "properties": {
                 "list": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/dto.CommonUserRelationData"
+                        "$ref": "#/definitions/dto.PublicUserData"
                     }
                 },
+                "next_cursor": {
+                    "type": "string"
+                },
                 "page": {
                     "type": "integer"
                 },
@@ -1628,39 +6014,38 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.LoginRequest": {
-            "description": "登录信息",
+        "dto.PushEvent": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "at": {
+                    "description": "事件产生时间",
                     "type": "string"
                 },
-                "username": {
+                "data": {
+                    "description": "事件内容，结构随Type变化"
+                },
+                "id": {
+                    "description": "推送序号，同一进程内递增，客户端可以用来判断有没有漏事件",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "coins_changed/relation_created/relation_updated/relation_deleted/banned/forced_logout/moderation_rejected",
                     "type": "string"
                 }
             }
         },
-        "dto.PaginatedData": {
+        "dto.ReadinessData": {
             "type": "object",
             "properties": {
-                "list": {
-                    "description": "查询所得列表"
-                },
-                "page": {
-                    "description": "页码",
-                    "type": "integer"
-                },
-                "page_size": {
-                    "description": "每页多少",
-                    "type": "integer"
+                "checks": {
+                    "description": "key为database/migrations/storage",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/dto.DependencyStatus"
+                    }
                 },
-                "total": {
-                    "description": "返回数据总数",
-                    "type": "integer"
+                "status": {
+                    "type": "string"
                 }
             }
         },
@@ -1698,6 +6083,47 @@ const docTemplate = `{
                 "message": {
                     "description": "返回的消息",
                     "type": "string"
+                },
+                "request_id": {
+                    "description": "请求ID，出错(4xx/5xx)时由中间件填入，和响应头X-Request-ID相同，反馈问题时提供给后端查日志",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SelectAvatarPresetRequest": {
+            "description": "选用预设头像不受头像修改间隔限制",
+            "type": "object",
+            "required": [
+                "preset_id"
+            ],
+            "properties": {
+                "preset_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "可选，客户端读到的版本号，与当前不一致时返回409",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SnapshotRelationEntry": {
+            "description": "保存存档时关联的目标状态，skill_grade仅skills可用",
+            "type": "object",
+            "required": [
+                "resource_id"
+            ],
+            "properties": {
+                "claimed": {
+                    "type": "boolean"
+                },
+                "is_complete": {
+                    "type": "boolean"
+                },
+                "resource_id": {
+                    "type": "integer"
+                },
+                "skill_grade": {
+                    "type": "integer"
                 }
             }
         },
@@ -1710,6 +6136,10 @@ const docTemplate = `{
             "properties": {
                 "coin": {
                     "type": "integer"
+                },
+                "version": {
+                    "description": "可选，客户端读到的版本号，与当前不一致时返回409",
+                    "type": "integer"
                 }
             }
         },
@@ -1742,6 +6172,10 @@ const docTemplate = `{
                     "type": "string",
                     "maxLength": 20,
                     "minLength": 3
+                },
+                "version": {
+                    "description": "可选，客户端读到的版本号，与当前不一致时返回409",
+                    "type": "integer"
                 }
             }
         },
@@ -1787,6 +6221,10 @@ const docTemplate = `{
                 },
                 "skill_grade": {
                     "type": "integer"
+                },
+                "version": {
+                    "description": "可选，客户端读到的版本号，与当前不一致时返回409",
+                    "type": "integer"
                 }
             }
         }
@@ -78,10 +78,11 @@ type CommonAdminResourceData struct {
 }
 
 type CommonAdminResourcePageData struct {
-	List     []CommonAdminResourceData `json:"list"`
-	Total    int64                     `json:"total"`
-	Page     int                       `json:"page"`
-	PageSize int                       `json:"page_size"`
+	List       []CommonAdminResourceData `json:"list"`
+	Total      *int64                    `json:"total,omitempty"`
+	Page       int                       `json:"page"`
+	PageSize   int                       `json:"page_size"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
 }
 
 func BuildAdminAchievementData(record models.Achievement) AdminAchievementData {
@@ -0,0 +1,30 @@
+package dto
+
+import "MuXi/2026-MuxiShooter-Backend/models"
+
+// AvatarPresetData 预设头像，unlocked只在给用户看的列表里有意义
+type AvatarPresetData struct {
+	PresetID            uint              `json:"preset_id"`
+	Name                string            `json:"name"`
+	HeadImagePath       string            `json:"head_image_path"`
+	HeadImageThumbnails map[string]string `json:"head_image_thumbnails,omitempty"`
+	UnlockType          string            `json:"unlock_type"`
+	UnlockResourceID    *uint             `json:"unlock_resource_id,omitempty"`
+	SortOrder           int               `json:"sort_order"`
+	Enabled             bool              `json:"enabled"`
+	Unlocked            bool              `json:"unlocked"`
+}
+
+func BuildAvatarPresetData(preset *models.AvatarPreset, unlocked bool) AvatarPresetData {
+	return AvatarPresetData{
+		PresetID:            preset.ID,
+		Name:                preset.Name,
+		HeadImagePath:       FileURL(preset.HeadImagePath),
+		HeadImageThumbnails: FileURLs(preset.HeadImageThumbnails),
+		UnlockType:          preset.UnlockType,
+		UnlockResourceID:    preset.UnlockResourceID,
+		SortOrder:           preset.SortOrder,
+		Enabled:             preset.Enabled,
+		Unlocked:            unlocked,
+	}
+}
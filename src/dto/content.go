@@ -0,0 +1,48 @@
+package dto
+
+// @summary		游戏内容文件
+// @description	一次性描述achievements/skills/items/cards四类资源，资源之间用名称关联
+// @description	支持JSON和YAML两种格式，YAML的字段名与JSON一致
+type ContentFile struct {
+	Achievements []ContentResource `json:"achievements"`
+	Skills       []ContentSkill    `json:"skills"`
+	Items        []ContentResource `json:"items"`
+	Cards        []ContentResource `json:"cards"`
+}
+
+type ContentResource struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ContentSkill struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SkillGroup  string `json:"skill_group"`
+	//前置技能名称，可以引用同一文件或数据库中已有的技能
+	Prerequisite string `json:"prerequisite,omitempty"`
+}
+
+type ContentFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type ContentDiffEntry struct {
+	//资源类型(achievements/skills/items/cards)
+	Type string `json:"type"`
+	Name string `json:"name"`
+	//create/update/unchanged
+	Action  string               `json:"action"`
+	Changes []ContentFieldChange `json:"changes,omitempty"`
+}
+
+type ContentImportResult struct {
+	//为true时只计算差异，没有写入数据库
+	DryRun    bool               `json:"dry_run"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Unchanged int                `json:"unchanged"`
+	Entries   []ContentDiffEntry `json:"entries"`
+}
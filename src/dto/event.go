@@ -0,0 +1,35 @@
+package dto
+
+import "time"
+
+// PushEvent 通过/api/events推送给客户端的事件，SSE的event字段就是Type
+type PushEvent struct {
+	//推送序号，同一进程内递增，客户端可以用来判断有没有漏事件
+	ID uint64 `json:"id"`
+	//coins_changed/relation_created/relation_updated/relation_deleted/banned/forced_logout/moderation_rejected
+	Type string `json:"type"`
+	//事件内容，结构随Type变化
+	Data interface{} `json:"data,omitempty"`
+	//事件产生时间
+	At time.Time `json:"at"`
+}
+
+type CoinsChangedEventData struct {
+	StrengthCoin uint   `json:"strength_coin"`
+	SelectCoin   uint   `json:"select_coin"`
+	Version      uint64 `json:"version"`
+}
+
+type RelationEventData struct {
+	//关联类型(achievements/skills/items/cards)
+	Type string `json:"type"`
+	//资源ID
+	ResourceID uint `json:"resource_id"`
+	//关联的当前状态，删除和批量发放时没有
+	Relation *CommonUserRelationData `json:"relation,omitempty"`
+}
+
+type SessionEventData struct {
+	//原因，给客户端展示用
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,22 @@
+package dto
+
+// FileGCReport 一次上传文件垃圾回收的结果，dry-run时列出的是将要做的修改
+type FileGCReport struct {
+	DryRun bool `json:"dry_run"`
+	//存储里扫描到的对象数
+	Scanned int `json:"scanned"`
+	//和实际引用次数不一致而被修正的引用计数
+	RepairedRefs []FileRefRepair `json:"repaired_refs"`
+	//引用计数为0被删除的文件
+	Purged []string `json:"purged"`
+	//存储里有但既没有引用计数记录也没被引用的文件(旧版本遗留或上传中途失败)
+	Orphans []string `json:"orphans"`
+	//删除失败的数量，下次回收会重试
+	Failed int `json:"failed"`
+}
+
+type FileRefRepair struct {
+	Key  string `json:"key"`
+	From int    `json:"from"`
+	To   int    `json:"to"`
+}
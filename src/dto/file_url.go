@@ -0,0 +1,29 @@
+package dto
+
+import "strings"
+
+var fileURLResolver func(key string) string
+
+// SetFileURLResolver 启动时设置，把库里存的文件key转成客户端访问的URL；不设置时原样返回key
+func SetFileURLResolver(resolver func(key string) string) {
+	fileURLResolver = resolver
+}
+
+// FileURL static/下的默认资源不在存储里，和已经是完整URL的一样原样返回
+func FileURL(key string) string {
+	if key == "" || fileURLResolver == nil || strings.HasPrefix(key, "static/") || strings.Contains(key, "://") {
+		return key
+	}
+	return fileURLResolver(key)
+}
+
+func FileURLs(keys map[string]string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	urls := make(map[string]string, len(keys))
+	for name, key := range keys {
+		urls[name] = FileURL(key)
+	}
+	return urls
+}
@@ -0,0 +1,89 @@
+package dto
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"time"
+)
+
+// PublicUserData 给其他玩家看的用户信息，是CommonUserData去掉金币、权限组和版本号后的投影
+type PublicUserData struct {
+	UserID              uint              `json:"user_id"`
+	Username            string            `json:"username"`
+	HeadImagePath       string            `json:"head_image_path"`
+	HeadImageThumbnails map[string]string `json:"head_image_thumbnails,omitempty"`
+}
+
+type FriendData struct {
+	User PublicUserData `json:"user"`
+	//成为好友的时间
+	Since time.Time `json:"since"`
+}
+
+type FriendRequestData struct {
+	RequestID uint           `json:"request_id"`
+	From      PublicUserData `json:"from"`
+	To        PublicUserData `json:"to"`
+	//pending/accepted/declined/cancelled
+	Status      string     `json:"status"`
+	Message     string     `json:"message"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+type BlockedUserData struct {
+	User      PublicUserData `json:"user"`
+	BlockedAt time.Time      `json:"blocked_at"`
+}
+
+type FriendPageData struct {
+	List       []FriendData `json:"list"`
+	Total      *int64       `json:"total,omitempty"`
+	Page       int          `json:"page"`
+	PageSize   int          `json:"page_size"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type FriendRequestPageData struct {
+	List       []FriendRequestData `json:"list"`
+	Total      *int64              `json:"total,omitempty"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+type BlockedUserPageData struct {
+	List       []BlockedUserData `json:"list"`
+	Total      *int64            `json:"total,omitempty"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type PublicUserPageData struct {
+	List       []PublicUserData `json:"list"`
+	Total      *int64           `json:"total,omitempty"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func BuildPublicUserData(user models.User) PublicUserData {
+	return PublicUserData{
+		UserID:              user.ID,
+		Username:            user.Username,
+		HeadImagePath:       FileURL(user.HeadImagePath),
+		HeadImageThumbnails: FileURLs(user.HeadImageThumbnails),
+	}
+}
+
+func BuildFriendRequestData(request models.FriendRequest) FriendRequestData {
+	return FriendRequestData{
+		RequestID:   request.ID,
+		From:        BuildPublicUserData(request.FromUser),
+		To:          BuildPublicUserData(request.ToUser),
+		Status:      request.Status,
+		Message:     request.Message,
+		CreatedAt:   request.CreatedAt,
+		RespondedAt: request.RespondedAt,
+	}
+}
@@ -0,0 +1,16 @@
+package dto
+
+// DependencyStatus 就绪检查里单个依赖的结果
+type DependencyStatus struct {
+	//ok或error
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// ReadinessData 全部依赖都ok时status为ok
+type ReadinessData struct {
+	Status string `json:"status"`
+	//key为database/migrations/storage
+	Checks map[string]DependencyStatus `json:"checks"`
+}
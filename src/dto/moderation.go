@@ -0,0 +1,44 @@
+package dto
+
+import "time"
+
+// ModerationRecordData 审核记录，头像的值是可以直接访问的URL
+type ModerationRecordData struct {
+	ModerationID uint   `json:"moderation_id"`
+	UserID       uint   `json:"user_id"`
+	Field        string `json:"field"`
+	Status       string `json:"status"`
+	//审核前最后一次通过的值，驳回时恢复成它
+	PreviousValue          string            `json:"previous_value"`
+	PreviousHeadThumbnails map[string]string `json:"previous_head_thumbnails,omitempty"`
+	NewValue               string            `json:"new_value"`
+	NewHeadThumbnails      map[string]string `json:"new_head_thumbnails,omitempty"`
+	Reason                 string            `json:"reason,omitempty"`
+	ReviewerID             *uint             `json:"reviewer_id,omitempty"`
+	ReviewedAt             *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt              time.Time         `json:"created_at"`
+	UpdatedAt              time.Time         `json:"updated_at"`
+}
+
+type ModerationPageData struct {
+	List       []ModerationRecordData `json:"list"`
+	Total      *int64                 `json:"total,omitempty"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"page_size"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+}
+
+type ModerationRejectData struct {
+	Record ModerationRecordData `json:"record"`
+	//用户在审核前又改过时为false，此时不恢复原值
+	Reverted bool `json:"reverted"`
+}
+
+type ModerationEventData struct {
+	//username或head_image
+	Field string `json:"field"`
+	//驳回原因
+	Reason string `json:"reason,omitempty"`
+	//是否已恢复为修改前的值
+	Reverted bool `json:"reverted"`
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	models "MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
 	"time"
 )
 
@@ -109,21 +110,26 @@ type CommonRelationResourceData struct {
 }
 
 type CommonUserRelationData struct {
-	IsComplete bool                       `json:"is_complete"`
-	CompleteAt *time.Time                 `json:"complete_at,omitempty"`
-	SkillGrade uint                       `json:"skill_grade,omitempty"`
-	Claimed    bool                       `json:"claimed"`
-	ClaimedAt  *time.Time                 `json:"claimed_at,omitempty"`
-	CreatedAt  time.Time                  `json:"created_at"`
-	UpdatedAt  time.Time                  `json:"updated_at"`
-	Resource   CommonRelationResourceData `json:"resource"`
+	IsComplete bool       `json:"is_complete"`
+	CompleteAt *time.Time `json:"complete_at,omitempty"`
+	SkillGrade uint       `json:"skill_grade,omitempty"`
+	Claimed    bool       `json:"claimed"`
+	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	//乐观锁版本号
+	Version uint64 `json:"version"`
+	//强ETag，更新时放进If-Match里，记录在这期间被改过则返回412
+	ETag     string                     `json:"etag"`
+	Resource CommonRelationResourceData `json:"resource"`
 }
 
 type CommonUserRelationPageData struct {
-	List     []CommonUserRelationData `json:"list"`
-	Total    int64                    `json:"total"`
-	Page     int                      `json:"page"`
-	PageSize int                      `json:"page_size"`
+	List       []CommonUserRelationData `json:"list"`
+	Total      *int64                   `json:"total,omitempty"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
 }
 
 func BuildCommonUserAchievementRelationList(records []models.UserAchievement) []CommonUserRelationData {
@@ -136,6 +142,8 @@ func BuildCommonUserAchievementRelationList(records []models.UserAchievement) []
 			ClaimedAt:  record.ClaimedAt,
 			CreatedAt:  record.CreatedAt,
 			UpdatedAt:  record.UpdatedAt,
+			Version:    record.Version,
+			ETag:       utils.VersionETag(record.Version),
 			Resource: CommonRelationResourceData{
 				ResourceID:   record.Achievement.ID,
 				ResourceName: record.Achievement.Name,
@@ -157,6 +165,8 @@ func BuildCommonUserSkillRelationList(records []models.UserSkill) []CommonUserRe
 			ClaimedAt:  record.ClaimedAt,
 			CreatedAt:  record.CreatedAt,
 			UpdatedAt:  record.UpdatedAt,
+			Version:    record.Version,
+			ETag:       utils.VersionETag(record.Version),
 			Resource: CommonRelationResourceData{
 				ResourceID:   record.Skill.ID,
 				ResourceName: record.Skill.Name,
@@ -179,6 +189,8 @@ func BuildCommonUserItemRelationList(records []models.UserItem) []CommonUserRela
 			ClaimedAt:  record.ClaimedAt,
 			CreatedAt:  record.CreatedAt,
 			UpdatedAt:  record.UpdatedAt,
+			Version:    record.Version,
+			ETag:       utils.VersionETag(record.Version),
 			Resource: CommonRelationResourceData{
 				ResourceID:   record.Item.ID,
 				ResourceName: record.Item.Name,
@@ -199,6 +211,8 @@ func BuildCommonUserCardRelationList(records []models.UserCard) []CommonUserRela
 			ClaimedAt:  record.ClaimedAt,
 			CreatedAt:  record.CreatedAt,
 			UpdatedAt:  record.UpdatedAt,
+			Version:    record.Version,
+			ETag:       utils.VersionETag(record.Version),
 			Resource: CommonRelationResourceData{
 				ResourceID:   record.Card.ID,
 				ResourceName: record.Card.Name,
@@ -291,3 +305,23 @@ func BuildUserCardRelationList(records []models.UserCard) []UserCardRelationData
 	}
 	return result
 }
+
+// CatalogueEntryData 图鉴里的一条资源定义，附带当前用户的拥有/完成状态
+// owned=false时用户状态相关字段都是零值，客户端据此显示为未解锁
+type CatalogueEntryData struct {
+	Resource   CommonRelationResourceData `json:"resource"`
+	Owned      bool                       `json:"owned"`
+	IsComplete bool                       `json:"is_complete"`
+	CompleteAt *time.Time                 `json:"complete_at,omitempty"`
+	SkillGrade uint                       `json:"skill_grade,omitempty"`
+	Claimed    bool                       `json:"claimed"`
+	ClaimedAt  *time.Time                 `json:"claimed_at,omitempty"`
+	AcquiredAt *time.Time                 `json:"acquired_at,omitempty"`
+}
+
+type CatalogueData struct {
+	Type  string               `json:"type"`
+	List  []CatalogueEntryData `json:"list"`
+	Total int                  `json:"total"`
+	Owned int                  `json:"owned"`
+}
@@ -1,6 +1,9 @@
 package dto
 
-import "mime/multipart"
+import (
+	"mime/multipart"
+	"time"
+)
 
 // @summary		用户注册请求
 // @description	注册信息
@@ -27,18 +30,32 @@ type UpdatePasswordRequest struct {
 // @description	修改用户名
 type UpdateUsernameRequest struct {
 	NewUsername string `json:"new_username" binding:"required,min=3,max=20"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `json:"version,omitempty"`
 }
 
 // @summary		用户修改头像
 // @description	用户修改头像
 type UpdateHeadImageRequest struct {
 	NewHeadImage *multipart.FileHeader `form:"new_head_image" binding:"required"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `form:"version"`
+}
+
+// @summary		用户选用预设头像
+// @description	选用预设头像不受头像修改间隔限制
+type SelectAvatarPresetRequest struct {
+	PresetID uint `json:"preset_id" binding:"required,gt=0"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `json:"version,omitempty"`
 }
 
 // @summary		用户按类型修改金币
 // @description	通过query参数type(strength/select)修改对应金币值
 type UpdateCoinByTypeRequest struct {
 	Coin *uint `json:"coin" binding:"required"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `json:"version,omitempty"`
 }
 
 // @summary		管理员删除用户请求
@@ -79,7 +96,7 @@ type AdminCreateCardRequest struct {
 // @summary		通用资源创建请求
 // @description	用于achievements/items/cards，skills可额外携带技能字段
 type CommonResourceCreateRequest struct {
-	Name        string `json:"name"`
+	Name        string `json:"name" binding:"required,min=1,max=50"`
 	Description string `json:"description"`
 	SkillGroup  string `json:"skill_group,omitempty"`
 	PrqSkillID  uint   `json:"prq_skill_id,omitempty"`
@@ -114,8 +131,8 @@ type AdminUpdateCardRequest struct {
 // @summary		通用资源更新请求
 // @description	用于achievements/items/cards，skills可额外携带技能字段
 type CommonResourceUpdateRequest struct {
-	ID          uint    `json:"id"`
-	Name        *string `json:"name,omitempty"`
+	ID          uint    `json:"id" binding:"required,gt=0"`
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=50"`
 	Description *string `json:"description,omitempty"`
 	SkillGroup  *string `json:"skill_group,omitempty"`
 	PrqSkillID  *uint   `json:"prq_skill_id,omitempty"`
@@ -127,6 +144,84 @@ type AdminDeleteResourceByTypeRequest struct {
 	ID uint `json:"id" binding:"required,gt=0"`
 }
 
+// @summary		管理员批量创建基础资源请求
+// @description	rows里每一项与单个创建的请求体一致，条数上限见bulk.max_rows(默认500)
+type AdminBulkResourceCreateRequest struct {
+	Rows []CommonResourceCreateRequest `json:"rows" binding:"required,min=1,dive"`
+}
+
+// @summary		管理员批量更新基础资源请求
+// @description	rows里每一项与单个更新的请求体一致，条数上限见bulk.max_rows(默认500)
+type AdminBulkResourceUpdateRequest struct {
+	Rows []CommonResourceUpdateRequest `json:"rows" binding:"required,min=1,dive"`
+}
+
+// @summary		管理员批量删除基础资源请求
+// @description	按ID批量删除，条数上限见bulk.max_rows(默认500)
+type AdminBulkResourceDeleteRequest struct {
+	IDs []uint `json:"ids" binding:"required,min=1,dive,gt=0"`
+}
+
+// @summary		管理员批量发放/回收资源请求
+// @description	user_ids与筛选条件同时给出时取交集；都不给时需要显式设置all_users=true
+type AdminBulkRelationRequest struct {
+	ResourceID uint   `json:"resource_id" binding:"required,gt=0"`
+	UserIDs    []uint `json:"user_ids" binding:"omitempty,dive,gt=0"`
+	//只选这个时间之前注册的用户(RFC3339)
+	RegisteredBefore *time.Time `json:"registered_before,omitempty"`
+	//只选这个时间之后注册的用户(RFC3339)
+	RegisteredAfter *time.Time `json:"registered_after,omitempty"`
+	//只选某个权限组的用户
+	Group    string `json:"group,omitempty" binding:"omitempty,oneof=user admin"`
+	AllUsers bool   `json:"all_users"`
+}
+
+// @summary		管理员为用户创建关联请求
+// @description	按用户ID和资源ID创建关联记录
+type AdminUserRelationCreateRequest struct {
+	UserID     uint `json:"user_id" binding:"required,gt=0"`
+	ResourceID uint `json:"resource_id" binding:"required,gt=0"`
+}
+
+// @summary		管理员更新用户关联请求
+// @description	按用户ID和资源ID更新关联记录，skills可额外更新skill_grade
+type AdminUserRelationUpdateRequest struct {
+	UserID     uint  `json:"user_id" binding:"required,gt=0"`
+	ResourceID uint  `json:"resource_id" binding:"required,gt=0"`
+	IsComplete *bool `json:"is_complete,omitempty"`
+	Claimed    *bool `json:"claimed,omitempty"`
+	SkillGrade *uint `json:"skill_grade,omitempty"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `json:"version,omitempty"`
+	//来自If-Match请求头，不从body里读
+	IfMatch string `json:"-"`
+}
+
+// @summary		管理员删除用户关联请求
+// @description	按用户ID和资源ID删除关联记录
+type AdminUserRelationDeleteRequest struct {
+	UserID     uint `json:"user_id" binding:"required,gt=0"`
+	ResourceID uint `json:"resource_id" binding:"required,gt=0"`
+}
+
+// @summary		管理员调整用户金币请求
+// @description	delta为增减量(不能为0)，调整后不能为负数；reason必填，会记入流水
+type AdminAdjustCoinRequest struct {
+	UserID   uint   `json:"user_id" binding:"required,gt=0"`
+	CoinType string `json:"coin_type" binding:"required,oneof=strength select"`
+	Delta    int64  `json:"delta" binding:"required"`
+	Reason   string `json:"reason" binding:"required,min=2,max=255"`
+}
+
+// @summary		管理员重置用户资料请求
+// @description	reset_username为true时改成new_username，不填则改成player_<user_id>；reset_head_image为true时恢复默认头像
+type AdminResetUserProfileRequest struct {
+	UserID         uint   `json:"user_id" binding:"required,gt=0"`
+	ResetUsername  bool   `json:"reset_username"`
+	NewUsername    string `json:"new_username,omitempty" binding:"omitempty,min=3,max=20"`
+	ResetHeadImage bool   `json:"reset_head_image"`
+}
+
 // @summary		用户创建关联请求
 // @description	按资源ID创建本人关联记录
 type UserRelationCreateRequest struct {
@@ -140,6 +235,10 @@ type UserRelationUpdateRequest struct {
 	IsComplete *bool `json:"is_complete,omitempty"`
 	Claimed    *bool `json:"claimed,omitempty"`
 	SkillGrade *uint `json:"skill_grade,omitempty"`
+	//可选，客户端读到的版本号，与当前不一致时返回409
+	Version *uint64 `json:"version,omitempty"`
+	//来自If-Match请求头，不从body里读
+	IfMatch string `json:"-"`
 }
 
 // @summary		用户删除关联请求
@@ -147,3 +246,75 @@ type UserRelationUpdateRequest struct {
 type UserRelationDeleteRequest struct {
 	ResourceID uint `json:"resource_id" binding:"required,gt=0"`
 }
+
+// @summary		存档中的一条关联
+// @description	保存存档时关联的目标状态，skill_grade仅skills可用
+type SnapshotRelationEntry struct {
+	ResourceID uint `json:"resource_id" binding:"required,gt=0"`
+	IsComplete bool `json:"is_complete"`
+	Claimed    bool `json:"claimed"`
+	SkillGrade uint `json:"skill_grade"`
+}
+
+// @summary		保存存档请求
+// @description	base_revision为上次读取/保存存档时拿到的revision，期间存档被改过时返回409
+// @description	金币不传则不修改；relations只包含要同步的类型，出现的类型按列表整体覆盖，列表里没有的关联会被删除
+type ProfileSnapshotRequest struct {
+	BaseRevision *uint64 `json:"base_revision" binding:"required"`
+	StrengthCoin *uint   `json:"strength_coin,omitempty"`
+	SelectCoin   *uint   `json:"select_coin,omitempty"`
+	//key为关联类型(achievements/skills/items/cards)
+	Relations map[string][]SnapshotRelationEntry `json:"relations" binding:"dive,dive"`
+}
+
+// @summary		发送好友申请请求
+// @description	对方已经向自己发过待处理的申请时直接成为好友
+type FriendRequestCreateRequest struct {
+	UserID  uint   `json:"user_id" binding:"required,gt=0"`
+	Message string `json:"message" binding:"max=100"`
+}
+
+// @summary		按用户操作的请求
+// @description	删除好友、拉黑、取消拉黑时指定对方的用户ID
+type FriendTargetRequest struct {
+	UserID uint `json:"user_id" binding:"required,gt=0"`
+}
+
+// @summary		管理员创建预设头像请求
+// @description	multipart表单，图片按头像的规则校验和重新编码；unlock_type为空表示所有人可用
+type AdminAvatarPresetCreateRequest struct {
+	Image            *multipart.FileHeader `form:"image" binding:"required"`
+	Name             string                `form:"name" binding:"required,min=1,max=50"`
+	UnlockType       string                `form:"unlock_type" binding:"omitempty,oneof=achievements cards"`
+	UnlockResourceID uint                  `form:"unlock_resource_id"`
+	SortOrder        int                   `form:"sort_order"`
+}
+
+// @summary		管理员修改预设头像请求
+// @description	只修改传了的字段，unlock_type传空字符串表示取消解锁条件；图片不能修改，需要换图时删掉重建
+type AdminAvatarPresetUpdateRequest struct {
+	PresetID         uint    `json:"preset_id" binding:"required,gt=0"`
+	Name             *string `json:"name,omitempty" binding:"omitempty,min=1,max=50"`
+	UnlockType       *string `json:"unlock_type,omitempty"`
+	UnlockResourceID *uint   `json:"unlock_resource_id,omitempty"`
+	SortOrder        *int    `json:"sort_order,omitempty"`
+	Enabled          *bool   `json:"enabled,omitempty"`
+}
+
+// @summary		管理员删除预设头像请求
+// @description	已经在用这个预设的用户头像不受影响
+type AdminAvatarPresetDeleteRequest struct {
+	PresetID uint `json:"preset_id" binding:"required,gt=0"`
+}
+
+// @summary		管理员通过审核请求
+type AdminModerationApproveRequest struct {
+	ModerationID uint `json:"moderation_id" binding:"required,gt=0"`
+}
+
+// @summary		管理员驳回审核请求
+// @description	驳回后恢复为修改前的值，reason会推送给用户
+type AdminModerationRejectRequest struct {
+	ModerationID uint   `json:"moderation_id" binding:"required,gt=0"`
+	Reason       string `json:"reason" binding:"max=255"`
+}
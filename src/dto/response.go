@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // @description	通用响应结构体
 type Response struct {
 	//http状态码
@@ -8,6 +10,8 @@ type Response struct {
 	Message string `json:"message"`
 	//根据具体数据来定类型
 	Data interface{} `json:"data"`
+	//请求ID，出错(4xx/5xx)时由中间件填入，和响应头X-Request-ID相同，反馈问题时提供给后端查日志
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type CommonUserData struct {
@@ -19,10 +23,21 @@ type CommonUserData struct {
 	Group string `json:"group"`
 	//头像路径
 	HeadImagePath string `json:"head_image_path"`
+	//头像缩略图，key为边长，没有缩略图时客户端使用head_image_path
+	HeadImageThumbnails map[string]string `json:"head_image_thumbnails,omitempty"`
 	//强化货币
 	StrengthCoin uint `json:"strength_coin"`
 	//抽卡货币
 	SelectCoin uint `json:"select_coin"`
+	//乐观锁版本号，修改时可带上，期间被别人改过会返回409
+	Version uint64 `json:"version"`
+}
+
+type HeadImageData struct {
+	HeadImagePath       string            `json:"head_image_path"`
+	HeadImageThumbnails map[string]string `json:"head_image_thumbnails"`
+	//开启审核时为true，头像已生效，管理员驳回时会改回原头像
+	PendingReview bool `json:"pending_review,omitempty"`
 }
 
 type AuthData struct {
@@ -37,10 +52,96 @@ type AuthData struct {
 type PaginatedData struct {
 	//查询所得列表
 	List interface{} `json:"list"`
-	//返回数据总数
-	Total int64 `json:"total"`
+	//返回数据总数，游标模式默认不统计，此时不返回
+	Total *int64 `json:"total,omitempty"`
 	//页码
 	Page int `json:"page"`
 	//每页多少
 	PageSize int `json:"page_size"`
+	//游标模式下一页的游标，没有下一页时不返回
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type ProfileSnapshotData struct {
+	//存档修订号，保存存档时作为base_revision带回
+	Revision uint64 `json:"revision"`
+	//用户基础信息和金币
+	Profile CommonUserData `json:"profile"`
+	//按类型(achievements/skills/items/cards)分组的全部关联数据
+	Relations map[string][]CommonUserRelationData `json:"relations"`
+}
+
+type ChangedRelationData struct {
+	//关联类型(achievements/skills/items/cards)
+	Type string `json:"type"`
+	//资源ID
+	ResourceID uint `json:"resource_id"`
+	//为true表示关联已被删除(墓碑)，此时没有relation
+	Deleted bool `json:"deleted"`
+	//关联的当前状态
+	Relation *CommonUserRelationData `json:"relation,omitempty"`
+}
+
+type ProfileChangesData struct {
+	//请求里的since
+	Since uint64 `json:"since"`
+	//当前存档修订号，下次增量同步作为since带上
+	Revision uint64 `json:"revision"`
+	//为true时增量无法覆盖(变更太多或since比当前修订号还新)，客户端应重新拉取完整存档
+	FullResync bool `json:"full_resync"`
+	//资料或金币变化时才返回
+	Profile *CommonUserData `json:"profile,omitempty"`
+	//变化过的关联，同一个关联只返回最终状态
+	Relations []ChangedRelationData `json:"relations"`
+}
+
+type UserExportData struct {
+	//用户基础信息
+	User CommonUserData `json:"user"`
+	//按类型(achievements/skills/items/cards)分组的全部关联数据
+	Relations map[string][]CommonUserRelationData `json:"relations"`
+}
+
+type BulkRowResult struct {
+	//请求中的下标，发放/回收时按匹配到的用户顺序
+	Index int `json:"index"`
+	//资源ID，发放/回收时为用户ID
+	ID uint `json:"id"`
+	//succeeded/failed/skipped
+	Status string `json:"status"`
+	//失败或跳过的原因
+	Error string `json:"error,omitempty"`
+	//成功时的最新数据
+	Data interface{} `json:"data,omitempty"`
+}
+
+type BulkOperationResult struct {
+	//是否整体回滚(atomic模式下有失败行时为true)
+	RolledBack bool `json:"rolled_back"`
+	Total      int  `json:"total"`
+	Succeeded  int  `json:"succeeded"`
+	Failed     int  `json:"failed"`
+	Skipped    int  `json:"skipped"`
+	//发放/回收时分几批写入，所有批次在同一个事务里
+	Batches int             `json:"batches,omitempty"`
+	Rows    []BulkRowResult `json:"rows"`
+}
+
+type CoinAdjustmentData struct {
+	ID      uint `json:"id"`
+	UserID  uint `json:"user_id"`
+	AdminID uint `json:"admin_id"`
+	//strength/select
+	CoinType      string    `json:"coin_type"`
+	Delta         int64     `json:"delta"`
+	BalanceBefore uint      `json:"balance_before"`
+	BalanceAfter  uint      `json:"balance_after"`
+	Reason        string    `json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AdminCoinAdjustData struct {
+	//调整后的用户信息
+	User       CommonUserData     `json:"user"`
+	Adjustment CoinAdjustmentData `json:"adjustment"`
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/storage"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func runGCFiles(args []string) error {
+	fs := flag.NewFlagSet("gc-files", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "只输出要做的修改，不修改数据库也不删文件")
+	grace := fs.Duration("grace", 0, "不处理这段时间内变动过的引用计数和新写入的文件，不填时使用storage.gc_grace_period")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errors.New("用法: gc-files [-dry-run] [-grace 1h]，关闭dry-run请写 -dry-run=false")
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	//显式写了 -grace 0 时按0处理，没写才用配置
+	graceSet := false
+	fs.Visit(func(f *flag.Flag) { graceSet = graceSet || f.Name == "grace" })
+	if !graceSet {
+		*grace = appState.Settings.Storage.GCGracePeriod
+	}
+	fileStorage, err := storage.New(appState.Settings.Storage)
+	if err != nil {
+		return fmt.Errorf("文件存储初始化失败: %w", err)
+	}
+	ctx := context.Background()
+	fileGCService := service.NewFileGCService(repository.NewStoredFileRepository(appState.DB), fileStorage, appState.Logger)
+	report, err := fileGCService.Collect(ctx, *dryRun, *grace)
+	if err != nil {
+		return err
+	}
+
+	for _, repair := range report.RepairedRefs {
+		fmt.Printf("repair  %s %d -> %d\n", repair.Key, repair.From, repair.To)
+	}
+	for _, key := range report.Purged {
+		fmt.Printf("purge   %s\n", key)
+	}
+	for _, key := range report.Orphans {
+		fmt.Printf("orphan  %s\n", key)
+	}
+	fmt.Printf("扫描%d 修正引用计数%d 删除无引用文件%d 删除孤儿文件%d 失败%d\n", report.Scanned, len(report.RepairedRefs), len(report.Purged), len(report.Orphans), report.Failed)
+	if report.DryRun {
+		fmt.Println("dry-run模式，未修改数据库和存储")
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminResourceHandler struct {
+	adminResourceService *service.AdminResourceService
+}
+
+func NewAdminResourceHandler(adminResourceService *service.AdminResourceService) *AdminResourceHandler {
+	return &AdminResourceHandler{adminResourceService: adminResourceService}
+}
+
+// BulkCreateResources godoc
+// @Summary      管理员批量创建基础资源
+// @Description  整个请求在一个事务里执行，每行单独成败并在data.rows里报告
+// @Description  atomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true
+// @Tags         admin-resource
+// @Accept       json
+// @Produce      json
+// @Param        type     query     string                              true   "资源类型(achievements/skills/items/cards)"
+// @Param        atomic   query     bool                                false  "有失败行时是否整体回滚，默认false"
+// @Param        request  body      dto.AdminBulkResourceCreateRequest  true   "批量创建请求体"
+// @Success      200      {object}  dto.Response{data=dto.BulkOperationResult}  "执行完成"
+// @Failure      400      {object}  dto.Response                        "请求参数错误"
+// @Failure      401      {object}  dto.Response                        "登录状态异常"
+// @Failure      422      {object}  dto.Response{data=dto.BulkOperationResult}  "存在失败行，已整体回滚"
+// @Failure      500      {object}  dto.Response                        "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/resources/bulk [post]
+func (h *AdminResourceHandler) BulkCreateResources(c *gin.Context) {
+	resourceType, atomic, ok := parseBulkQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminBulkResourceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	result, err := h.adminResourceService.BulkCreateResources(c.Request.Context(), resourceType, req.Rows, atomic)
+	respondBulkResult(c, result, err)
+}
+
+// BulkUpdateResources godoc
+// @Summary      管理员批量更新基础资源
+// @Description  整个请求在一个事务里执行，每行单独成败并在data.rows里报告
+// @Description  atomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true
+// @Tags         admin-resource
+// @Accept       json
+// @Produce      json
+// @Param        type     query     string                              true   "资源类型(achievements/skills/items/cards)"
+// @Param        atomic   query     bool                                false  "有失败行时是否整体回滚，默认false"
+// @Param        request  body      dto.AdminBulkResourceUpdateRequest  true   "批量更新请求体"
+// @Success      200      {object}  dto.Response{data=dto.BulkOperationResult}  "执行完成"
+// @Failure      400      {object}  dto.Response                        "请求参数错误"
+// @Failure      401      {object}  dto.Response                        "登录状态异常"
+// @Failure      422      {object}  dto.Response{data=dto.BulkOperationResult}  "存在失败行，已整体回滚"
+// @Failure      500      {object}  dto.Response                        "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/update/resources/bulk [put]
+func (h *AdminResourceHandler) BulkUpdateResources(c *gin.Context) {
+	resourceType, atomic, ok := parseBulkQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminBulkResourceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	result, err := h.adminResourceService.BulkUpdateResources(c.Request.Context(), resourceType, req.Rows, atomic)
+	respondBulkResult(c, result, err)
+}
+
+// BulkDeleteResources godoc
+// @Summary      管理员批量删除基础资源
+// @Description  整个请求在一个事务里执行，每行单独成败并在data.rows里报告
+// @Description  atomic=true时只要有一行失败就整体回滚，此时返回422且rolled_back=true
+// @Tags         admin-resource
+// @Accept       json
+// @Produce      json
+// @Param        type     query     string                              true   "资源类型(achievements/skills/items/cards)"
+// @Param        atomic   query     bool                                false  "有失败行时是否整体回滚，默认false"
+// @Param        request  body      dto.AdminBulkResourceDeleteRequest  true   "批量删除请求体"
+// @Success      200      {object}  dto.Response{data=dto.BulkOperationResult}  "执行完成"
+// @Failure      400      {object}  dto.Response                        "请求参数错误"
+// @Failure      401      {object}  dto.Response                        "登录状态异常"
+// @Failure      422      {object}  dto.Response{data=dto.BulkOperationResult}  "存在失败行，已整体回滚"
+// @Failure      500      {object}  dto.Response                        "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/resources/bulk [delete]
+func (h *AdminResourceHandler) BulkDeleteResources(c *gin.Context) {
+	resourceType, atomic, ok := parseBulkQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminBulkResourceDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	result, err := h.adminResourceService.BulkDeleteResources(c.Request.Context(), resourceType, req.IDs, atomic)
+	respondBulkResult(c, result, err)
+}
+
+// BulkGrantResource godoc
+// @Summary      管理员批量发放资源
+// @Description  把一个资源发放给多个用户，用户可以用user_ids显式指定，也可以按注册时间/权限组筛选
+// @Description  已拥有的用户记为skipped；atomic=true时有失败行(用户不存在等)就整体回滚
+// @Description  整个操作在一个事务里完成，中途出错整体回滚；目标用户按bulk.grant_batch_size(默认5000)个一批分批写入，只用来控制单条SQL的大小
+// @Tags         admin-resource
+// @Accept       json
+// @Produce      json
+// @Param        type     query     string                        true   "关联类型(achievements/skills/items/cards)"
+// @Param        atomic   query     bool                          false  "有失败行时是否整体回滚，默认false"
+// @Param        request  body      dto.AdminBulkRelationRequest  true   "批量发放请求体"
+// @Success      200      {object}  dto.Response{data=dto.BulkOperationResult}  "执行完成"
+// @Failure      400      {object}  dto.Response                  "请求参数错误"
+// @Failure      401      {object}  dto.Response                  "登录状态异常"
+// @Failure      404      {object}  dto.Response                  "目标资源不存在"
+// @Failure      422      {object}  dto.Response{data=dto.BulkOperationResult}  "存在失败行，已整体回滚"
+// @Failure      500      {object}  dto.Response                  "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/user-relations/bulk [post]
+func (h *AdminResourceHandler) BulkGrantResource(c *gin.Context) {
+	relationType, atomic, ok := parseBulkQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminBulkRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	result, err := h.adminResourceService.BulkGrantResource(c.Request.Context(), relationType, req, atomic)
+	respondBulkResult(c, result, err)
+}
+
+// BulkRevokeResource godoc
+// @Summary      管理员批量回收资源
+// @Description  从多个用户处回收一个资源，用户选择方式与批量发放相同
+// @Description  未拥有的用户记为skipped；atomic=true时有失败行(用户不存在等)就整体回滚
+// @Description  整个操作在一个事务里完成，中途出错整体回滚；目标用户按bulk.grant_batch_size(默认5000)个一批分批写入，只用来控制单条SQL的大小
+// @Tags         admin-resource
+// @Accept       json
+// @Produce      json
+// @Param        type     query     string                        true   "关联类型(achievements/skills/items/cards)"
+// @Param        atomic   query     bool                          false  "有失败行时是否整体回滚，默认false"
+// @Param        request  body      dto.AdminBulkRelationRequest  true   "批量回收请求体"
+// @Success      200      {object}  dto.Response{data=dto.BulkOperationResult}  "执行完成"
+// @Failure      400      {object}  dto.Response                  "请求参数错误"
+// @Failure      401      {object}  dto.Response                  "登录状态异常"
+// @Failure      404      {object}  dto.Response                  "目标资源不存在"
+// @Failure      422      {object}  dto.Response{data=dto.BulkOperationResult}  "存在失败行，已整体回滚"
+// @Failure      500      {object}  dto.Response                  "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/user-relations/bulk [delete]
+func (h *AdminResourceHandler) BulkRevokeResource(c *gin.Context) {
+	relationType, atomic, ok := parseBulkQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminBulkRelationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	result, err := h.adminResourceService.BulkRevokeResource(c.Request.Context(), relationType, req, atomic)
+	respondBulkResult(c, result, err)
+}
+
+// parseBulkQuery 解析批量接口共用的type和atomic参数，失败时已经写好了响应
+func parseBulkQuery(c *gin.Context) (service.UserRelationType, bool, bool) {
+	relationType, ok := parseRelationTypeQuery(c)
+	if !ok {
+		return "", false, false
+	}
+
+	atomic := false
+	if atomicStr := c.Query("atomic"); atomicStr != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(atomicStr); err != nil {
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "atomic参数格式错误"})
+			return "", false, false
+		}
+	}
+	return relationType, atomic, true
+}
+
+func respondBulkResult(c *gin.Context, result dto.BulkOperationResult, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBulkTooManyRows),
+			errors.Is(err, service.ErrBulkInvalidRow), errors.Is(err, service.ErrBulkUserSelectorRequired),
+			errors.Is(err, service.ErrBulkRegisteredRange), errors.Is(err, service.ErrUnsupportedRelationType):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "目标资源不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "批量操作失败：" + err.Error()})
+		}
+		return
+	}
+
+	if result.RolledBack {
+		c.JSON(http.StatusUnprocessableEntity, dto.Response{Code: http.StatusUnprocessableEntity, Message: "存在失败行，已整体回滚", Data: result})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "执行完成", Data: result})
+}
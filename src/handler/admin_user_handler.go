@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminUserHandler struct {
+	adminUserService *service.AdminUserService
+	logger           *slog.Logger
+}
+
+func NewAdminUserHandler(adminUserService *service.AdminUserService, logger *slog.Logger) *AdminUserHandler {
+	return &AdminUserHandler{adminUserService: adminUserService, logger: logger}
+}
+
+// CreateUserRelation godoc
+// @Summary      管理员为任意用户创建资源关联
+// @Description  通过query参数type(achievements/skills/items/cards)和body中的user_id/resource_id创建关联记录
+// @Tags         admin-user
+// @Accept       json
+// @Produce      json
+// @Param        type  query     string                              true  "关联类型(achievements/skills/items/cards)"
+// @Param        body  body      dto.AdminUserRelationCreateRequest  true  "创建关联请求体"
+// @Success      200   {object}  dto.Response{data=dto.CommonUserRelationData}  "创建成功"
+// @Failure      400   {object}  dto.Response                        "请求参数错误"
+// @Failure      401   {object}  dto.Response                        "登录状态异常"
+// @Failure      404   {object}  dto.Response                        "用户或目标资源不存在"
+// @Failure      409   {object}  dto.Response                        "关联已存在"
+// @Failure      500   {object}  dto.Response                        "创建失败"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/user-relations [post]
+func (h *AdminUserHandler) CreateUserRelation(c *gin.Context) {
+	relationType, ok := parseRelationTypeQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminUserRelationCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.adminUserService.CreateUserRelation(c.Request.Context(), req.UserID, relationType, req.ResourceID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "目标资源不存在"})
+		case errors.Is(err, service.ErrResourceNameExists):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: "关联已存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "创建失败：" + err.Error()})
+		}
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员创建用户关联", "admin_id", adminID, "user_id", req.UserID, "type", relationType, "resource_id", req.ResourceID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "创建成功", Data: data})
+}
+
+// UpdateUserRelation godoc
+// @Summary      管理员更新任意用户的资源关联
+// @Description  通过query参数type和body更新指定用户的关联记录，skills支持skill_grade
+// @Description  带If-Match时记录在此期间被修改过则返回412
+// @Tags         admin-user
+// @Accept       json
+// @Produce      json
+// @Param        type      query     string                              true   "关联类型(achievements/skills/items/cards)"
+// @Param        If-Match  header    string                              false  "关联记录的etag"
+// @Param        body      body      dto.AdminUserRelationUpdateRequest  true   "更新关联请求体"
+// @Success      200   {object}  dto.Response{data=dto.CommonUserRelationData}  "更新成功"
+// @Failure      400   {object}  dto.Response                        "请求参数错误"
+// @Failure      401   {object}  dto.Response                        "登录状态异常"
+// @Failure      404   {object}  dto.Response                        "用户或关联记录不存在"
+// @Failure      409   {object}  dto.Response                        "数据已被其他请求修改"
+// @Failure      412   {object}  dto.Response                        "记录已被修改"
+// @Failure      500   {object}  dto.Response                        "更新失败"
+// @Security     BearerAuth
+// @Router       /api/admin/update/user-relations [put]
+func (h *AdminUserHandler) UpdateUserRelation(c *gin.Context) {
+	relationType, ok := parseRelationTypeQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminUserRelationUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+	req.IfMatch = c.GetHeader("If-Match")
+
+	data, err := h.adminUserService.UpdateUserRelation(c.Request.Context(), relationType, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoUpdateFields), errors.Is(err, service.ErrSkillGradeOnlyForSkills):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "关联记录不存在"})
+		case errors.Is(err, service.ErrPreconditionFailed):
+			c.JSON(http.StatusPreconditionFailed, dto.Response{Code: http.StatusPreconditionFailed, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "更新失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.Header("ETag", data.ETag)
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员更新用户关联", "admin_id", adminID, "user_id", req.UserID, "type", relationType, "resource_id", req.ResourceID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "更新成功", Data: data})
+}
+
+// DeleteUserRelation godoc
+// @Summary      管理员删除任意用户的资源关联
+// @Description  通过query参数type和body中的user_id/resource_id删除关联记录
+// @Tags         admin-user
+// @Accept       json
+// @Produce      json
+// @Param        type  query     string                              true  "关联类型(achievements/skills/items/cards)"
+// @Param        body  body      dto.AdminUserRelationDeleteRequest  true  "删除关联请求体"
+// @Success      200   {object}  dto.Response                        "删除成功"
+// @Failure      400   {object}  dto.Response                        "请求参数错误"
+// @Failure      401   {object}  dto.Response                        "登录状态异常"
+// @Failure      404   {object}  dto.Response                        "用户或关联记录不存在"
+// @Failure      500   {object}  dto.Response                        "删除失败"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/user-relations [delete]
+func (h *AdminUserHandler) DeleteUserRelation(c *gin.Context) {
+	relationType, ok := parseRelationTypeQuery(c)
+	if !ok {
+		return
+	}
+	var req dto.AdminUserRelationDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	if err := h.adminUserService.DeleteUserRelation(c.Request.Context(), req.UserID, relationType, req.ResourceID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "关联记录不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "删除失败：" + err.Error()})
+		}
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员删除用户关联", "admin_id", adminID, "user_id", req.UserID, "type", relationType, "resource_id", req.ResourceID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "删除成功"})
+}
+
+// AdjustCoin godoc
+// @Summary      管理员调整用户金币
+// @Description  按增减量调整strength/select金币，调整后不能为负数；reason必填，每次调整都会写一条流水
+// @Tags         admin-user
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminAdjustCoinRequest  true  "调整金币请求体"
+// @Success      200   {object}  dto.Response{data=dto.AdminCoinAdjustData}  "调整成功"
+// @Failure      400   {object}  dto.Response                "请求参数错误"
+// @Failure      401   {object}  dto.Response                "登录状态异常"
+// @Failure      404   {object}  dto.Response                "用户不存在"
+// @Failure      409   {object}  dto.Response                "余额不足"
+// @Failure      500   {object}  dto.Response                "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/update/coin [put]
+func (h *AdminUserHandler) AdjustCoin(c *gin.Context) {
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	var req dto.AdminAdjustCoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.adminUserService.AdjustCoin(c.Request.Context(), adminID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedCoinType):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		case errors.Is(err, service.ErrCoinAdjustUnderflow):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "调整金币失败：" + err.Error()})
+		}
+		return
+	}
+
+	h.logger.InfoContext(c.Request.Context(), "管理员调整用户金币", "admin_id", adminID, "user_id", req.UserID, "coin_type", req.CoinType, "delta", req.Delta, "reason", req.Reason)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "调整成功", Data: data})
+}
+
+// ResetUserProfile godoc
+// @Summary      管理员重置用户名/头像
+// @Description  用于处理违规昵称或头像；重置后会清掉对应的修改时间，用户可以立即重新修改
+// @Tags         admin-user
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminResetUserProfileRequest  true  "重置请求体"
+// @Success      200   {object}  dto.Response{data=dto.CommonUserData}  "重置成功"
+// @Failure      400   {object}  dto.Response                      "请求参数错误"
+// @Failure      401   {object}  dto.Response                      "登录状态异常"
+// @Failure      404   {object}  dto.Response                      "用户不存在"
+// @Failure      409   {object}  dto.Response                      "用户名已被占用"
+// @Failure      500   {object}  dto.Response                      "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/update/user-profile [put]
+func (h *AdminUserHandler) ResetUserProfile(c *gin.Context) {
+	var req dto.AdminResetUserProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.adminUserService.ResetUserProfile(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNothingToReset):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		case errors.Is(err, service.ErrUsernameTaken):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "重置失败：" + err.Error()})
+		}
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员重置用户资料", "admin_id", adminID, "user_id", req.UserID, "reset_username", req.ResetUsername, "reset_head_image", req.ResetHeadImage)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "重置成功", Data: data})
+}
+
+// parseRelationTypeQuery 解析必填的type参数，失败时已经写好了响应
+func parseRelationTypeQuery(c *gin.Context) (service.UserRelationType, bool) {
+	typeStr := c.Query("type")
+	if typeStr == "" {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "缺少type参数"})
+		return "", false
+	}
+	relationType, err := service.ParseUserRelationType(typeStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		return "", false
+	}
+	return relationType, true
+}
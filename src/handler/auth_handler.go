@@ -27,8 +27,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	authData, err := h.authService.Register(req)
+	authData, err := h.authService.Register(c.Request.Context(), req)
 	if err != nil {
+		if errors.Is(err, service.ErrUsernameBanned) {
+			c.JSON(http.StatusBadRequest, dto.Response{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			})
+			return
+		}
 		if errors.Is(err, service.ErrUserAlreadyExists) {
 			c.JSON(http.StatusConflict, dto.Response{
 				Code:    http.StatusConflict,
@@ -60,7 +67,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	authData, err := h.authService.Login(req)
+	authData, err := h.authService.Login(c.Request.Context(), req)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) {
 			c.JSON(http.StatusForbidden, dto.Response{
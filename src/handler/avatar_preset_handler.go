@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AvatarPresetHandler struct {
+	avatarPresetService *service.AvatarPresetService
+	logger              *slog.Logger
+	imageOptions        utils.ImageProcessOptions
+}
+
+// NewAvatarPresetHandler 预设图片和用户头像用同样的imageOptions处理
+func NewAvatarPresetHandler(avatarPresetService *service.AvatarPresetService, logger *slog.Logger, imageOptions utils.ImageProcessOptions) *AvatarPresetHandler {
+	return &AvatarPresetHandler{avatarPresetService: avatarPresetService, logger: logger, imageOptions: imageOptions}
+}
+
+// ListPresets godoc
+// @Summary      获取预设头像列表
+// @Description  列出所有启用的预设头像，unlocked表示当前用户是否已满足解锁条件
+// @Tags         profile
+// @Produce      json
+// @Success      200  {object}  dto.Response{data=[]dto.AvatarPresetData}  "获取成功"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      500  {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/profile/avatars [get]
+func (h *AvatarPresetHandler) ListPresets(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	list, err := h.avatarPresetService.ListForUser(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "获取预设头像失败：" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "获取成功", Data: list})
+}
+
+// SelectPreset godoc
+// @Summary      选择预设头像
+// @Description  把头像换成已解锁的预设头像，不上传文件，也不受头像修改间隔限制
+// @Tags         profile
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.SelectAvatarPresetRequest  true  "选择预设头像请求体"
+// @Success      200   {object}  dto.Response{data=dto.HeadImageData}  "修改成功"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      403   {object}  dto.Response  "尚未解锁该预设头像"
+// @Failure      404   {object}  dto.Response  "用户或预设头像不存在"
+// @Failure      409   {object}  dto.Response  "数据已被其他请求修改"
+// @Failure      500   {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/profile/update/headimage/preset [put]
+func (h *AvatarPresetHandler) SelectPreset(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	var req dto.SelectAvatarPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	headImage, err := h.avatarPresetService.Select(c.Request.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		case errors.Is(err, service.ErrAvatarPresetNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+		case errors.Is(err, service.ErrAvatarPresetLocked):
+			c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "修改头像失败：" + err.Error()})
+		}
+		return
+	}
+
+	h.logger.InfoContext(c.Request.Context(), "用户选择预设头像", "user_id", userID, "preset_id", req.PresetID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "修改成功", Data: headImage})
+}
+
+// ListPresetsForAdmin godoc
+// @Summary      管理员获取预设头像列表
+// @Description  包括未启用的预设头像
+// @Tags         admin-avatar-preset
+// @Produce      json
+// @Success      200  {object}  dto.Response{data=[]dto.AvatarPresetData}  "获取成功"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      500  {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/admin/get/avatar-presets [get]
+func (h *AvatarPresetHandler) ListPresetsForAdmin(c *gin.Context) {
+	list, err := h.avatarPresetService.ListForAdmin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "获取预设头像失败：" + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "获取成功", Data: list})
+}
+
+// CreatePreset godoc
+// @Summary      管理员新增预设头像
+// @Description  上传图片新增预设头像，图片按头像的规则校验和生成缩略图；unlock_type为空时所有用户都可选
+// @Tags         admin-avatar-preset
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        image               formData  file    true   "预设头像图片(jpeg/png/gif/webp)"
+// @Param        name                formData  string  true   "名称"
+// @Param        unlock_type         formData  string  false  "解锁条件类型(achievements/cards)"
+// @Param        unlock_resource_id  formData  int     false  "解锁条件对应的成就/卡牌id"
+// @Param        sort_order          formData  int     false  "排序，越小越靠前"
+// @Success      200  {object}  dto.Response{data=dto.AvatarPresetData}  "创建成功"
+// @Failure      400  {object}  dto.Response  "请求参数错误、解锁条件无效或图片无法解析"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      409  {object}  dto.Response  "名称已存在"
+// @Failure      413  {object}  dto.Response  "图片过大"
+// @Failure      415  {object}  dto.Response  "图片格式不支持"
+// @Failure      500  {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/avatar-presets [post]
+func (h *AvatarPresetHandler) CreatePreset(c *gin.Context) {
+	var req dto.AdminAvatarPresetCreateRequest
+	if !bindImageForm(c, &req, h.imageOptions.MaxBytes) {
+		return
+	}
+	processed, ok := processUploadedImage(c, req.Image, h.imageOptions)
+	if !ok {
+		return
+	}
+
+	data, err := h.avatarPresetService.Create(c.Request.Context(), req, processed)
+	if err != nil {
+		writeAvatarPresetError(c, err, "创建失败：")
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员新增预设头像", "admin_id", adminID, "preset_id", data.PresetID, "name", data.Name)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "创建成功", Data: data})
+}
+
+// UpdatePreset godoc
+// @Summary      管理员修改预设头像
+// @Description  只修改传了的字段，unlock_type传空字符串表示取消解锁条件；图片不能修改
+// @Tags         admin-avatar-preset
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminAvatarPresetUpdateRequest  true  "修改预设头像请求体"
+// @Success      200   {object}  dto.Response{data=dto.AvatarPresetData}  "更新成功"
+// @Failure      400   {object}  dto.Response  "请求参数错误或解锁条件无效"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "预设头像不存在"
+// @Failure      409   {object}  dto.Response  "名称已存在"
+// @Failure      500   {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/admin/update/avatar-presets [put]
+func (h *AvatarPresetHandler) UpdatePreset(c *gin.Context) {
+	var req dto.AdminAvatarPresetUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.avatarPresetService.Update(c.Request.Context(), req)
+	if err != nil {
+		writeAvatarPresetError(c, err, "更新失败：")
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员修改预设头像", "admin_id", adminID, "preset_id", req.PresetID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "更新成功", Data: data})
+}
+
+// DeletePreset godoc
+// @Summary      管理员删除预设头像
+// @Description  已经在用这个预设的用户头像不受影响
+// @Tags         admin-avatar-preset
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminAvatarPresetDeleteRequest  true  "删除预设头像请求体"
+// @Success      200   {object}  dto.Response  "删除成功"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "预设头像不存在"
+// @Failure      500   {object}  dto.Response  "服务器错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/avatar-presets [delete]
+func (h *AvatarPresetHandler) DeletePreset(c *gin.Context) {
+	var req dto.AdminAvatarPresetDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	if err := h.avatarPresetService.Delete(c.Request.Context(), req.PresetID); err != nil {
+		writeAvatarPresetError(c, err, "删除失败：")
+		return
+	}
+
+	adminID, _ := getUserIDFromContext(c)
+	h.logger.InfoContext(c.Request.Context(), "管理员删除预设头像", "admin_id", adminID, "preset_id", req.PresetID)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "删除成功"})
+}
+
+// writeAvatarPresetError 管理端增删改共用的错误映射
+func writeAvatarPresetError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrNoUpdateFields),
+		errors.Is(err, service.ErrUnsupportedUnlockType),
+		errors.Is(err, service.ErrUnlockResourceRequired),
+		errors.Is(err, service.ErrUnlockResourceNotFound):
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+	case errors.Is(err, service.ErrAvatarPresetNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: service.ErrAvatarPresetNotFound.Error()})
+	case errors.Is(err, service.ErrAvatarPresetNameExists):
+		c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: fallback + err.Error()})
+	}
+}
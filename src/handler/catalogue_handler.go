@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogueHandler struct {
+	catalogueService *service.CatalogueService
+}
+
+func NewCatalogueHandler(catalogueService *service.CatalogueService) *CatalogueHandler {
+	return &CatalogueHandler{catalogueService: catalogueService}
+}
+
+// GetCatalogue godoc
+// @Summary      玩家查询资源图鉴
+// @Description  返回某类资源的全部定义，并合并当前用户的拥有/完成状态，owned=false的条目客户端显示为未解锁
+// @Description  响应带弱ETag，客户端带If-None-Match请求且内容没变时返回304(无响应体)
+// @Tags         catalogue
+// @Produce      json
+// @Param        type           path      string  true   "资源类型(achievements/skills/items/cards)"
+// @Param        If-None-Match  header    string  false  "上次响应的ETag"
+// @Success      200            {object}  dto.Response{data=dto.CatalogueData}  "查询成功"
+// @Success      304            "内容未变化"
+// @Failure      400            {object}  dto.Response  "请求参数错误"
+// @Failure      401            {object}  dto.Response  "登录状态异常"
+// @Failure      500            {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/catalogue/{type} [get]
+func (h *CatalogueHandler) GetCatalogue(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	data, err := h.catalogueService.GetCatalogue(c.Request.Context(), userID, c.Param("type"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedRelationType):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: data})
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 内容文件上限，正常的策划配置远小于这个值
+const maxContentFileBytes = 5 << 20
+
+type ContentHandler struct {
+	contentService *service.ContentService
+}
+
+func NewContentHandler(contentService *service.ContentService) *ContentHandler {
+	return &ContentHandler{contentService: contentService}
+}
+
+// ExportContent godoc
+// @Summary      管理员导出游戏内容文件
+// @Description  导出全部achievements/skills/items/cards，格式与导入接口一致，可直接提交到git
+// @Description  返回的是文件本身而不是通用响应结构体
+// @Tags         admin-content
+// @Produce      json
+// @Produce      application/x-yaml
+// @Param        format  query     string           false  "文件格式(json/yaml)，默认json"
+// @Success      200     {object}  dto.ContentFile  "内容文件"
+// @Failure      400     {object}  dto.Response     "请求参数错误"
+// @Failure      401     {object}  dto.Response     "登录状态异常"
+// @Failure      500     {object}  dto.Response     "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/get/content [get]
+func (h *ContentHandler) ExportContent(c *gin.Context) {
+	format, err := service.ParseContentFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	content, err := h.contentService.Export(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+		return
+	}
+	raw, err := service.EncodeContentFile(content, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "导出失败：" + err.Error()})
+		return
+	}
+
+	contentType := "application/json; charset=utf-8"
+	if format == service.ContentFormatYAML {
+		contentType = "application/x-yaml; charset=utf-8"
+	}
+	fileName := fmt.Sprintf("content_%s.%s", time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Data(http.StatusOK, contentType, raw)
+}
+
+// ImportContent godoc
+// @Summary      管理员导入游戏内容文件
+// @Description  按名称upsert四类资源，整个文件在一个事务里执行，任意一条失败全部回滚
+// @Description  技能的前置技能用prerequisite(名称)表示，可以引用同文件或库里已有的技能
+// @Description  dry_run=true时只返回差异不写库；body为YAML时需format=yaml或Content-Type带yaml
+// @Tags         admin-content
+// @Accept       json
+// @Accept       application/x-yaml
+// @Produce      json
+// @Param        format   query     string           false  "文件格式(json/yaml)，默认按Content-Type判断"
+// @Param        dry_run  query     bool             false  "只计算差异，不写入"
+// @Param        request  body      dto.ContentFile  true   "内容文件"
+// @Success      200      {object}  dto.Response{data=dto.ContentImportResult}  "导入成功"
+// @Failure      400      {object}  dto.Response     "请求参数错误"
+// @Failure      401      {object}  dto.Response     "登录状态异常"
+// @Failure      413      {object}  dto.Response     "文件过大"
+// @Failure      422      {object}  dto.Response     "内容校验失败"
+// @Failure      500      {object}  dto.Response     "数据库错误"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/content [post]
+func (h *ContentHandler) ImportContent(c *gin.Context) {
+	formatStr := c.Query("format")
+	if formatStr == "" && strings.Contains(c.ContentType(), "yaml") {
+		formatStr = service.ContentFormatYAML
+	}
+	format, err := service.ParseContentFormat(formatStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := c.Query("dry_run"); dryRunStr != "" {
+		if dryRun, err = strconv.ParseBool(dryRunStr); err != nil {
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "dry_run参数格式错误"})
+			return
+		}
+	}
+
+	raw, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxContentFileBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, dto.Response{Code: http.StatusRequestEntityTooLarge, Message: "内容文件过大"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "读取请求体失败：" + err.Error()})
+		return
+	}
+
+	content, err := service.DecodeContentFile(raw, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		return
+	}
+
+	result, err := h.contentService.Import(c.Request.Context(), content, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrContentNameRequired), errors.Is(err, service.ErrContentNameDuplicated),
+			errors.Is(err, service.ErrContentNameTooLong), errors.Is(err, service.ErrContentPrerequisiteCycle),
+			errors.Is(err, service.ErrContentPrerequisiteAbsent):
+			c.JSON(http.StatusUnprocessableEntity, dto.Response{Code: http.StatusUnprocessableEntity, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "导入失败：" + err.Error()})
+		}
+		return
+	}
+
+	message := "导入成功"
+	if dryRun {
+		message = "预览成功，未写入数据库"
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: message, Data: result})
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/realtime"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventHandler struct {
+	hub *realtime.Hub
+}
+
+func NewEventHandler(hub *realtime.Hub) *EventHandler {
+	return &EventHandler{hub: hub}
+}
+
+// Stream godoc
+// @Summary      玩家订阅实时事件
+// @Description  Server-Sent Events长连接，event为事件类型，data为dto.PushEvent的JSON：
+// @Description  coins_changed(金币变化)、relation_created/relation_updated/relation_deleted(关联变化)、banned(账号被删除)、forced_logout(token被吊销)
+// @Description  收到banned/forced_logout后服务端会关闭连接；服务端每隔一段时间发送注释行作为心跳
+// @Description  事件只是提醒，断线期间的事件不会补发，客户端重连后应调用/api/profile/changes增量同步
+// @Tags         events
+// @Produce      text/event-stream
+// @Success      200  {object}  dto.PushEvent  "事件流"
+// @Failure      401  {object}  dto.Response   "登录状态异常"
+// @Security     BearerAuth
+// @Router       /api/events [get]
+func (h *EventHandler) Stream(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	sub := h.hub.Subscribe(userID)
+	defer sub.Close()
+
+	//长连接不受服务器的读写超时限制，读超时到了会取消请求的context
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	//让nginx之类的反向代理不要缓冲
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	//先发一行注释，客户端立刻就能确认连接建立
+	_, _ = io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(config.EventStreamHeartbeat)
+	defer heartbeat.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, open := <-sub.Events():
+			if !open {
+				//积压太多被hub踢掉或服务器正在关闭，客户端重连即可
+				return
+			}
+			if err := writeServerSentEvent(c.Writer, event); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			if event.Type == service.EventBanned || event.Type == service.EventForcedLogout {
+				return
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(w io.Writer, event dto.PushEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+	return err
+}
@@ -0,0 +1,468 @@
+package handler
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/middleware"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FriendHandler struct {
+	friendService *service.FriendService
+	pagination    config.PaginationSettings
+}
+
+func NewFriendHandler(friendService *service.FriendService, pagination config.PaginationSettings) *FriendHandler {
+	return &FriendHandler{friendService: friendService, pagination: pagination}
+}
+
+// ListFriends godoc
+// @Summary      玩家查询好友列表
+// @Description  按好友的用户ID排序，只返回公开信息(不含金币)，支持分页
+// @Tags         profile-friend
+// @Produce      json
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.FriendPageData}  "查询成功"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends [get]
+func (h *FriendHandler) ListFriends(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.friendService.ListFriends(c.Request.Context(), userID, pagination)
+	if err != nil {
+		writeFriendQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    http.StatusOK,
+		Message: "查询成功",
+		Data: dto.FriendPageData{
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
+		},
+	})
+}
+
+// RemoveFriend godoc
+// @Summary      玩家删除好友
+// @Description  双方的好友关系同时解除
+// @Tags         profile-friend
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.FriendTargetRequest  true  "好友的用户ID"
+// @Success      200   {object}  dto.Response  "删除成功"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "对方不是好友"
+// @Failure      500   {object}  dto.Response  "删除失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends [delete]
+func (h *FriendHandler) RemoveFriend(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	var req dto.FriendTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	if err := h.friendService.RemoveFriend(c.Request.Context(), userID, req.UserID); err != nil {
+		writeFriendCommandError(c, err, "删除好友失败：")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "删除成功"})
+}
+
+// SendRequest godoc
+// @Summary      玩家发送好友申请
+// @Description  对方已经向自己发过待处理的申请时直接成为好友，返回的申请status为accepted
+// @Description  被对方拒绝或自己取消过的申请可以重新发送；双方任意一方拉黑了对方时不能发送
+// @Tags         profile-friend
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.FriendRequestCreateRequest  true  "好友申请"
+// @Success      200   {object}  dto.Response{data=dto.FriendRequestData}  "已成为好友"
+// @Success      201   {object}  dto.Response{data=dto.FriendRequestData}  "申请已发送"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      403   {object}  dto.Response  "被拉黑或已拉黑对方"
+// @Failure      404   {object}  dto.Response  "用户不存在"
+// @Failure      409   {object}  dto.Response  "已是好友、申请待处理或好友数已达上限"
+// @Failure      500   {object}  dto.Response  "发送失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends/requests [post]
+func (h *FriendHandler) SendRequest(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	var req dto.FriendRequestCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, accepted, err := h.friendService.SendRequest(c.Request.Context(), userID, req)
+	if err != nil {
+		writeFriendCommandError(c, err, "发送好友申请失败：")
+		return
+	}
+
+	if accepted {
+		c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "对方已向你发送过申请，已成为好友", Data: data})
+		return
+	}
+	c.JSON(http.StatusCreated, dto.Response{Code: http.StatusCreated, Message: "好友申请已发送", Data: data})
+}
+
+// ListPendingRequests godoc
+// @Summary      玩家查询待处理的好友申请
+// @Description  direction=incoming(默认)为收到的申请，outgoing为自己发出的申请，新的在前，支持分页
+// @Tags         profile-friend
+// @Produce      json
+// @Param        direction   query     string  false  "incoming/outgoing，默认incoming"
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.FriendRequestPageData}  "查询成功"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends/requests [get]
+func (h *FriendHandler) ListPendingRequests(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.friendService.ListPendingRequests(c.Request.Context(), userID, c.Query("direction"), pagination)
+	if err != nil {
+		writeFriendQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    http.StatusOK,
+		Message: "查询成功",
+		Data: dto.FriendRequestPageData{
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
+		},
+	})
+}
+
+// AcceptRequest godoc
+// @Summary      玩家同意好友申请
+// @Description  只能处理发给自己的待处理申请，任意一方好友数已达上限时返回409
+// @Tags         profile-friend
+// @Produce      json
+// @Param        id   path      int  true  "申请ID"
+// @Success      200  {object}  dto.Response{data=dto.FriendRequestData}  "已同意"
+// @Failure      400  {object}  dto.Response  "请求参数错误"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      404  {object}  dto.Response  "申请不存在或已处理"
+// @Failure      409  {object}  dto.Response  "好友数已达上限"
+// @Failure      500  {object}  dto.Response  "处理失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends/requests/{id}/accept [post]
+func (h *FriendHandler) AcceptRequest(c *gin.Context) {
+	h.respondRequest(c, true)
+}
+
+// DeclineRequest godoc
+// @Summary      玩家拒绝好友申请
+// @Description  只能处理发给自己的待处理申请，拒绝后对方可以重新申请
+// @Tags         profile-friend
+// @Produce      json
+// @Param        id   path      int  true  "申请ID"
+// @Success      200  {object}  dto.Response{data=dto.FriendRequestData}  "已拒绝"
+// @Failure      400  {object}  dto.Response  "请求参数错误"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      404  {object}  dto.Response  "申请不存在或已处理"
+// @Failure      500  {object}  dto.Response  "处理失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends/requests/{id}/decline [post]
+func (h *FriendHandler) DeclineRequest(c *gin.Context) {
+	h.respondRequest(c, false)
+}
+
+func (h *FriendHandler) respondRequest(c *gin.Context, accept bool) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	requestID, ok := parseFriendRequestID(c)
+	if !ok {
+		return
+	}
+
+	data, err := h.friendService.RespondRequest(c.Request.Context(), userID, requestID, accept)
+	if err != nil {
+		writeFriendCommandError(c, err, "处理好友申请失败：")
+		return
+	}
+
+	message := "已拒绝好友申请"
+	if accept {
+		message = "已同意好友申请"
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: message, Data: data})
+}
+
+// CancelRequest godoc
+// @Summary      玩家取消自己发出的好友申请
+// @Tags         profile-friend
+// @Produce      json
+// @Param        id   path      int  true  "申请ID"
+// @Success      200  {object}  dto.Response  "已取消"
+// @Failure      400  {object}  dto.Response  "请求参数错误"
+// @Failure      401  {object}  dto.Response  "登录状态异常"
+// @Failure      404  {object}  dto.Response  "申请不存在或已处理"
+// @Failure      500  {object}  dto.Response  "取消失败"
+// @Security     BearerAuth
+// @Router       /api/profile/friends/requests/{id} [delete]
+func (h *FriendHandler) CancelRequest(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	requestID, ok := parseFriendRequestID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.CancelRequest(c.Request.Context(), userID, requestID); err != nil {
+		writeFriendCommandError(c, err, "取消好友申请失败：")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "已取消好友申请"})
+}
+
+// ListBlocked godoc
+// @Summary      玩家查询黑名单
+// @Tags         profile-friend
+// @Produce      json
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.BlockedUserPageData}  "查询成功"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/blocks [get]
+func (h *FriendHandler) ListBlocked(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.friendService.ListBlocked(c.Request.Context(), userID, pagination)
+	if err != nil {
+		writeFriendQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    http.StatusOK,
+		Message: "查询成功",
+		Data: dto.BlockedUserPageData{
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
+		},
+	})
+}
+
+// Block godoc
+// @Summary      玩家拉黑用户
+// @Description  同时解除好友关系并取消双方之间待处理的好友申请；被拉黑的人不能再发送好友申请，也搜索不到自己
+// @Tags         profile-friend
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.FriendTargetRequest  true  "要拉黑的用户ID"
+// @Success      200   {object}  dto.Response  "拉黑成功"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "用户不存在"
+// @Failure      500   {object}  dto.Response  "拉黑失败"
+// @Security     BearerAuth
+// @Router       /api/profile/blocks [post]
+func (h *FriendHandler) Block(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	var req dto.FriendTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	if err := h.friendService.Block(c.Request.Context(), userID, req.UserID); err != nil {
+		writeFriendCommandError(c, err, "拉黑失败：")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "拉黑成功"})
+}
+
+// Unblock godoc
+// @Summary      玩家取消拉黑
+// @Description  取消拉黑后不会恢复原来的好友关系
+// @Tags         profile-friend
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.FriendTargetRequest  true  "要取消拉黑的用户ID"
+// @Success      200   {object}  dto.Response  "已取消拉黑"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "没有拉黑该用户"
+// @Failure      500   {object}  dto.Response  "取消拉黑失败"
+// @Security     BearerAuth
+// @Router       /api/profile/blocks [delete]
+func (h *FriendHandler) Unblock(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	var req dto.FriendTargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	if err := h.friendService.Unblock(c.Request.Context(), userID, req.UserID); err != nil {
+		writeFriendCommandError(c, err, "取消拉黑失败：")
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "已取消拉黑"})
+}
+
+// SearchUsers godoc
+// @Summary      玩家按用户名搜索用户
+// @Description  用户名模糊匹配(最多30字，%和_按普通字符处理)，结果不含自己和拉黑了自己的人，只返回公开信息，支持分页
+// @Tags         profile-friend
+// @Produce      json
+// @Param        keyword     query     string  true   "用户名关键字"
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.PublicUserPageData}  "查询成功"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/users/search [get]
+func (h *FriendHandler) SearchUsers(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.friendService.SearchUsers(c.Request.Context(), userID, c.Query("keyword"), pagination)
+	if err != nil {
+		writeFriendQueryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    http.StatusOK,
+		Message: "查询成功",
+		Data: dto.PublicUserPageData{
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
+		},
+	})
+}
+
+func parseFriendRequestID(c *gin.Context) (uint, bool) {
+	requestID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || requestID == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "申请ID格式错误"})
+		return 0, false
+	}
+	return uint(requestID), true
+}
+
+func writeFriendQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSearchKeyword), errors.Is(err, service.ErrInvalidFriendDirection),
+		errors.Is(err, utils.ErrCursorMismatch):
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+	}
+}
+
+func writeFriendCommandError(c *gin.Context, err error, failedPrefix string) {
+	switch {
+	case errors.Is(err, service.ErrFriendSelf):
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+	case errors.Is(err, service.ErrFriendTargetBlocked), errors.Is(err, service.ErrFriendRequestRejected):
+		c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
+	case errors.Is(err, service.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+	case errors.Is(err, service.ErrFriendRequestNotFound), errors.Is(err, service.ErrNotFriends), errors.Is(err, service.ErrNotBlocked):
+		c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+	case errors.Is(err, service.ErrAlreadyFriends), errors.Is(err, service.ErrFriendRequestPending),
+		errors.Is(err, service.ErrFriendLimitReached), errors.Is(err, service.ErrFriendTargetLimitReached):
+		c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: failedPrefix + err.Error()})
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Livez godoc
+// @Summary      存活检查
+// @Description  进程能处理请求就返回200，不检查数据库等依赖，依赖故障时不应该因此重启进程
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  dto.Response  "存活"
+// @Router       /livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "alive"})
+}
+
+// Readyz godoc
+// @Summary      就绪检查
+// @Description  检查数据库连通(带超时)、迁移是否为最新版本、上传存储是否可写(结果缓存10秒)，任意一项失败返回503
+// @Description  反向代理用它判断是否把流量转发到这个实例
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  dto.Response{data=dto.ReadinessData}  "就绪"
+// @Failure      503  {object}  dto.Response{data=dto.ReadinessData}  "依赖异常"
+// @Router       /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	data := h.healthService.Readiness(c.Request.Context())
+	if data.Status != service.HealthStatusOK {
+		c.JSON(http.StatusServiceUnavailable, dto.Response{Code: http.StatusServiceUnavailable, Message: "依赖异常", Data: data})
+		return
+	}
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "就绪", Data: data})
+}
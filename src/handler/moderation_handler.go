@@ -0,0 +1,165 @@
+package handler
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/middleware"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	moderationService *service.ModerationService
+	logger            *slog.Logger
+	pagination        config.PaginationSettings
+}
+
+func NewModerationHandler(moderationService *service.ModerationService, logger *slog.Logger, pagination config.PaginationSettings) *ModerationHandler {
+	return &ModerationHandler{moderationService: moderationService, logger: logger, pagination: pagination}
+}
+
+// ListModeration godoc
+// @Summary      管理员查看审核队列
+// @Description  开启审核(MODERATION_ENABLED)时用户改用户名、上传头像会生成审核记录，修改先生效
+// @Description  同一用户同一字段最多一条待审核记录，previous_value是最后一次通过审核的值
+// @Tags         admin-moderation
+// @Produce      json
+// @Param        status      query     string  false  "状态(pending/approved/rejected)，默认pending"
+// @Param        field       query     string  false  "字段(username/head_image)，不传表示全部"
+// @Param        user_id     query     int     false  "只看某个用户"
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.ModerationPageData}  "查询成功"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/admin/get/moderation [get]
+func (h *ModerationHandler) ListModeration(c *gin.Context) {
+	var userID uint64
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		var err error
+		if userID, err = strconv.ParseUint(userIDStr, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "user_id参数错误"})
+			return
+		}
+	}
+
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.moderationService.List(c.Request.Context(), c.Query("status"), c.Query("field"), uint(userID), pagination)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidModerationStatus), errors.Is(err, service.ErrInvalidModerationField), errors.Is(err, utils.ErrCursorMismatch):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "查询失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{
+		Code:    http.StatusOK,
+		Message: "查询成功",
+		Data: dto.ModerationPageData{
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
+		},
+	})
+}
+
+// ApproveModeration godoc
+// @Summary      管理员通过审核
+// @Description  通过后不能再驳回，头像记录保留的旧头像引用随之释放
+// @Tags         admin-moderation
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminModerationApproveRequest  true  "通过审核请求体"
+// @Success      200   {object}  dto.Response{data=dto.ModerationRecordData}  "已通过"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "审核记录不存在或已处理"
+// @Failure      500   {object}  dto.Response  "处理失败"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/moderation/approve [post]
+func (h *ModerationHandler) ApproveModeration(c *gin.Context) {
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	var req dto.AdminModerationApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.moderationService.Approve(c.Request.Context(), adminID, req.ModerationID)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	h.logger.InfoContext(c.Request.Context(), "管理员通过审核", "admin_id", adminID, "moderation_id", data.ModerationID, "user_id", data.UserID, "field", data.Field)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "已通过", Data: data})
+}
+
+// RejectModeration godoc
+// @Summary      管理员驳回审核
+// @Description  用户当前的值还是待审核的值时恢复为previous_value，原用户名已被别人占用时改为player_<id>
+// @Description  用户在审核前又改成了别的值(如选用预设头像)时只标记驳回，不改动当前的值
+// @Description  驳回后向用户推送moderation_rejected事件
+// @Tags         admin-moderation
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.AdminModerationRejectRequest  true  "驳回审核请求体"
+// @Success      200   {object}  dto.Response{data=dto.ModerationRejectData}  "已驳回"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "审核记录不存在或已处理"
+// @Failure      409   {object}  dto.Response  "原用户名和默认用户名都已被占用"
+// @Failure      500   {object}  dto.Response  "处理失败"
+// @Security     BearerAuth
+// @Router       /api/admin/operation/moderation/reject [post]
+func (h *ModerationHandler) RejectModeration(c *gin.Context) {
+	adminID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+	var req dto.AdminModerationRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.moderationService.Reject(c.Request.Context(), adminID, req)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	h.logger.InfoContext(c.Request.Context(), "管理员驳回审核", "admin_id", adminID, "moderation_id", data.Record.ModerationID, "user_id", data.Record.UserID, "field", data.Record.Field, "reverted", data.Reverted)
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "已驳回", Data: data})
+}
+
+func writeModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrModerationRecordNotFound):
+		c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+	case errors.Is(err, service.ErrUsernameTaken):
+		c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "处理失败：" + err.Error()})
+	}
+}
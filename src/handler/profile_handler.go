@@ -8,8 +8,12 @@ import (
 	"MuXi/2026-MuxiShooter-Backend/utils"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,10 +21,14 @@ import (
 
 type ProfileHandler struct {
 	profileService *service.ProfileService
+	logger         *slog.Logger
+	imageOptions   utils.ImageProcessOptions
+	pagination     config.PaginationSettings
 }
 
-func NewProfileHandler(profileService *service.ProfileService) *ProfileHandler {
-	return &ProfileHandler{profileService: profileService}
+// NewProfileHandler imageOptions为上传头像的限制和重新编码参数
+func NewProfileHandler(profileService *service.ProfileService, logger *slog.Logger, imageOptions utils.ImageProcessOptions, pagination config.PaginationSettings) *ProfileHandler {
+	return &ProfileHandler{profileService: profileService, logger: logger, imageOptions: imageOptions, pagination: pagination}
 }
 
 // Logout godoc
@@ -43,7 +51,7 @@ func (h *ProfileHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	err := h.profileService.Logout(userID)
+	err := h.profileService.Logout(c.Request.Context(), userID)
 	if err != nil {
 		if errors.Is(err, service.ErrUserNotFound) || errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusUnauthorized, dto.Response{
@@ -77,6 +85,7 @@ func (h *ProfileHandler) Logout(c *gin.Context) {
 // @Failure      401   {object}  dto.Response               "登录状态异常"
 // @Failure      403   {object}  dto.Response               "旧密码错误或修改过于频繁"
 // @Failure      404   {object}  dto.Response               "用户不存在"
+// @Failure      409   {object}  dto.Response               "数据已被其他请求修改"
 // @Failure      500   {object}  dto.Response               "服务器错误"
 // @Security     BearerAuth
 // @Router       /api/profile/update/password [put]
@@ -99,7 +108,7 @@ func (h *ProfileHandler) UpdatePassword(c *gin.Context) {
 		return
 	}
 
-	err := h.profileService.UpdatePassword(userID, req)
+	err := h.profileService.UpdatePassword(c.Request.Context(), userID, req)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrSamePassword):
@@ -110,6 +119,8 @@ func (h *ProfileHandler) UpdatePassword(c *gin.Context) {
 			c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
 		case errors.Is(err, service.ErrInvalidOldPassword):
 			c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "服务器错误:" + err.Error()})
 		}
@@ -124,16 +135,18 @@ func (h *ProfileHandler) UpdatePassword(c *gin.Context) {
 
 // UpdateUsername godoc
 // @Summary      用户修改用户名
-// @Description  修改当前用户用户名
+// @Description  修改当前用户用户名，用户名不能包含敏感词
+// @Description  开启审核时修改先生效并进入审核队列，管理员驳回后恢复原用户名
 // @Tags         profile
 // @Accept       json
 // @Produce      json
 // @Param        body  body      dto.UpdateUsernameRequest  true  "修改用户名请求体"
 // @Success      200   {object}  dto.Response               "修改成功"
-// @Failure      400   {object}  dto.Response               "请求参数错误"
+// @Failure      400   {object}  dto.Response               "请求参数错误或用户名包含敏感词"
 // @Failure      401   {object}  dto.Response               "登录状态异常"
 // @Failure      403   {object}  dto.Response               "修改过于频繁"
 // @Failure      404   {object}  dto.Response               "用户不存在"
+// @Failure      409   {object}  dto.Response               "数据已被其他请求修改"
 // @Failure      500   {object}  dto.Response               "服务器错误"
 // @Security     BearerAuth
 // @Router       /api/profile/update/username [put]
@@ -156,47 +169,57 @@ func (h *ProfileHandler) UpdateUsername(c *gin.Context) {
 		return
 	}
 
-	err := h.profileService.UpdateUsername(userID, req)
+	pending, err := h.profileService.UpdateUsername(c.Request.Context(), userID, req)
 	if err != nil {
 		switch {
+		case errors.Is(err, service.ErrUsernameBanned):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
 		case errors.Is(err, service.ErrUsernameTooFrequent):
 			c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "服务器错误:" + err.Error()})
 		}
 		return
 	}
 
+	message := "修改用户名成功"
+	if pending {
+		message = "修改用户名成功，等待审核，审核不通过时会恢复原用户名"
+	}
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    http.StatusOK,
-		Message: "修改用户名成功",
+		Message: message,
 	})
 }
 
 // UpdateHeadImage godoc
 // @Summary      用户修改头像
-// @Description  上传并更新当前用户头像
+// @Description  上传并更新当前用户头像，只接受jpeg/png/gif(按文件内容判断，不看扩展名)，gif只取第一帧
+// @Description  服务端解码后重新编码为jpeg(去掉EXIF等元数据)，最长边超过512时等比缩小，并生成正方形缩略图
+// @Description  开启审核时头像先生效并进入审核队列(pending_review为true)，管理员驳回后恢复原头像
 // @Tags         profile
 // @Accept       multipart/form-data
 // @Produce      json
-// @Param        new_head_image  formData  file  true  "头像文件"
-// @Success      200  {object}  dto.Response  "修改成功"
-// @Failure      400  {object}  dto.Response  "请求参数错误"
+// @Param        new_head_image  formData  file  true   "头像文件，最大5MB，宽高不超过4096"
+// @Param        version         formData  int   false  "读到的用户版本号，与当前不一致时返回409"
+// @Success      200  {object}  dto.Response{data=dto.HeadImageData}  "修改成功"
+// @Failure      400  {object}  dto.Response  "请求参数错误或图片无法解析"
 // @Failure      401  {object}  dto.Response  "登录状态异常"
 // @Failure      403  {object}  dto.Response  "修改过于频繁"
 // @Failure      404  {object}  dto.Response  "用户不存在"
+// @Failure      409  {object}  dto.Response  "数据已被其他请求修改"
+// @Failure      413  {object}  dto.Response  "图片过大"
+// @Failure      415  {object}  dto.Response  "图片格式不支持"
 // @Failure      500  {object}  dto.Response  "服务器错误"
 // @Security     BearerAuth
 // @Router       /api/profile/update/headimage [put]
 func (h *ProfileHandler) UpdateHeadImage(c *gin.Context) {
 	var req dto.UpdateHeadImageRequest
-	if err := c.ShouldBind(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.Response{
-			Code:    http.StatusBadRequest,
-			Message: "请求参数错误:" + err.Error(),
-		})
+	if !bindImageForm(c, &req, h.imageOptions.MaxBytes) {
 		return
 	}
 
@@ -209,62 +232,119 @@ func (h *ProfileHandler) UpdateHeadImage(c *gin.Context) {
 		return
 	}
 
-	if req.NewHeadImage == nil || req.NewHeadImage.Size == 0 {
-		c.JSON(http.StatusBadRequest, dto.Response{
-			Code:    http.StatusBadRequest,
-			Message: "头像为空",
-		})
-		return
+	if req.NewHeadImage != nil {
+		h.logger.InfoContext(c.Request.Context(), "用户上传头像", "user_id", userID, "size", req.NewHeadImage.Size)
 	}
-
-	log.Printf("用户(id:%d)上传头像,Size:%d", userID, req.NewHeadImage.Size)
-	savePath, err := utils.SaveImages(c, req.NewHeadImage, config.PrefixHeadImg)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, dto.Response{
-			Code:    http.StatusInternalServerError,
-			Message: "图片保存失败：" + err.Error(),
-		})
+	processed, ok := processUploadedImage(c, req.NewHeadImage, h.imageOptions)
+	if !ok {
 		return
 	}
 
-	oldHeadImagePath, err := h.profileService.UpdateHeadImage(userID, savePath)
+	headImage, err := h.profileService.UpdateHeadImage(c.Request.Context(), userID, processed, req.Version)
 	if err != nil {
-		_ = utils.RemoveFile(savePath)
 		switch {
 		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
 		case errors.Is(err, service.ErrHeadImageTooFrequent):
 			c.JSON(http.StatusForbidden, dto.Response{Code: http.StatusForbidden, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "修改头像失败：" + err.Error()})
 		}
 		return
 	}
-
-	if oldHeadImagePath != "" && oldHeadImagePath != savePath && oldHeadImagePath != config.DefaultHeadImagePath {
-		if removeErr := utils.RemoveFile(oldHeadImagePath); removeErr != nil {
-			log.Printf("删除旧头像失败(user_id:%d,path:%s): %v", userID, oldHeadImagePath, removeErr)
-		}
-	}
-
 	c.JSON(http.StatusOK, dto.Response{
 		Code:    http.StatusOK,
 		Message: "修改头像成功",
+		Data:    headImage,
 	})
 }
 
+// headImageFormOverhead multipart里除文件外的部分(边界、其他字段)允许的大小
+const headImageFormOverhead = 64 << 10
+
+// bindImageForm 绑定带图片的multipart表单，整个请求体也限制住，multipart解析时不会先把超大文件落盘
+// 失败时已经写好响应，返回false
+func bindImageForm(c *gin.Context, req interface{}, maxBytes int64) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+headImageFormOverhead)
+	if err := c.ShouldBind(req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, dto.Response{Code: http.StatusRequestEntityTooLarge, Message: utils.ErrImageTooLarge.Error()})
+			return false
+		}
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    http.StatusBadRequest,
+			Message: "请求参数错误:" + err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// processUploadedImage 按头像的规则校验并重新编码上传的图片，失败时已经写好响应，返回false
+func processUploadedImage(c *gin.Context, file *multipart.FileHeader, options utils.ImageProcessOptions) (*utils.ProcessedImage, bool) {
+	if file == nil || file.Size == 0 {
+		c.JSON(http.StatusBadRequest, dto.Response{
+			Code:    http.StatusBadRequest,
+			Message: "图片为空",
+		})
+		return nil, false
+	}
+	if file.Size > options.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, dto.Response{Code: http.StatusRequestEntityTooLarge, Message: utils.ErrImageTooLarge.Error()})
+		return nil, false
+	}
+
+	data, err := readUploadedFile(file, options.MaxBytes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "读取图片失败：" + err.Error()})
+		return nil, false
+	}
+	processed, err := utils.ProcessImage(data, options)
+	if err != nil {
+		switch {
+		case errors.Is(err, utils.ErrImageTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, dto.Response{Code: http.StatusRequestEntityTooLarge, Message: err.Error()})
+		case errors.Is(err, utils.ErrUnsupportedImageType):
+			c.JSON(http.StatusUnsupportedMediaType, dto.Response{Code: http.StatusUnsupportedMediaType, Message: err.Error()})
+		case errors.Is(err, utils.ErrImageDimensionsTooLarge), errors.Is(err, utils.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "图片处理失败：" + err.Error()})
+		}
+		return nil, false
+	}
+	return processed, true
+}
+
+// readUploadedFile 最多读limit+1字节，超出说明文件实际比声明的大
+func readUploadedFile(file *multipart.FileHeader, limit int64) ([]byte, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit+1))
+}
+
 // UpdateCoinByType godoc
 // @Summary      用户按类型修改金币
 // @Description  通过query参数type(strength/select)修改对应金币
+// @Description  带Idempotency-Key请求头时，网络重试不会重复修改，重试直接返回第一次的结果
 // @Tags         profile
 // @Accept       json
 // @Produce      json
 // @Param        type  query     string                   true  "金币类型(strength/select)"
 // @Param        body  body      dto.UpdateCoinByTypeRequest true  "修改金币请求体"
+// @Param        Idempotency-Key  header  string  false  "幂等键，客户端为每次操作生成的唯一值"
 // @Success      200   {object}  dto.Response             "修改成功"
 // @Failure      400   {object}  dto.Response             "请求参数错误"
 // @Failure      401   {object}  dto.Response             "登录状态异常"
 // @Failure      404   {object}  dto.Response             "用户不存在"
+// @Failure      409   {object}  dto.Response             "数据已被其他请求修改或相同幂等键的请求正在处理"
+// @Failure      422   {object}  dto.Response             "幂等键已用于其他请求"
 // @Failure      500   {object}  dto.Response             "数据库错误"
 // @Security     BearerAuth
 // @Router       /api/profile/update/coin [put]
@@ -292,13 +372,15 @@ func (h *ProfileHandler) UpdateCoinByType(c *gin.Context) {
 	}
 
 	coinType := c.Query("type")
-	userData, err := h.profileService.UpdateCoinByType(userID, coinType, *req.Coin)
+	userData, err := h.profileService.UpdateCoinByType(c.Request.Context(), userID, coinType, *req.Coin, req.Version)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrMissingCoinType), errors.Is(err, service.ErrUnsupportedCoinType):
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
 		}
@@ -315,16 +397,19 @@ func (h *ProfileHandler) UpdateCoinByType(c *gin.Context) {
 // CreateSelfRelationByType godoc
 // @Summary      用户按类型创建自身资源关联
 // @Description  通过query参数type(achievements/skills/items/cards)和body中的resource_id创建本人关联记录
+// @Description  带Idempotency-Key请求头时，网络重试会返回第一次的结果，而不是"关联已存在"
 // @Tags         profile-relation
 // @Accept       json
 // @Produce      json
 // @Param        type  query     string                       true  "关联类型(achievements/skills/items/cards)"
 // @Param        body  body      dto.UserRelationCreateRequest true  "创建关联请求体"
+// @Param        Idempotency-Key  header  string  false  "幂等键，客户端为每次操作生成的唯一值"
 // @Success      200   {object}  dto.Response                 "创建成功"
 // @Failure      400   {object}  dto.Response                 "请求参数错误"
 // @Failure      401   {object}  dto.Response                 "登录状态异常"
 // @Failure      404   {object}  dto.Response                 "目标资源不存在"
-// @Failure      409   {object}  dto.Response                 "关联已存在"
+// @Failure      409   {object}  dto.Response                 "关联已存在或相同幂等键的请求正在处理"
+// @Failure      422   {object}  dto.Response                 "幂等键已用于其他请求"
 // @Failure      500   {object}  dto.Response                 "创建失败"
 // @Security     BearerAuth
 // @Router       /api/profile/operation/relations [post]
@@ -352,9 +437,9 @@ func (h *ProfileHandler) CreateSelfRelationByType(c *gin.Context) {
 		return
 	}
 
-	log.Printf("创建关联请求: user_id=%d type=%s resource_id=%d", userID, relationType, req.ResourceID)
+	h.logger.DebugContext(c.Request.Context(), "创建关联请求", "user_id", userID, "type", relationType, "resource_id", req.ResourceID)
 
-	data, err := h.profileService.CreateSelfRelationByType(userID, relationType, req.ResourceID)
+	data, err := h.profileService.CreateSelfRelationByType(c.Request.Context(), userID, relationType, req.ResourceID)
 	if err != nil {
 		switch {
 		case errors.Is(err, gorm.ErrRecordNotFound):
@@ -368,12 +453,12 @@ func (h *ProfileHandler) CreateSelfRelationByType(c *gin.Context) {
 	}
 
 	if data.Resource.ResourceID == 0 || data.Resource.ResourceID != req.ResourceID {
-		log.Printf("创建关联结果异常: user_id=%d type=%s req_resource_id=%d resp_resource_id=%d", userID, relationType, req.ResourceID, data.Resource.ResourceID)
+		h.logger.ErrorContext(c.Request.Context(), "创建关联结果异常", "user_id", userID, "type", relationType, "req_resource_id", req.ResourceID, "resp_resource_id", data.Resource.ResourceID)
 		c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: service.ErrRelationCreateInconsistent.Error()})
 		return
 	}
 
-	log.Printf("创建关联成功: user_id=%d type=%s resource_id=%d", userID, relationType, req.ResourceID)
+	h.logger.InfoContext(c.Request.Context(), "创建关联成功", "user_id", userID, "type", relationType, "resource_id", req.ResourceID)
 
 	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "创建成功", Data: data})
 }
@@ -381,15 +466,19 @@ func (h *ProfileHandler) CreateSelfRelationByType(c *gin.Context) {
 // UpdateSelfRelationByType godoc
 // @Summary      用户按类型更新自身资源关联
 // @Description  通过query参数type(achievements/skills/items/cards)和body更新本人关联记录，skills支持skill_grade
+// @Description  带If-Match(值为查询结果里的etag)时，记录在此期间被修改过则返回412，不带则直接覆盖
 // @Tags         profile-relation
 // @Accept       json
 // @Produce      json
-// @Param        type  query     string                       true  "关联类型(achievements/skills/items/cards)"
-// @Param        body  body      dto.UserRelationUpdateRequest true  "更新关联请求体"
+// @Param        type      query     string                       true   "关联类型(achievements/skills/items/cards)"
+// @Param        If-Match  header    string                       false  "关联记录的etag"
+// @Param        body      body      dto.UserRelationUpdateRequest true   "更新关联请求体"
 // @Success      200   {object}  dto.Response                 "更新成功"
 // @Failure      400   {object}  dto.Response                 "请求参数错误"
 // @Failure      401   {object}  dto.Response                 "登录状态异常"
 // @Failure      404   {object}  dto.Response                 "关联记录不存在"
+// @Failure      409   {object}  dto.Response                 "数据已被其他请求修改"
+// @Failure      412   {object}  dto.Response                 "记录已被修改"
 // @Failure      500   {object}  dto.Response                 "更新失败"
 // @Security     BearerAuth
 // @Router       /api/profile/update/relations [put]
@@ -416,20 +505,26 @@ func (h *ProfileHandler) UpdateSelfRelationByType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
 		return
 	}
+	req.IfMatch = c.GetHeader("If-Match")
 
-	data, err := h.profileService.UpdateSelfRelationByType(userID, relationType, req)
+	data, err := h.profileService.UpdateSelfRelationByType(c.Request.Context(), userID, relationType, req)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoUpdateFields), errors.Is(err, service.ErrSkillGradeOnlyForSkills), errors.Is(err, service.ErrUnsupportedRelationType):
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 		case errors.Is(err, gorm.ErrRecordNotFound):
 			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "关联记录不存在"})
+		case errors.Is(err, service.ErrPreconditionFailed):
+			c.JSON(http.StatusPreconditionFailed, dto.Response{Code: http.StatusPreconditionFailed, Message: err.Error()})
+		case errors.Is(err, service.ErrVersionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "更新失败：" + err.Error()})
 		}
 		return
 	}
 
+	c.Header("ETag", data.ETag)
 	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "更新成功", Data: data})
 }
 
@@ -472,7 +567,7 @@ func (h *ProfileHandler) DeleteSelfRelationByType(c *gin.Context) {
 		return
 	}
 
-	if err = h.profileService.DeleteSelfRelationByType(userID, relationType, req.ResourceID); err != nil {
+	if err = h.profileService.DeleteSelfRelationByType(c.Request.Context(), userID, relationType, req.ResourceID); err != nil {
 		switch {
 		case errors.Is(err, service.ErrUnsupportedRelationType):
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
@@ -490,9 +585,13 @@ func (h *ProfileHandler) DeleteSelfRelationByType(c *gin.Context) {
 // GetSelfProfile godoc
 // @Summary      获取当前用户信息
 // @Description  查询当前登录用户的基础信息
+// @Description  响应带弱ETag和Last-Modified，按版本号和更新时间在查询前计算，带If-None-Match或If-Modified-Since且内容没变时直接返回304(无响应体)
 // @Tags         profile
 // @Produce      json
+// @Param        If-None-Match      header    string  false  "上次响应的ETag"
+// @Param        If-Modified-Since  header    string  false  "上次响应的Last-Modified"
 // @Success      200  {object}  dto.Response  "查询成功"
+// @Success      304  "内容未变化"
 // @Failure      401  {object}  dto.Response  "登录状态异常"
 // @Failure      404  {object}  dto.Response  "用户不存在"
 // @Failure      500  {object}  dto.Response  "数据库错误"
@@ -505,7 +604,7 @@ func (h *ProfileHandler) GetSelfProfile(c *gin.Context) {
 		return
 	}
 
-	data, err := h.profileService.GetSelfProfile(userID)
+	data, updatedAt, err := h.profileService.GetSelfProfile(c.Request.Context(), userID)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
@@ -516,21 +615,29 @@ func (h *ProfileHandler) GetSelfProfile(c *gin.Context) {
 		return
 	}
 
+	middleware.SetLastModified(c, updatedAt)
 	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: data})
 }
 
 // GetSelfRelationsByType godoc
 // @Summary      用户按类型查询自身资源关联
 // @Description  通过query参数type查询本人在achievements/skills/items/cards中的关联数据，支持分页
+// @Description  响应带弱ETag和Last-Modified，按关联数量和最后修改时间(包括删除)在查询前计算，带If-None-Match或If-Modified-Since且内容没变时直接返回304
+// @Description  每条记录的etag可用于更新时的If-Match
 // @Tags         profile-relation
 // @Produce      json
-// @Param        type       query     string  true   "关联类型(achievements/skills/items/cards)"
-// @Param        page       query     int     false  "页码，默认1"
-// @Param        page_size  query     int     false  "每页数量，默认20，最大100"
-// @Success      200        {object}  dto.Response  "查询成功"
-// @Failure      400        {object}  dto.Response  "请求参数错误"
-// @Failure      401        {object}  dto.Response  "登录状态异常"
-// @Failure      500        {object}  dto.Response  "查询失败"
+// @Param        If-None-Match      header  string  false  "上次响应的ETag"
+// @Param        If-Modified-Since  header  string  false  "上次响应的Last-Modified"
+// @Param        type        query     string  true   "关联类型(achievements/skills/items/cards)"
+// @Param        page        query     int     false  "页码，默认1"
+// @Param        page_size   query     int     false  "每页数量，默认20，最大100"
+// @Param        cursor      query     string  false  "游标，带上该参数即使用游标分页，第一页传空值，之后传上一页的next_cursor"
+// @Param        with_total  query     bool    false  "是否返回total，页码模式默认true，游标模式默认false"
+// @Success      200         {object}  dto.Response{data=dto.CommonUserRelationPageData}  "查询成功"
+// @Success      304         "内容未变化"
+// @Failure      400         {object}  dto.Response  "请求参数错误"
+// @Failure      401         {object}  dto.Response  "登录状态异常"
+// @Failure      500         {object}  dto.Response  "查询失败"
 // @Security     BearerAuth
 // @Router       /api/profile/get/relations [get]
 func (h *ProfileHandler) GetSelfRelationsByType(c *gin.Context) {
@@ -540,11 +647,12 @@ func (h *ProfileHandler) GetSelfRelationsByType(c *gin.Context) {
 		return
 	}
 
-	pagination := middleware.GetPagination(c)
-	list, total, err := h.profileService.GetSelfRelationsByType(userID, c.Query("type"), pagination)
+	pagination := middleware.GetPagination(c, h.pagination)
+	list, info, err := h.profileService.GetSelfRelationsByType(c.Request.Context(), userID, c.Query("type"), pagination)
 	if err != nil {
 		switch {
-		case errors.Is(err, service.ErrMissingRelationType), errors.Is(err, service.ErrUnsupportedRelationType):
+		case errors.Is(err, service.ErrMissingRelationType), errors.Is(err, service.ErrUnsupportedRelationType),
+			errors.Is(err, utils.ErrCursorMismatch):
 			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
@@ -556,14 +664,49 @@ func (h *ProfileHandler) GetSelfRelationsByType(c *gin.Context) {
 		Code:    http.StatusOK,
 		Message: "查询成功",
 		Data: dto.CommonUserRelationPageData{
-			List:     list,
-			Total:    total,
-			Page:     pagination.Page,
-			PageSize: pagination.PageSize,
+			List:       list,
+			Total:      info.Total,
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			NextCursor: utils.EncodeCursor(info.Next),
 		},
 	})
 }
 
+// SelfProfileValidators 给ConditionalGET用，按用户的版本号和更新时间生成校验信息，不加载资料
+func (h *ProfileHandler) SelfProfileValidators(c *gin.Context) (middleware.Validators, error) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return middleware.Validators{}, service.ErrMissingUserContext
+	}
+	stamp, err := h.profileService.GetSelfProfileStamp(c.Request.Context(), userID)
+	if err != nil {
+		return middleware.Validators{}, err
+	}
+	return stampValidators("self", userID, "", stamp), nil
+}
+
+// SelfRelationsValidators 给ConditionalGET用，按关联数量和最后修改时间生成校验信息，分页参数也算进ETag
+func (h *ProfileHandler) SelfRelationsValidators(c *gin.Context) (middleware.Validators, error) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		return middleware.Validators{}, service.ErrMissingUserContext
+	}
+	stamp, err := h.profileService.GetSelfRelationsStamp(c.Request.Context(), userID, c.Query("type"))
+	if err != nil {
+		return middleware.Validators{}, err
+	}
+	return stampValidators("relations", userID, c.Request.URL.RawQuery, stamp), nil
+}
+
+func stampValidators(kind string, userID uint, query string, stamp service.ContentStamp) middleware.Validators {
+	return middleware.Validators{
+		ETag: utils.StampETag(kind, strconv.FormatUint(uint64(userID), 10), query,
+			strconv.FormatUint(stamp.Version, 10), strconv.FormatInt(stamp.Count, 10), stamp.UpdatedAt.UTC().Format(time.RFC3339Nano)),
+		LastModified: stamp.UpdatedAt,
+	}
+}
+
 func getUserIDFromContext(c *gin.Context) (uint, bool) {
 	userIDValue, exists := c.Get("user_id")
 	if !exists {
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SnapshotHandler struct {
+	snapshotService *service.SnapshotService
+}
+
+func NewSnapshotHandler(snapshotService *service.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{snapshotService: snapshotService}
+}
+
+// GetSnapshot godoc
+// @Summary      玩家读取完整存档
+// @Description  一次返回用户资料、金币和全部关联(成就/技能/物品/卡牌)，revision为存档修订号，保存存档时作为base_revision带回
+// @Description  响应带弱ETag，客户端带If-None-Match请求且存档没变时返回304(无响应体)
+// @Tags         profile-snapshot
+// @Produce      json
+// @Param        If-None-Match  header    string  false  "上次响应的ETag"
+// @Success      200            {object}  dto.Response{data=dto.ProfileSnapshotData}  "查询成功"
+// @Success      304            "存档未变化"
+// @Failure      401            {object}  dto.Response  "登录状态异常"
+// @Failure      404            {object}  dto.Response  "用户不存在"
+// @Failure      500            {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/snapshot [get]
+func (h *SnapshotHandler) GetSnapshot(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	data, err := h.snapshotService.GetSnapshot(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: data})
+}
+
+// GetChanges godoc
+// @Summary      玩家增量同步存档
+// @Description  返回修订号since之后变化过的实体的当前状态，同一个实体只返回最终状态，被删除的关联返回deleted=true的墓碑
+// @Description  客户端应用完变更后把返回的revision作为下次的since；full_resync=true时应改为读取完整存档
+// @Tags         profile-snapshot
+// @Produce      json
+// @Param        since  query     int  true  "上次同步拿到的revision，首次同步传0"
+// @Success      200    {object}  dto.Response{data=dto.ProfileChangesData}  "查询成功"
+// @Failure      400    {object}  dto.Response  "请求参数错误"
+// @Failure      401    {object}  dto.Response  "登录状态异常"
+// @Failure      404    {object}  dto.Response  "用户不存在"
+// @Failure      500    {object}  dto.Response  "查询失败"
+// @Security     BearerAuth
+// @Router       /api/profile/changes [get]
+func (h *SnapshotHandler) GetChanges(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	since, err := strconv.ParseUint(c.Query("since"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "since参数缺失或格式错误"})
+		return
+	}
+
+	data, err := h.snapshotService.GetChanges(c.Request.Context(), userID, since)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "数据库查询失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "查询成功", Data: data})
+}
+
+// SaveSnapshot godoc
+// @Summary      玩家保存完整存档
+// @Description  服务端把请求中的存档和当前存档比对，只写入有差异的部分，整个保存在一个事务里
+// @Description  金币不传则不修改；relations只包含要同步的类型，出现的类型按列表整体覆盖，列表里没有的关联会被删除
+// @Description  base_revision和当前存档修订号不一致(期间在其他地方被修改过)时返回409，客户端应重新读取存档合并后再保存
+// @Tags         profile-snapshot
+// @Accept       json
+// @Produce      json
+// @Param        body  body      dto.ProfileSnapshotRequest  true  "存档"
+// @Success      200   {object}  dto.Response{data=dto.ProfileSnapshotData}  "保存成功，返回保存后的完整存档"
+// @Failure      400   {object}  dto.Response  "请求参数错误"
+// @Failure      401   {object}  dto.Response  "登录状态异常"
+// @Failure      404   {object}  dto.Response  "用户或资源不存在"
+// @Failure      409   {object}  dto.Response  "存档已在其他地方被修改"
+// @Failure      500   {object}  dto.Response  "保存失败"
+// @Security     BearerAuth
+// @Router       /api/profile/snapshot [put]
+func (h *SnapshotHandler) SaveSnapshot(c *gin.Context) {
+	userID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: service.ErrMissingUserContext.Error()})
+		return
+	}
+
+	var req dto.ProfileSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "请求参数错误:" + err.Error()})
+		return
+	}
+
+	data, err := h.snapshotService.SaveSnapshot(c.Request.Context(), userID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoUpdateFields), errors.Is(err, service.ErrUnsupportedRelationType),
+			errors.Is(err, service.ErrSnapshotDuplicateEntry), errors.Is(err, service.ErrSkillGradeOnlyForSkills):
+			c.JSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+		case errors.Is(err, service.ErrSnapshotResourceNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: err.Error()})
+		case errors.Is(err, service.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, dto.Response{Code: http.StatusNotFound, Message: "用户不存在"})
+		case errors.Is(err, service.ErrSnapshotRevisionConflict):
+			c.JSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "保存存档失败：" + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.Response{Code: http.StatusOK, Message: "保存成功", Data: data})
+}
@@ -0,0 +1,40 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestContextHandlerRequestID(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"带请求ID", WithRequestID(context.Background(), "req-1"), "req-1"},
+		{"不在请求里", context.Background(), ""},
+		{"请求ID为空", WithRequestID(context.Background(), ""), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, slog.LevelInfo, "json").With("component", "test")
+			logger.InfoContext(tt.ctx, "hello")
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("解析日志失败: %v, %s", err, buf.String())
+			}
+			got, _ := record["request_id"].(string)
+			if got != tt.want {
+				t.Fatalf("request_id = %q, want %q", got, tt.want)
+			}
+			if record["component"] != "test" {
+				t.Fatalf("With加的属性丢了: %s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "muxishooter"
+
+// Metrics 进程内的Prometheus指标，同时实现middleware.HTTPMetricsRecorder和service.GameMetrics
+// 用独立的registry，/metrics只暴露这里注册的指标
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	dbQueryDuration *prometheus.HistogramVec
+
+	registrations prometheus.Counter
+	logins        *prometheus.CounterVec
+	coins         *prometheus.CounterVec
+	draws         prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP请求数，route为路由模板，没匹配到路由时为unmatched",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP请求处理耗时，事件流长连接记录的是连接持续时间",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "GORM语句耗时，operation为create/query/update/delete/row/raw",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "result"}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "registrations_total",
+			Help:      "注册成功的用户数",
+		}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "登录次数，result为success/failure，failure只算用户名或密码错误",
+		}, []string{"result"}),
+		coins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "coins_total",
+			Help:      "金币变化总量，direction为earned/spent，source为player(客户端上报)/admin(管理员调整)",
+		}, []string{"coin_type", "direction", "source"}),
+		draws: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "draws_total",
+			Help:      "抽卡次数，按客户端上报的抽卡货币减少次数统计",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.dbQueryDuration,
+		m.registrations,
+		m.logins,
+		m.coins,
+		m.draws,
+	)
+	return m
+}
+
+// Handler 输出Prometheus文本格式
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, elapsed time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) Registered() {
+	m.registrations.Inc()
+}
+
+func (m *Metrics) LoginSucceeded() {
+	m.logins.WithLabelValues("success").Inc()
+}
+
+func (m *Metrics) LoginFailed() {
+	m.logins.WithLabelValues("failure").Inc()
+}
+
+func (m *Metrics) CoinsChanged(coinType, source string, delta int64) {
+	switch {
+	case delta > 0:
+		m.coins.WithLabelValues(coinType, "earned", source).Add(float64(delta))
+	case delta < 0:
+		m.coins.WithLabelValues(coinType, "spent", source).Add(float64(-delta))
+	}
+}
+
+func (m *Metrics) Drew() {
+	m.draws.Inc()
+}
@@ -0,0 +1,378 @@
+package migration
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var migrationFiles embed.FS
+
+var (
+	ErrInvalidMigrationFile = errors.New("迁移文件命名不合法")
+	ErrMissingDownMigration = errors.New("缺少对应的down迁移文件")
+	ErrDuplicateMigration   = errors.New("迁移版本重复")
+	ErrMigrationVersionGap  = errors.New("迁移版本不连续")
+	ErrUnknownVersion       = errors.New("目标版本不存在")
+	ErrSchemaOutdated       = errors.New("数据库结构版本落后")
+)
+
+const migrationsTable = "schema_migrations"
+
+type Migration struct {
+	Version uint
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return migrationsTable
+}
+
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	return newMigrator(db, migrationFiles, "sql")
+}
+
+func newMigrator(db *gorm.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := loadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+func (m *Migrator) LatestVersion() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) CurrentVersion() (uint, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return 0, err
+	}
+	var current uint
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// EnsureCurrent 检查数据库是否已经迁移到最新版本，落后时返回ErrSchemaOutdated
+func (m *Migrator) EnsureCurrent() error {
+	pending, err := m.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	current, err := m.CurrentVersion()
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w(当前:%d,最新:%d,待执行:%d个)", ErrSchemaOutdated, current, m.LatestVersion(), len(pending))
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	var records []SchemaMigration
+	if err := m.db.Order("version").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[uint]time.Time, len(records))
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			at := at
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// Up 执行全部未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.LatestVersion())
+}
+
+// Down 回滚最近的steps个迁移
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0, steps)
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err = m.rollback(migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// To 迁移到指定版本：高于当前版本时向上执行，低于时回滚，0表示全部回滚
+func (m *Migrator) To(target uint) ([]Migration, error) {
+	if target != 0 && !m.hasVersion(target) {
+		return nil, fmt.Errorf("%w:%d", ErrUnknownVersion, target)
+	}
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]Migration, 0)
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err = m.apply(migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err = m.rollback(migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	//MySQL的DDL会隐式提交，没法整体放进事务，这里只能逐条执行
+	//中途失败时版本不会被记录，修复后重新执行即可
+	if err := m.execStatements(migration.UpSQL); err != nil {
+		return fmt.Errorf("执行迁移%04d_%s失败: %w", migration.Version, migration.Name, err)
+	}
+	record := SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+	if err := m.db.Create(&record).Error; err != nil {
+		return fmt.Errorf("记录迁移%04d_%s失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	if err := m.execStatements(migration.DownSQL); err != nil {
+		return fmt.Errorf("回滚迁移%04d_%s失败: %w", migration.Version, migration.Name, err)
+	}
+	if err := m.db.Delete(&SchemaMigration{}, migration.Version).Error; err != nil {
+		return fmt.Errorf("删除迁移记录%04d_%s失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+func (m *Migrator) execStatements(sqlText string) error {
+	for _, statement := range splitStatements(sqlText) {
+		if err := m.db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) ensureMigrationsTable() error {
+	//表选项只有MySQL认，测试用的sqlite不加
+	tableOptions := ""
+	if m.db.Dialector.Name() == "mysql" {
+		tableOptions = " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+	}
+	return m.db.Exec("CREATE TABLE IF NOT EXISTS `" + migrationsTable + "` (" +
+		"`version` bigint unsigned NOT NULL," +
+		"`name` varchar(255) NOT NULL," +
+		"`applied_at` datetime(3) NOT NULL," +
+		"PRIMARY KEY (`version`)" +
+		")" + tableOptions).Error
+}
+
+func (m *Migrator) appliedVersions() (map[uint]struct{}, error) {
+	if err := m.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+	var versions []uint
+	if err := m.db.Model(&SchemaMigration{}).Pluck("version", &versions).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[uint]struct{}, len(versions))
+	for _, version := range versions {
+		applied[version] = struct{}{}
+	}
+	return applied, nil
+}
+
+func (m *Migrator) hasVersion(version uint) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		//文件名格式: 0001_init_schema.up.sql / 0001_init_schema.down.sql
+		fileName := entry.Name()
+		base := strings.TrimSuffix(fileName, ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok || (direction != ".up" && direction != ".down") {
+			return nil, fmt.Errorf("%w:%s", ErrInvalidMigrationFile, fileName)
+		}
+		version, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w:%s", ErrInvalidMigrationFile, fileName)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[uint(version)]
+		if !exists {
+			migration = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("%w:版本%d存在不同名称的文件", ErrDuplicateMigration, version)
+		}
+		//0001_a.up.sql和01_a.up.sql解析出来是同一个版本，不能让后读到的悄悄覆盖前面的
+		target := &migration.UpSQL
+		if direction == ".down" {
+			target = &migration.DownSQL
+		}
+		if *target != "" {
+			return nil, fmt.Errorf("%w:版本%d存在多个%s文件", ErrDuplicateMigration, version, strings.TrimPrefix(direction, "."))
+		}
+		*target = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpSQL == "" {
+			return nil, fmt.Errorf("%w:版本%d缺少up文件", ErrInvalidMigrationFile, migration.Version)
+		}
+		if migration.DownSQL == "" {
+			return nil, fmt.Errorf("%w:%04d_%s", ErrMissingDownMigration, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	//版本号从1开始连续，合并分支时撞号或漏号在启动时就能发现
+	for i, migration := range migrations {
+		if migration.Version != uint(i+1) {
+			return nil, fmt.Errorf("%w:期望版本%d，实际为%04d_%s", ErrMigrationVersionGap, i+1, migration.Version, migration.Name)
+		}
+	}
+	return migrations, nil
+}
+
+func splitStatements(sqlText string) []string {
+	//按行尾分号切分，迁移文件里不要在字符串里写换行+分号
+	statements := make([]string, 0)
+	var builder strings.Builder
+	for _, line := range strings.Split(sqlText, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		builder.WriteString(line)
+		builder.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(builder.String()), ";"))
+			if statement != "" {
+				statements = append(statements, statement)
+			}
+			builder.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(builder.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,170 @@
+package migration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		sqlText string
+		want    []string
+	}{
+		{"空文件", "", []string{}},
+		{"只有注释", "-- 说明\n  -- 缩进的注释\n", []string{}},
+		{"多条语句", "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n", []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"}},
+		{"跨行语句和注释", "-- 建表\nCREATE TABLE a (\n  id int\n);\n", []string{"CREATE TABLE a (\n  id int\n)"}},
+		{"行内分号不切分", "INSERT INTO a (s) VALUES ('x;y');\n", []string{"INSERT INTO a (s) VALUES ('x;y')"}},
+		{"引号里的--不算注释", "INSERT INTO a (s) VALUES (\n'-- x');\n", []string{"INSERT INTO a (s) VALUES (\n'-- x')"}},
+		{"最后一条没有分号", "DROP TABLE a;\nDROP TABLE b", []string{"DROP TABLE a", "DROP TABLE b"}},
+		{"单独一行的分号", "DROP TABLE a\n;\n;\n", []string{"DROP TABLE a"}},
+		{"CRLF换行", "DROP TABLE a;\r\nDROP TABLE b;\r\n", []string{"DROP TABLE a", "DROP TABLE b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.sqlText)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantErr      error
+		wantVersions []uint
+	}{
+		{"按版本排序", fstest.MapFS{
+			"sql/0002_b.up.sql": file("B"), "sql/0002_b.down.sql": file("b"),
+			"sql/0001_a.up.sql": file("A"), "sql/0001_a.down.sql": file("a"),
+		}, nil, []uint{1, 2}},
+		{"版本不连续", fstest.MapFS{
+			"sql/0001_a.up.sql": file("A"), "sql/0001_a.down.sql": file("a"),
+			"sql/0003_c.up.sql": file("C"), "sql/0003_c.down.sql": file("c"),
+		}, ErrMigrationVersionGap, nil},
+		{"不是从1开始", fstest.MapFS{
+			"sql/0002_b.up.sql": file("B"), "sql/0002_b.down.sql": file("b"),
+		}, ErrMigrationVersionGap, nil},
+		{"同一版本不同名称", fstest.MapFS{
+			"sql/0001_a.up.sql": file("A"), "sql/0001_a.down.sql": file("a"),
+			"sql/0001_b.up.sql": file("B"), "sql/0001_b.down.sql": file("b"),
+		}, ErrDuplicateMigration, nil},
+		{"同一版本写法不同", fstest.MapFS{
+			"sql/0001_a.up.sql": file("A"), "sql/0001_a.down.sql": file("a"),
+			"sql/01_a.up.sql": file("A2"),
+		}, ErrDuplicateMigration, nil},
+		{"缺少down文件", fstest.MapFS{
+			"sql/0001_a.up.sql": file("A"),
+		}, ErrMissingDownMigration, nil},
+		{"缺少up文件", fstest.MapFS{
+			"sql/0001_a.down.sql": file("a"),
+		}, ErrInvalidMigrationFile, nil},
+		{"文件名没有方向", fstest.MapFS{
+			"sql/0001_a.sql": file("A"),
+		}, ErrInvalidMigrationFile, nil},
+		{"版本号为0", fstest.MapFS{
+			"sql/0000_a.up.sql": file("A"), "sql/0000_a.down.sql": file("a"),
+		}, ErrInvalidMigrationFile, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "sql")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			versions := make([]uint, 0, len(migrations))
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.wantVersions) {
+				t.Fatalf("版本为%v，期望%v", versions, tt.wantVersions)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	if _, err := loadMigrations(migrationFiles, "sql"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigratorTo(t *testing.T) {
+	//后一个迁移依赖前一个建的表，up/down顺序错了就会执行失败
+	files := fstest.MapFS{
+		"sql/0001_users.up.sql":        {Data: []byte("CREATE TABLE users (id integer PRIMARY KEY);\n")},
+		"sql/0001_users.down.sql":      {Data: []byte("DROP TABLE users;\n")},
+		"sql/0002_user_name.up.sql":    {Data: []byte("ALTER TABLE users ADD COLUMN name text;\n")},
+		"sql/0002_user_name.down.sql":  {Data: []byte("ALTER TABLE users DROP COLUMN name;\n")},
+		"sql/0003_user_index.up.sql":   {Data: []byte("-- 依赖0002的name列\nCREATE INDEX idx_users_name ON users (name);\n")},
+		"sql/0003_user_index.down.sql": {Data: []byte("DROP INDEX idx_users_name;\n")},
+	}
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	m, err := newMigrator(db, files, "sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//按顺序执行，每一步都基于上一步的结果
+	steps := []struct {
+		name        string
+		target      uint
+		wantErr     error
+		wantDone    []uint
+		wantCurrent uint
+	}{
+		{"从空库升到最新", 3, nil, []uint{1, 2, 3}, 3},
+		{"已是目标版本", 3, nil, []uint{}, 3},
+		{"回滚到1按倒序执行", 1, nil, []uint{3, 2}, 1},
+		{"升到2", 2, nil, []uint{2}, 2},
+		{"全部回滚", 0, nil, []uint{2, 1}, 0},
+		{"目标版本不存在", 9, ErrUnknownVersion, nil, 0},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			done, err := m.To(step.target)
+			if !errors.Is(err, step.wantErr) {
+				t.Fatalf("got %v, want %v", err, step.wantErr)
+			}
+			if err == nil {
+				versions := make([]uint, 0, len(done))
+				for _, migration := range done {
+					versions = append(versions, migration.Version)
+				}
+				if !reflect.DeepEqual(versions, step.wantDone) {
+					t.Fatalf("执行了%v，期望%v", versions, step.wantDone)
+				}
+			}
+			current, err := m.CurrentVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if current != step.wantCurrent {
+				t.Fatalf("当前版本为%d，期望%d", current, step.wantCurrent)
+			}
+		})
+	}
+}
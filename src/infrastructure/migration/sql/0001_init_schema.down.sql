@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS `user_cards`;
+DROP TABLE IF EXISTS `user_items`;
+DROP TABLE IF EXISTS `user_skills`;
+DROP TABLE IF EXISTS `user_achievements`;
+DROP TABLE IF EXISTS `cards`;
+DROP TABLE IF EXISTS `items`;
+DROP TABLE IF EXISTS `skills`;
+DROP TABLE IF EXISTS `achievements`;
+DROP TABLE IF EXISTS `users`;
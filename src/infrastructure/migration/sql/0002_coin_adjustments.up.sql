@@ -0,0 +1,17 @@
+-- 管理员调整金币的流水，每次调整都必须带原因
+-- admin_id不加外键，管理员账号被删后流水仍然保留
+
+CREATE TABLE IF NOT EXISTS `coin_adjustments` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `admin_id` bigint unsigned NOT NULL,
+  `coin_type` varchar(32) NOT NULL,
+  `delta` bigint NOT NULL,
+  `balance_before` bigint unsigned NOT NULL,
+  `balance_after` bigint unsigned NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_coin_adjustments_user_id` (`user_id`),
+  CONSTRAINT `fk_coin_adjustments_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,5 @@
+ALTER TABLE `user_cards` DROP COLUMN `version`;
+ALTER TABLE `user_items` DROP COLUMN `version`;
+ALTER TABLE `user_skills` DROP COLUMN `version`;
+ALTER TABLE `user_achievements` DROP COLUMN `version`;
+ALTER TABLE `users` DROP COLUMN `version`;
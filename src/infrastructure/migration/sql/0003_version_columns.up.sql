@@ -0,0 +1,8 @@
+-- 乐观锁版本号，每次修改都+1，更新时带上读到的版本号做条件
+-- 已有数据从1开始
+
+ALTER TABLE `users` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+ALTER TABLE `user_achievements` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+ALTER TABLE `user_skills` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+ALTER TABLE `user_items` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
+ALTER TABLE `user_cards` ADD COLUMN `version` bigint unsigned NOT NULL DEFAULT 1;
@@ -0,0 +1,19 @@
+-- 带Idempotency-Key的写请求的处理记录，过期后可以删除
+-- completed=0表示第一次请求还在处理中，此时expires_at是占位的过期时间，防止进程崩溃后key一直被占着
+
+CREATE TABLE IF NOT EXISTS `idempotency_records` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `idempotency_key` varchar(128) NOT NULL,
+  `request_hash` varchar(64) NOT NULL,
+  `completed` tinyint(1) NOT NULL DEFAULT 0,
+  `status_code` bigint NULL,
+  `content_type` varchar(128) NULL,
+  `response` longblob NULL,
+  `expires_at` datetime(3) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_idempotency_user_key` (`user_id`, `idempotency_key`),
+  KEY `idx_idempotency_records_expires_at` (`expires_at`),
+  CONSTRAINT `fk_idempotency_records_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
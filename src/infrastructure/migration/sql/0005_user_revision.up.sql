@@ -0,0 +1,3 @@
+-- 用户存档修订号，资料或任何关联变化都+1，云存档同步时用来检测冲突
+
+ALTER TABLE `users` ADD COLUMN `revision` bigint unsigned NOT NULL DEFAULT 0;
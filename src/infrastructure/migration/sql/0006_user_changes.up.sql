@@ -0,0 +1,15 @@
+-- 用户存档的变更流水，客户端带上次同步的revision来拉取之后的变更
+-- 同一个修订号可以有多行(一次存档保存会改多个实体)
+
+CREATE TABLE IF NOT EXISTS `user_changes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `revision` bigint unsigned NOT NULL,
+  `entity` varchar(32) NOT NULL,
+  `resource_id` bigint unsigned NOT NULL DEFAULT 0,
+  `op` varchar(16) NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_changes_user_revision` (`user_id`, `revision`),
+  CONSTRAINT `fk_user_changes_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `user_blocks`;
+DROP TABLE IF EXISTS `friendships`;
+DROP TABLE IF EXISTS `friend_requests`;
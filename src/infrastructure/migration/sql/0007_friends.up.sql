@@ -0,0 +1,37 @@
+-- 好友申请、好友关系(双向各一行)和拉黑
+
+CREATE TABLE IF NOT EXISTS `friend_requests` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `from_user_id` bigint unsigned NOT NULL,
+  `to_user_id` bigint unsigned NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `message` varchar(100) NOT NULL DEFAULT '',
+  `responded_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_friend_requests_pair` (`from_user_id`, `to_user_id`),
+  KEY `idx_friend_requests_to_status` (`to_user_id`, `status`),
+  CONSTRAINT `fk_friend_requests_from_user` FOREIGN KEY (`from_user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_friend_requests_to_user` FOREIGN KEY (`to_user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `friendships` (
+  `user_id` bigint unsigned NOT NULL,
+  `friend_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`user_id`, `friend_id`),
+  KEY `idx_friendships_friend_id` (`friend_id`),
+  CONSTRAINT `fk_friendships_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_friendships_friend` FOREIGN KEY (`friend_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS `user_blocks` (
+  `user_id` bigint unsigned NOT NULL,
+  `blocked_user_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) NULL,
+  PRIMARY KEY (`user_id`, `blocked_user_id`),
+  KEY `idx_user_blocks_blocked_user_id` (`blocked_user_id`),
+  CONSTRAINT `fk_user_blocks_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `fk_user_blocks_blocked_user` FOREIGN KEY (`blocked_user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1 @@
+ALTER TABLE `users` DROP COLUMN `head_image_thumbnails`;
@@ -0,0 +1,2 @@
+-- 头像缩略图路径，JSON对象，key为边长
+ALTER TABLE `users` ADD COLUMN `head_image_thumbnails` text NULL AFTER `head_image_path`;
@@ -0,0 +1,11 @@
+-- 按内容寻址的上传文件的引用计数，key为存储里的对象key(如uploads/HeadImg_<sha256>.jpg)
+-- 旧版本上传的头像没有记录，由垃圾回收按users表里的引用补上，没人引用的直接当孤儿文件删掉
+
+CREATE TABLE IF NOT EXISTS `stored_files` (
+  `file_key` varchar(255) NOT NULL,
+  `ref_count` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`file_key`),
+  KEY `idx_stored_files_ref_count` (`ref_count`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
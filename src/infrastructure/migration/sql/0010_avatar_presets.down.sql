@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `avatar_presets`;
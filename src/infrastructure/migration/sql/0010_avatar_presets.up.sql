@@ -0,0 +1,16 @@
+-- 预设头像，图片存在存储里(按内容寻址，stored_files里有预设持有的引用)
+
+CREATE TABLE IF NOT EXISTS `avatar_presets` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(50) NOT NULL,
+  `head_image_path` varchar(255) NOT NULL,
+  `head_image_thumbnails` text NULL,
+  `unlock_type` varchar(16) NOT NULL DEFAULT '',
+  `unlock_resource_id` bigint unsigned NULL,
+  `sort_order` bigint NOT NULL DEFAULT 0,
+  `enabled` tinyint(1) NOT NULL DEFAULT 1,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uni_avatar_presets_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
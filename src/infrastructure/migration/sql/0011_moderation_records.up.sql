@@ -0,0 +1,21 @@
+-- 用户名/头像修改的审核记录，头像的pending记录在stored_files里持有旧头像的一份引用
+
+CREATE TABLE IF NOT EXISTS `moderation_records` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `field` varchar(16) NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `previous_value` varchar(255) NOT NULL,
+  `previous_head_thumbnails` text NULL,
+  `new_value` varchar(255) NOT NULL,
+  `new_head_thumbnails` text NULL,
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `reviewer_id` bigint unsigned NULL,
+  `reviewed_at` datetime(3) NULL,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_moderation_records_user_field` (`user_id`, `field`),
+  KEY `idx_moderation_records_status` (`status`),
+  CONSTRAINT `fk_moderation_records_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
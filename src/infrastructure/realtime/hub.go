@@ -0,0 +1,111 @@
+package realtime
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"sync"
+	"sync/atomic"
+)
+
+// subscriptionBuffer 每个连接最多积压的事件数，客户端读得太慢时直接断开，让它重连后走增量同步
+const subscriptionBuffer = 64
+
+// Hub 进程内的事件分发，按用户ID把事件推给该用户的所有在线连接
+// 只在单实例内有效，多实例部署时其他实例上的连接收不到
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[*Subscription]struct{}
+	seq         atomic.Uint64
+	closed      bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[uint]map[*Subscription]struct{})}
+}
+
+// Subscription 一个连接的订阅，Events被关闭表示订阅已结束(主动Close或积压过多被踢掉)
+type Subscription struct {
+	hub    *Hub
+	userID uint
+	events chan dto.PushEvent
+	closed bool
+}
+
+func (s *Subscription) Events() <-chan dto.PushEvent {
+	return s.events
+}
+
+// Close 可以重复调用
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+func (h *Hub) Subscribe(userID uint) *Subscription {
+	sub := &Subscription{hub: h, userID: userID, events: make(chan dto.PushEvent, subscriptionBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	//已关闭时返回一个结束了的订阅，调用方和被踢掉一样处理
+	if h.closed {
+		sub.closed = true
+		close(sub.events)
+		return sub
+	}
+	subs, ok := h.subscribers[userID]
+	if !ok {
+		subs = make(map[*Subscription]struct{})
+		h.subscribers[userID] = subs
+	}
+	subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish 不会阻塞，用户不在线时事件直接丢弃
+func (h *Hub) Publish(userID uint, event dto.PushEvent) {
+	event.ID = h.seq.Add(1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.events <- event:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Connections 当前在线的连接数
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	total := 0
+	for _, subs := range h.subscribers {
+		total += len(subs)
+	}
+	return total
+}
+
+// Close 结束所有订阅并拒绝新的订阅，服务器关闭时调用，否则事件流长连接会一直拖住Shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.removeLocked(sub)
+		}
+	}
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	close(sub.events)
+	subs := h.subscribers[sub.userID]
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.userID)
+	}
+}
@@ -0,0 +1,557 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// errBulkRollback 用来让atomic模式下有失败行的事务整体回滚，不会返回给调用方
+var errBulkRollback = errors.New("bulk rollback")
+
+const bulkInsertBatchSize = 200
+
+type AdminResourceRepositoryGorm struct {
+	db             *gorm.DB
+	grantBatchSize int
+}
+
+// NewAdminResourceRepository grantBatchSize为批量发放/回收每批处理的用户数
+func NewAdminResourceRepository(db *gorm.DB, grantBatchSize int) *AdminResourceRepositoryGorm {
+	return &AdminResourceRepositoryGorm{db: db, grantBatchSize: grantBatchSize}
+}
+
+// bulkRowFunc 处理单行，返回的id和data会写进这一行的结果里
+type bulkRowFunc func(tx *gorm.DB, index int) (uint, interface{}, error)
+
+func (r *AdminResourceRepositoryGorm) BulkCreateResources(ctx context.Context, resourceType service.UserRelationType, rows []dto.CommonResourceCreateRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if _, err := resourceModel(resourceType); err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	return r.runBulkRows(ctx, len(rows), atomic, func(tx *gorm.DB, index int) (uint, interface{}, error) {
+		return createResourceRow(tx, resourceType, rows[index])
+	})
+}
+
+func (r *AdminResourceRepositoryGorm) BulkUpdateResources(ctx context.Context, resourceType service.UserRelationType, rows []dto.CommonResourceUpdateRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if _, err := resourceModel(resourceType); err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	return r.runBulkRows(ctx, len(rows), atomic, func(tx *gorm.DB, index int) (uint, interface{}, error) {
+		data, err := updateResourceRow(tx, resourceType, rows[index])
+		return rows[index].ID, data, err
+	})
+}
+
+func (r *AdminResourceRepositoryGorm) BulkDeleteResources(ctx context.Context, resourceType service.UserRelationType, ids []uint, atomic bool) (dto.BulkOperationResult, error) {
+	spec, err := relationSpecFor(resourceType)
+	if err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	return r.runBulkRows(ctx, len(ids), atomic, func(tx *gorm.DB, index int) (uint, interface{}, error) {
+		//关联会被级联删除，先给拥有者记删除变更
+		if err := recordResourceOwnersDeleted(tx, resourceType, spec, ids[index]); err != nil {
+			return ids[index], nil, err
+		}
+		return ids[index], nil, mapDeleteResult(tx.Delete(spec.resourceModel(), ids[index]))
+	})
+}
+
+func (r *AdminResourceRepositoryGorm) BulkGrantResource(ctx context.Context, relationType service.UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+
+	return r.runBulkRelation(ctx, spec, req, atomic, func(tx *gorm.DB, rows []dto.BulkRowResult, owned map[uint]bool) error {
+		toGrant := make([]uint, 0, len(rows))
+		for i := range rows {
+			if rows[i].Status != "" {
+				continue
+			}
+			if owned[rows[i].ID] {
+				rows[i].Status, rows[i].Error = service.BulkRowSkipped, "已拥有该资源"
+				continue
+			}
+			rows[i].Status = service.BulkRowSucceeded
+			toGrant = append(toGrant, rows[i].ID)
+		}
+		if len(toGrant) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(spec.newRecords(req.ResourceID, toGrant), bulkInsertBatchSize).Error; err != nil {
+			return err
+		}
+		return recordUsersChange(tx, toGrant, relationChange(relationType, req.ResourceID, service.ChangeOpUpsert))
+	})
+}
+
+func (r *AdminResourceRepositoryGorm) BulkRevokeResource(ctx context.Context, relationType service.UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+
+	return r.runBulkRelation(ctx, spec, req, atomic, func(tx *gorm.DB, rows []dto.BulkRowResult, owned map[uint]bool) error {
+		toRevoke := make([]uint, 0, len(owned))
+		for i := range rows {
+			if rows[i].Status != "" {
+				continue
+			}
+			if !owned[rows[i].ID] {
+				rows[i].Status, rows[i].Error = service.BulkRowSkipped, "未拥有该资源"
+				continue
+			}
+			rows[i].Status = service.BulkRowSucceeded
+			toRevoke = append(toRevoke, rows[i].ID)
+		}
+		if len(toRevoke) == 0 {
+			return nil
+		}
+		err := tx.Where(spec.resourceColumn+" = ? AND user_id IN ?", req.ResourceID, toRevoke).Delete(spec.relationModel()).Error
+		if err != nil {
+			return err
+		}
+		return recordUsersChange(tx, toRevoke, relationChange(relationType, req.ResourceID, service.ChangeOpDelete))
+	})
+}
+
+// bulkRelationFunc 处理一批目标用户，把还没有状态的行标记为成功或跳过并写库
+// owned为这一批里已拥有该资源的用户
+type bulkRelationFunc func(tx *gorm.DB, rows []dto.BulkRowResult, owned map[uint]bool) error
+
+// runBulkRelation 在一个事务里按批解析目标用户并写库，分批只是为了控制单条SQL的大小，中途出错整体回滚
+// 用户失败只可能是显式给的user_id不存在或不满足筛选条件，atomic时处理完所有批次后有失败行就整体回滚
+func (r *AdminResourceRepositoryGorm) runBulkRelation(ctx context.Context, spec relationSpec, req dto.AdminBulkRelationRequest, atomic bool, fn bulkRelationFunc) (dto.BulkOperationResult, error) {
+	var result dto.BulkOperationResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(spec.resourceModel(), req.ResourceID).Error; err != nil {
+			return err
+		}
+
+		var rows []dto.BulkRowResult
+		batches := 0
+		cursor := newBulkUserCursor(req, r.grantBatchSize)
+		for !cursor.done {
+			batch, userIDs, err := cursor.next(tx)
+			if err != nil {
+				return err
+			}
+			owned, err := ownedRelationUsers(tx, spec, req.ResourceID, userIDs)
+			if err != nil {
+				return err
+			}
+			if err = fn(tx, batch, owned); err != nil {
+				return fmt.Errorf("第%d批处理失败，已整体回滚: %w", batches+1, err)
+			}
+			rows = append(rows, batch...)
+			batches++
+		}
+
+		result = buildBulkResult(rows)
+		result.Batches = batches
+		if atomic && result.Failed > 0 {
+			return errBulkRollback
+		}
+		return nil
+	})
+	return finishBulkResult(result, err)
+}
+
+func (r *AdminResourceRepositoryGorm) runBulkRows(ctx context.Context, count int, atomic bool, fn bulkRowFunc) (dto.BulkOperationResult, error) {
+	var result dto.BulkOperationResult
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rows := make([]dto.BulkRowResult, 0, count)
+		for i := 0; i < count; i++ {
+			row := dto.BulkRowResult{Index: i}
+			//每行嵌套一层事务(savepoint)，单行失败只回滚这一行
+			err := tx.Transaction(func(rowTx *gorm.DB) error {
+				id, data, err := fn(rowTx, i)
+				row.ID, row.Data = id, data
+				return err
+			})
+			if err != nil {
+				row.Status, row.Error, row.Data = service.BulkRowFailed, bulkRowErrorMessage(err), nil
+			} else {
+				row.Status = service.BulkRowSucceeded
+			}
+			rows = append(rows, row)
+		}
+
+		result = buildBulkResult(rows)
+		if atomic && result.Failed > 0 {
+			return errBulkRollback
+		}
+		return nil
+	})
+	return finishBulkResult(result, err)
+}
+
+// bulkUserCursor 按批解析发放/回收的目标用户
+// 显式给了user_ids时按请求顺序每次切一段，否则按id做keyset分页
+type bulkUserCursor struct {
+	req       dto.AdminBulkRelationRequest
+	batchSize int
+	//显式user_ids下一段的起点，或者已经产出的行数
+	offset int
+	lastID uint
+	seen   map[uint]bool
+	done   bool
+}
+
+func newBulkUserCursor(req dto.AdminBulkRelationRequest, batchSize int) *bulkUserCursor {
+	return &bulkUserCursor{req: req, batchSize: batchSize, seen: map[uint]bool{}}
+}
+
+// next 返回下一批的结果行和其中匹配上的用户ID，行里已经标记了重复和不存在/不满足筛选条件的用户
+func (c *bulkUserCursor) next(tx *gorm.DB) ([]dto.BulkRowResult, []uint, error) {
+	if len(c.req.UserIDs) > 0 {
+		return c.nextExplicit(tx)
+	}
+
+	var userIDs []uint
+	err := bulkUserQuery(tx, c.req).Where("id > ?", c.lastID).Order("id").Limit(c.batchSize).Pluck("id", &userIDs).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([]dto.BulkRowResult, 0, len(userIDs))
+	for _, id := range userIDs {
+		rows = append(rows, dto.BulkRowResult{Index: c.offset, ID: id})
+		c.offset++
+	}
+	if len(userIDs) > 0 {
+		c.lastID = userIDs[len(userIDs)-1]
+	}
+	c.done = len(userIDs) < c.batchSize
+	return rows, userIDs, nil
+}
+
+// nextExplicit 显式给了user_ids时按请求顺序逐个报告，没匹配上的直接标记失败
+func (c *bulkUserCursor) nextExplicit(tx *gorm.DB) ([]dto.BulkRowResult, []uint, error) {
+	end := min(c.offset+c.batchSize, len(c.req.UserIDs))
+	chunk := c.req.UserIDs[c.offset:end]
+
+	var userIDs []uint
+	if err := bulkUserQuery(tx, c.req).Where("id IN ?", chunk).Order("id").Pluck("id", &userIDs).Error; err != nil {
+		return nil, nil, err
+	}
+	found := make(map[uint]bool, len(userIDs))
+	for _, id := range userIDs {
+		found[id] = true
+	}
+
+	rows := make([]dto.BulkRowResult, 0, len(chunk))
+	matched := make([]uint, 0, len(userIDs))
+	for i, id := range chunk {
+		row := dto.BulkRowResult{Index: c.offset + i, ID: id}
+		switch {
+		case c.seen[id]:
+			row.Status, row.Error = service.BulkRowSkipped, "user_id重复"
+		case !found[id]:
+			row.Status, row.Error = service.BulkRowFailed, "用户不存在或不满足筛选条件"
+		default:
+			matched = append(matched, id)
+		}
+		c.seen[id] = true
+		rows = append(rows, row)
+	}
+	c.offset = end
+	c.done = end >= len(c.req.UserIDs)
+	return rows, matched, nil
+}
+
+func bulkUserQuery(tx *gorm.DB, req dto.AdminBulkRelationRequest) *gorm.DB {
+	query := tx.Model(&models.User{})
+	if req.RegisteredBefore != nil {
+		query = query.Where("created_at < ?", *req.RegisteredBefore)
+	}
+	if req.RegisteredAfter != nil {
+		query = query.Where("created_at > ?", *req.RegisteredAfter)
+	}
+	if req.Group != "" {
+		query = query.Where("`group` = ?", req.Group)
+	}
+	return query
+}
+
+// ownedRelationUsers 返回userIDs中已拥有该资源的用户
+func ownedRelationUsers(tx *gorm.DB, spec relationSpec, resourceID uint, userIDs []uint) (map[uint]bool, error) {
+	owned := make(map[uint]bool)
+	if len(userIDs) == 0 {
+		return owned, nil
+	}
+	var ownedIDs []uint
+	err := tx.Model(spec.relationModel()).
+		Where(spec.resourceColumn+" = ? AND user_id IN ?", resourceID, userIDs).
+		Pluck("user_id", &ownedIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ownedIDs {
+		owned[id] = true
+	}
+	return owned, nil
+}
+
+func createResourceRow(tx *gorm.DB, resourceType service.UserRelationType, req dto.CommonResourceCreateRequest) (uint, interface{}, error) {
+	if err := ensureResourceNameAvailable(tx, resourceType, req.Name, 0); err != nil {
+		return 0, nil, err
+	}
+
+	switch resourceType {
+	case service.UserRelationAchievement:
+		record := models.Achievement{Name: req.Name, Description: req.Description}
+		if err := tx.Create(&record).Error; err != nil {
+			return 0, nil, err
+		}
+		return record.ID, dto.BuildCommonAdminAchievementData(record), nil
+	case service.UserRelationSkill:
+		if err := ensurePrqSkillValid(tx, 0, req.PrqSkillID); err != nil {
+			return 0, nil, err
+		}
+		record := models.Skill{Name: req.Name, Description: req.Description, SkillGroup: req.SkillGroup, PrqSkillId: req.PrqSkillID}
+		if err := tx.Create(&record).Error; err != nil {
+			return 0, nil, err
+		}
+		return record.ID, dto.BuildCommonAdminSkillData(record), nil
+	case service.UserRelationItem:
+		record := models.Item{Name: req.Name, Description: req.Description}
+		if err := tx.Create(&record).Error; err != nil {
+			return 0, nil, err
+		}
+		return record.ID, dto.BuildCommonAdminItemData(record), nil
+	case service.UserRelationCard:
+		record := models.Card{Name: req.Name, Description: req.Description}
+		if err := tx.Create(&record).Error; err != nil {
+			return 0, nil, err
+		}
+		return record.ID, dto.BuildCommonAdminCardData(record), nil
+	default:
+		return 0, nil, service.ErrUnsupportedRelationType
+	}
+}
+
+func updateResourceRow(tx *gorm.DB, resourceType service.UserRelationType, req dto.CommonResourceUpdateRequest) (dto.CommonAdminResourceData, error) {
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		if err := ensureResourceNameAvailable(tx, resourceType, *req.Name, req.ID); err != nil {
+			return dto.CommonAdminResourceData{}, err
+		}
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if resourceType == service.UserRelationSkill {
+		if req.SkillGroup != nil {
+			updates["skill_group"] = *req.SkillGroup
+		}
+		if req.PrqSkillID != nil {
+			if err := ensurePrqSkillValid(tx, req.ID, *req.PrqSkillID); err != nil {
+				return dto.CommonAdminResourceData{}, err
+			}
+			updates["prq_skill_id"] = *req.PrqSkillID
+		}
+	}
+	if len(updates) == 0 {
+		return dto.CommonAdminResourceData{}, service.ErrNoUpdateFields
+	}
+
+	model, err := resourceModel(resourceType)
+	if err != nil {
+		return dto.CommonAdminResourceData{}, err
+	}
+	if err = tx.First(model, req.ID).Error; err != nil {
+		return dto.CommonAdminResourceData{}, err
+	}
+	if err = tx.Model(model).Updates(updates).Error; err != nil {
+		return dto.CommonAdminResourceData{}, err
+	}
+	if err = tx.First(model, req.ID).Error; err != nil {
+		return dto.CommonAdminResourceData{}, err
+	}
+	return buildCommonAdminResourceData(model), nil
+}
+
+func ensureResourceNameAvailable(tx *gorm.DB, resourceType service.UserRelationType, name string, excludeID uint) error {
+	model, err := resourceModel(resourceType)
+	if err != nil {
+		return err
+	}
+	query := tx.Model(model).Where("name = ?", name)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	if err = query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return service.ErrResourceNameExists
+	}
+	return nil
+}
+
+// ensurePrqSkillValid 0表示没有前置技能；否则前置技能必须存在，且顺着前置链往上走不能回到skillID自己
+func ensurePrqSkillValid(tx *gorm.DB, skillID, prqSkillID uint) error {
+	visited := map[uint]bool{}
+	for id := prqSkillID; id != 0; {
+		if id == skillID || visited[id] {
+			return service.ErrPrqSkillCycle
+		}
+		visited[id] = true
+		var skill models.Skill
+		if err := tx.Select("id", "prq_skill_id").First(&skill, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) && id == prqSkillID {
+				return service.ErrPrqSkillNotFound
+			}
+			//链条中间断掉说明是历史数据，不影响这次设置
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		id = skill.PrqSkillId
+	}
+	return nil
+}
+
+func resourceModel(resourceType service.UserRelationType) (interface{}, error) {
+	switch resourceType {
+	case service.UserRelationAchievement:
+		return &models.Achievement{}, nil
+	case service.UserRelationSkill:
+		return &models.Skill{}, nil
+	case service.UserRelationItem:
+		return &models.Item{}, nil
+	case service.UserRelationCard:
+		return &models.Card{}, nil
+	default:
+		return nil, service.ErrUnsupportedRelationType
+	}
+}
+
+func buildCommonAdminResourceData(model interface{}) dto.CommonAdminResourceData {
+	switch record := model.(type) {
+	case *models.Achievement:
+		return dto.BuildCommonAdminAchievementData(*record)
+	case *models.Skill:
+		return dto.BuildCommonAdminSkillData(*record)
+	case *models.Item:
+		return dto.BuildCommonAdminItemData(*record)
+	case *models.Card:
+		return dto.BuildCommonAdminCardData(*record)
+	default:
+		return dto.CommonAdminResourceData{}
+	}
+}
+
+// relationSpec 描述一种关联表：资源表、关联表和关联表里的资源ID列
+type relationSpec struct {
+	resourceColumn string
+	resourceModel  func() interface{}
+	relationModel  func() interface{}
+	newRecords     func(resourceID uint, userIDs []uint) interface{}
+}
+
+func relationSpecFor(relationType service.UserRelationType) (relationSpec, error) {
+	switch relationType {
+	case service.UserRelationAchievement:
+		return relationSpec{
+			resourceColumn: "achievement_id",
+			resourceModel:  func() interface{} { return &models.Achievement{} },
+			relationModel:  func() interface{} { return &models.UserAchievement{} },
+			newRecords: func(resourceID uint, userIDs []uint) interface{} {
+				records := make([]models.UserAchievement, 0, len(userIDs))
+				for _, userID := range userIDs {
+					records = append(records, models.UserAchievement{UserID: userID, AchievementID: resourceID})
+				}
+				return &records
+			},
+		}, nil
+	case service.UserRelationSkill:
+		return relationSpec{
+			resourceColumn: "skill_id",
+			resourceModel:  func() interface{} { return &models.Skill{} },
+			relationModel:  func() interface{} { return &models.UserSkill{} },
+			newRecords: func(resourceID uint, userIDs []uint) interface{} {
+				records := make([]models.UserSkill, 0, len(userIDs))
+				for _, userID := range userIDs {
+					records = append(records, models.UserSkill{UserID: userID, SkillID: resourceID})
+				}
+				return &records
+			},
+		}, nil
+	case service.UserRelationItem:
+		return relationSpec{
+			resourceColumn: "item_id",
+			resourceModel:  func() interface{} { return &models.Item{} },
+			relationModel:  func() interface{} { return &models.UserItem{} },
+			newRecords: func(resourceID uint, userIDs []uint) interface{} {
+				records := make([]models.UserItem, 0, len(userIDs))
+				for _, userID := range userIDs {
+					records = append(records, models.UserItem{UserID: userID, ItemID: resourceID})
+				}
+				return &records
+			},
+		}, nil
+	case service.UserRelationCard:
+		return relationSpec{
+			resourceColumn: "card_id",
+			resourceModel:  func() interface{} { return &models.Card{} },
+			relationModel:  func() interface{} { return &models.UserCard{} },
+			newRecords: func(resourceID uint, userIDs []uint) interface{} {
+				records := make([]models.UserCard, 0, len(userIDs))
+				for _, userID := range userIDs {
+					records = append(records, models.UserCard{UserID: userID, CardID: resourceID})
+				}
+				return &records
+			},
+		}, nil
+	default:
+		return relationSpec{}, service.ErrUnsupportedRelationType
+	}
+}
+
+func buildBulkResult(rows []dto.BulkRowResult) dto.BulkOperationResult {
+	result := dto.BulkOperationResult{Total: len(rows), Rows: rows}
+	for _, row := range rows {
+		switch row.Status {
+		case service.BulkRowSucceeded:
+			result.Succeeded++
+		case service.BulkRowFailed:
+			result.Failed++
+		default:
+			result.Skipped++
+		}
+	}
+	return result
+}
+
+func finishBulkResult(result dto.BulkOperationResult, err error) (dto.BulkOperationResult, error) {
+	if errors.Is(err, errBulkRollback) {
+		result.RolledBack = true
+		return result, nil
+	}
+	if err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	return result, nil
+}
+
+func bulkRowErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "目标资源不存在"
+	case errors.Is(err, service.ErrResourceNameExists), errors.Is(err, service.ErrNoUpdateFields),
+		errors.Is(err, service.ErrPrqSkillNotFound), errors.Is(err, service.ErrPrqSkillCycle):
+		return err.Error()
+	default:
+		return fmt.Sprintf("数据库错误：%v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AvatarPresetRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewAvatarPresetRepository(db *gorm.DB) *AvatarPresetRepositoryGorm {
+	return &AvatarPresetRepositoryGorm{db: db}
+}
+
+func (r *AvatarPresetRepositoryGorm) List(ctx context.Context, enabledOnly bool) ([]models.AvatarPreset, error) {
+	query := r.db.WithContext(ctx).Model(&models.AvatarPreset{})
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	var presets []models.AvatarPreset
+	if err := query.Order("sort_order ASC").Order("id ASC").Find(&presets).Error; err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+func (r *AvatarPresetRepositoryGorm) FindByID(ctx context.Context, presetID uint) (*models.AvatarPreset, bool, error) {
+	var preset models.AvatarPreset
+	err := r.db.WithContext(ctx).First(&preset, presetID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &preset, true, nil
+}
+
+func (r *AvatarPresetRepositoryGorm) CompletedResourceIDs(ctx context.Context, userID uint, relationType service.UserRelationType, resourceIDs []uint) (map[uint]bool, error) {
+	completed := make(map[uint]bool)
+	if len(resourceIDs) == 0 {
+		return completed, nil
+	}
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	err = r.db.WithContext(ctx).Model(spec.relationModel()).
+		Where("user_id = ? AND is_complete = ?", userID, true).
+		Where(spec.resourceColumn+" IN ?", resourceIDs).
+		Pluck(spec.resourceColumn, &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		completed[id] = true
+	}
+	return completed, nil
+}
+
+func (r *AvatarPresetRepositoryGorm) ResourceExists(ctx context.Context, relationType service.UserRelationType, resourceID uint) (bool, error) {
+	model, err := resourceModel(relationType)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	if err = r.db.WithContext(ctx).Model(model).Where("id = ?", resourceID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *AvatarPresetRepositoryGorm) Create(ctx context.Context, preset *models.AvatarPreset) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensurePresetNameAvailable(tx, preset.Name, 0); err != nil {
+			return err
+		}
+		return tx.Create(preset).Error
+	})
+}
+
+func (r *AvatarPresetRepositoryGorm) Update(ctx context.Context, preset *models.AvatarPreset) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//不能用RowsAffected判断是否存在，内容没变时MySQL返回0
+		var existing models.AvatarPreset
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&existing, preset.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return service.ErrAvatarPresetNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := ensurePresetNameAvailable(tx, preset.Name, preset.ID); err != nil {
+			return err
+		}
+		//Select里列出的列即使是零值(比如enabled=false、unlock_resource_id=nil)也会写入
+		return tx.Model(preset).
+			Select("name", "unlock_type", "unlock_resource_id", "sort_order", "enabled", "updated_at").
+			Updates(preset).Error
+	})
+}
+
+func (r *AvatarPresetRepositoryGorm) Delete(ctx context.Context, presetID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var preset models.AvatarPreset
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&preset, presetID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return service.ErrAvatarPresetNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err = releaseStoredFiles(tx, headImageFileKeys(preset.HeadImagePath, preset.HeadImageThumbnails)); err != nil {
+			return err
+		}
+		return tx.Delete(&preset).Error
+	})
+}
+
+func ensurePresetNameAvailable(tx *gorm.DB, name string, excludeID uint) error {
+	query := tx.Model(&models.AvatarPreset{}).Where("name = ?", name)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return service.ErrAvatarPresetNameExists
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CatalogueRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewCatalogueRepository(db *gorm.DB) *CatalogueRepositoryGorm {
+	return &CatalogueRepositoryGorm{db: db}
+}
+
+// catalogueRow 资源表LEFT JOIN用户关联表的一行，没有关联时ur.*都是NULL
+type catalogueRow struct {
+	ID          uint
+	Name        string
+	Description string
+	SkillGroup  string
+	PrqSkillID  uint
+	OwnerID     *uint
+	IsComplete  *bool
+	CompleteAt  *time.Time
+	SkillGrade  *uint
+	Claimed     *bool
+	ClaimedAt   *time.Time
+	AcquiredAt  *time.Time
+}
+
+func (r *CatalogueRepositoryGorm) ListCatalogue(ctx context.Context, userID uint, relationType service.UserRelationType) ([]dto.CatalogueEntryData, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return nil, err
+	}
+	resourceTable, err := tableNameOf(r.db, spec.resourceModel())
+	if err != nil {
+		return nil, err
+	}
+	relationTable, err := tableNameOf(r.db, spec.relationModel())
+	if err != nil {
+		return nil, err
+	}
+
+	columns := "r.id, r.name, r.description, ur.user_id AS owner_id, ur.is_complete, ur.complete_at, " +
+		"ur.claimed, ur.claimed_at, ur.created_at AS acquired_at"
+	if relationType == service.UserRelationSkill {
+		columns += ", r.skill_group, r.prq_skill_id, ur.skill_grade"
+	}
+
+	var rows []catalogueRow
+	err = r.db.WithContext(ctx).Table(resourceTable+" AS r").
+		Select(columns).
+		Joins("LEFT JOIN "+relationTable+" AS ur ON ur."+spec.resourceColumn+" = r.id AND ur.user_id = ?", userID).
+		Order("r.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.CatalogueEntryData, 0, len(rows))
+	for _, row := range rows {
+		entry := dto.CatalogueEntryData{
+			Resource: dto.CommonRelationResourceData{
+				ResourceID:   row.ID,
+				ResourceName: row.Name,
+				Description:  row.Description,
+				SkillGroup:   row.SkillGroup,
+				PrqSkillID:   row.PrqSkillID,
+			},
+			Owned: row.OwnerID != nil,
+		}
+		if entry.Owned {
+			entry.IsComplete = row.IsComplete != nil && *row.IsComplete
+			entry.CompleteAt = row.CompleteAt
+			entry.Claimed = row.Claimed != nil && *row.Claimed
+			entry.ClaimedAt = row.ClaimedAt
+			entry.AcquiredAt = row.AcquiredAt
+			if row.SkillGrade != nil {
+				entry.SkillGrade = *row.SkillGrade
+			}
+		}
+		list = append(list, entry)
+	}
+	return list, nil
+}
+
+// tableNameOf 按GORM的命名策略取模型对应的表名，避免在SQL里手写表名
+func tableNameOf(db *gorm.DB, model interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// errContentDryRun 用来让dry-run的事务回滚，不会返回给调用方
+var errContentDryRun = errors.New("content dry run")
+
+type ContentRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewContentRepository(db *gorm.DB) *ContentRepositoryGorm {
+	return &ContentRepositoryGorm{db: db}
+}
+
+type contentResourceRow struct {
+	ID          uint
+	Name        string
+	Description string
+	SkillGroup  string
+	PrqSkillId  uint
+}
+
+func (r *ContentRepositoryGorm) ImportResources(ctx context.Context, content dto.ContentFile, dryRun bool) (dto.ContentImportResult, error) {
+	result := dto.ContentImportResult{DryRun: dryRun, Entries: make([]dto.ContentDiffEntry, 0)}
+
+	//dry-run也在事务里真实执行一遍再回滚，这样前置技能引用同文件新建技能的情况也能算出准确的差异
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		simpleTypes := []struct {
+			relationType service.UserRelationType
+			resources    []dto.ContentResource
+			model        func() interface{}
+		}{
+			{service.UserRelationAchievement, content.Achievements, func() interface{} { return &models.Achievement{} }},
+			{service.UserRelationItem, content.Items, func() interface{} { return &models.Item{} }},
+			{service.UserRelationCard, content.Cards, func() interface{} { return &models.Card{} }},
+		}
+		for _, simple := range simpleTypes {
+			entries, err := importSimpleResources(tx, simple.relationType, simple.resources, simple.model)
+			if err != nil {
+				return err
+			}
+			result.Entries = append(result.Entries, entries...)
+		}
+
+		entries, err := importSkills(tx, content.Skills)
+		if err != nil {
+			return err
+		}
+		result.Entries = append(result.Entries, entries...)
+
+		if dryRun {
+			return errContentDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errContentDryRun) {
+		return dto.ContentImportResult{}, err
+	}
+
+	for _, entry := range result.Entries {
+		switch entry.Action {
+		case service.ContentActionCreate:
+			result.Created++
+		case service.ContentActionUpdate:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+func (r *ContentRepositoryGorm) ExportResources(ctx context.Context) (dto.ContentFile, error) {
+	content := dto.ContentFile{
+		Achievements: make([]dto.ContentResource, 0),
+		Skills:       make([]dto.ContentSkill, 0),
+		Items:        make([]dto.ContentResource, 0),
+		Cards:        make([]dto.ContentResource, 0),
+	}
+
+	var achievements []models.Achievement
+	if err := r.db.WithContext(ctx).Order("id").Find(&achievements).Error; err != nil {
+		return dto.ContentFile{}, err
+	}
+	for _, record := range achievements {
+		content.Achievements = append(content.Achievements, dto.ContentResource{Name: record.Name, Description: record.Description})
+	}
+
+	var skills []models.Skill
+	if err := r.db.WithContext(ctx).Order("id").Find(&skills).Error; err != nil {
+		return dto.ContentFile{}, err
+	}
+	skillNames := make(map[uint]string, len(skills))
+	for _, record := range skills {
+		skillNames[record.ID] = record.Name
+	}
+	for _, record := range skills {
+		content.Skills = append(content.Skills, dto.ContentSkill{
+			Name:         record.Name,
+			Description:  record.Description,
+			SkillGroup:   record.SkillGroup,
+			Prerequisite: skillNames[record.PrqSkillId],
+		})
+	}
+
+	var items []models.Item
+	if err := r.db.WithContext(ctx).Order("id").Find(&items).Error; err != nil {
+		return dto.ContentFile{}, err
+	}
+	for _, record := range items {
+		content.Items = append(content.Items, dto.ContentResource{Name: record.Name, Description: record.Description})
+	}
+
+	var cards []models.Card
+	if err := r.db.WithContext(ctx).Order("id").Find(&cards).Error; err != nil {
+		return dto.ContentFile{}, err
+	}
+	for _, record := range cards {
+		content.Cards = append(content.Cards, dto.ContentResource{Name: record.Name, Description: record.Description})
+	}
+
+	return content, nil
+}
+
+func importSimpleResources(tx *gorm.DB, relationType service.UserRelationType, resources []dto.ContentResource, model func() interface{}) ([]dto.ContentDiffEntry, error) {
+	entries := make([]dto.ContentDiffEntry, 0, len(resources))
+	for _, resource := range resources {
+		entry := dto.ContentDiffEntry{Type: string(relationType), Name: resource.Name}
+
+		existed, found, err := findContentRow(tx, model(), resource.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			record := model()
+			setSimpleResourceFields(record, resource)
+			if err = tx.Create(record).Error; err != nil {
+				return nil, err
+			}
+			entry.Action = service.ContentActionCreate
+			entries = append(entries, entry)
+			continue
+		}
+
+		if existed.Description != resource.Description {
+			entry.Changes = append(entry.Changes, dto.ContentFieldChange{Field: "description", From: existed.Description, To: resource.Description})
+			if err = tx.Model(model()).Where("id = ?", existed.ID).Update("description", resource.Description).Error; err != nil {
+				return nil, err
+			}
+		}
+		entry.Action = actionForChanges(entry.Changes)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func importSkills(tx *gorm.DB, skills []dto.ContentSkill) ([]dto.ContentDiffEntry, error) {
+	entries := make([]dto.ContentDiffEntry, 0, len(skills))
+	previousPrq := make(map[string]uint, len(skills))
+	skillIDs := make(map[string]uint, len(skills))
+
+	//第一轮只处理技能本身的字段，前置技能可能引用后面才建的技能，放到第二轮
+	for _, skill := range skills {
+		entry := dto.ContentDiffEntry{Type: string(service.UserRelationSkill), Name: skill.Name}
+
+		existed, found, err := findContentRow(tx, &models.Skill{}, skill.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			record := models.Skill{Name: skill.Name, Description: skill.Description, SkillGroup: skill.SkillGroup}
+			if err = tx.Create(&record).Error; err != nil {
+				return nil, err
+			}
+			skillIDs[skill.Name] = record.ID
+			entry.Action = service.ContentActionCreate
+			entries = append(entries, entry)
+			continue
+		}
+		skillIDs[skill.Name] = existed.ID
+
+		updates := map[string]interface{}{}
+		if existed.Description != skill.Description {
+			entry.Changes = append(entry.Changes, dto.ContentFieldChange{Field: "description", From: existed.Description, To: skill.Description})
+			updates["description"] = skill.Description
+		}
+		if existed.SkillGroup != skill.SkillGroup {
+			entry.Changes = append(entry.Changes, dto.ContentFieldChange{Field: "skill_group", From: existed.SkillGroup, To: skill.SkillGroup})
+			updates["skill_group"] = skill.SkillGroup
+		}
+		if len(updates) > 0 {
+			if err = tx.Model(&models.Skill{}).Where("id = ?", existed.ID).Updates(updates).Error; err != nil {
+				return nil, err
+			}
+		}
+		previousPrq[skill.Name] = existed.PrqSkillId
+		entries = append(entries, entry)
+	}
+
+	for i, skill := range skills {
+		var prqID uint
+		if skill.Prerequisite != "" {
+			prq, found, err := findContentRow(tx, &models.Skill{}, skill.Prerequisite)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				return nil, fmt.Errorf("%w:%s->%s", service.ErrContentPrerequisiteAbsent, skill.Name, skill.Prerequisite)
+			}
+			prqID = prq.ID
+		}
+
+		changed := prqID != 0
+		if entries[i].Action != service.ContentActionCreate {
+			oldPrqID := previousPrq[skill.Name]
+			changed = oldPrqID != prqID
+			if changed {
+				oldName, err := skillNameByID(tx, oldPrqID)
+				if err != nil {
+					return nil, err
+				}
+				entries[i].Changes = append(entries[i].Changes, dto.ContentFieldChange{Field: "prerequisite", From: oldName, To: skill.Prerequisite})
+			}
+			entries[i].Action = actionForChanges(entries[i].Changes)
+		}
+
+		if changed {
+			//文件内部的环已经在service里查过，这里查和库里已有技能连成的环；前面的修改已经生效，闭合环的那一条一定会被查到
+			if err := ensurePrqSkillValid(tx, skillIDs[skill.Name], prqID); err != nil {
+				if errors.Is(err, service.ErrPrqSkillCycle) {
+					return nil, fmt.Errorf("%w:%s->%s", service.ErrContentPrerequisiteCycle, skill.Name, skill.Prerequisite)
+				}
+				return nil, err
+			}
+			if err := tx.Model(&models.Skill{}).Where("name = ?", skill.Name).Update("prq_skill_id", prqID).Error; err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+func findContentRow(tx *gorm.DB, model interface{}, name string) (contentResourceRow, bool, error) {
+	var row contentResourceRow
+	result := tx.Model(model).Where("name = ?", name).Limit(1).Scan(&row)
+	if result.Error != nil {
+		return contentResourceRow{}, false, result.Error
+	}
+	return row, result.RowsAffected > 0, nil
+}
+
+func skillNameByID(tx *gorm.DB, id uint) (string, error) {
+	if id == 0 {
+		return "", nil
+	}
+	var skill models.Skill
+	err := tx.Select("name").First(&skill, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		//原前置技能已经被删掉了，按空处理
+		return "", nil
+	}
+	return skill.Name, err
+}
+
+func setSimpleResourceFields(record interface{}, resource dto.ContentResource) {
+	switch r := record.(type) {
+	case *models.Achievement:
+		r.Name, r.Description = resource.Name, resource.Description
+	case *models.Item:
+		r.Name, r.Description = resource.Name, resource.Description
+	case *models.Card:
+		r.Name, r.Description = resource.Name, resource.Description
+	}
+}
+
+func actionForChanges(changes []dto.ContentFieldChange) string {
+	if len(changes) > 0 {
+		return service.ContentActionUpdate
+	}
+	return service.ContentActionUnchanged
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FriendRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewFriendRepository(db *gorm.DB) *FriendRepositoryGorm {
+	return &FriendRepositoryGorm{db: db}
+}
+
+func (r *FriendRepositoryGorm) SendRequest(ctx context.Context, fromUserID, toUserID uint, message string, maxFriends int) (dto.FriendRequestData, bool, error) {
+	var request models.FriendRequest
+	accepted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockUserPair(tx, fromUserID, toUserID); err != nil {
+			return err
+		}
+
+		blocker, err := findBlocker(tx, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		switch blocker {
+		case fromUserID:
+			return service.ErrFriendTargetBlocked
+		case toUserID:
+			return service.ErrFriendRequestRejected
+		}
+
+		friends, err := areFriends(tx, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		if friends {
+			return service.ErrAlreadyFriends
+		}
+		if err = checkFriendLimit(tx, fromUserID, maxFriends, service.ErrFriendLimitReached); err != nil {
+			return err
+		}
+
+		//对方已经申请过自己，直接当作同意对方的申请
+		var reverse models.FriendRequest
+		err = tx.Where("from_user_id = ? AND to_user_id = ? AND status = ?", toUserID, fromUserID, service.FriendRequestPending).
+			First(&reverse).Error
+		if err == nil {
+			if err = acceptFriendRequest(tx, &reverse, maxFriends); err != nil {
+				return err
+			}
+			request = reverse
+			accepted = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		now := time.Now()
+		err = tx.Where("from_user_id = ? AND to_user_id = ?", fromUserID, toUserID).First(&request).Error
+		switch {
+		case err == nil:
+			if request.Status == service.FriendRequestPending {
+				return service.ErrFriendRequestPending
+			}
+			//被拒绝/取消过的申请重新打开，当作一条新申请
+			err = tx.Model(&request).Updates(map[string]interface{}{
+				"status":       service.FriendRequestPending,
+				"message":      message,
+				"responded_at": nil,
+				"created_at":   now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			request = models.FriendRequest{
+				FromUserID: fromUserID,
+				ToUserID:   toUserID,
+				Status:     service.FriendRequestPending,
+				Message:    message,
+			}
+			if err = tx.Create(&request).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.FriendRequestData{}, false, err
+	}
+
+	data, err := r.loadFriendRequest(ctx, request.ID)
+	return data, accepted, err
+}
+
+func (r *FriendRepositoryGorm) RespondRequest(ctx context.Context, userID, requestID uint, accept bool, maxFriends int) (dto.FriendRequestData, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var request models.FriendRequest
+		err := tx.Where("id = ? AND to_user_id = ? AND status = ?", requestID, userID, service.FriendRequestPending).First(&request).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return service.ErrFriendRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		//锁住双方后再确认一次，期间可能已被对方取消或因拉黑被关闭
+		if err = lockUserPair(tx, request.FromUserID, request.ToUserID); err != nil {
+			return err
+		}
+		err = tx.Where("id = ? AND status = ?", requestID, service.FriendRequestPending).First(&request).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return service.ErrFriendRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if accept {
+			return acceptFriendRequest(tx, &request, maxFriends)
+		}
+		now := time.Now()
+		return tx.Model(&request).Updates(map[string]interface{}{
+			"status":       service.FriendRequestDeclined,
+			"responded_at": now,
+		}).Error
+	})
+	if err != nil {
+		return dto.FriendRequestData{}, err
+	}
+	return r.loadFriendRequest(ctx, requestID)
+}
+
+func (r *FriendRepositoryGorm) CancelRequest(ctx context.Context, userID, requestID uint) error {
+	result := r.db.WithContext(ctx).Model(&models.FriendRequest{}).
+		Where("id = ? AND from_user_id = ? AND status = ?", requestID, userID, service.FriendRequestPending).
+		Updates(map[string]interface{}{
+			"status":       service.FriendRequestCancelled,
+			"responded_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return service.ErrFriendRequestNotFound
+	}
+	return nil
+}
+
+func (r *FriendRepositoryGorm) RemoveFriend(ctx context.Context, userID, friendID uint) error {
+	result := r.db.WithContext(ctx).Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", userID, friendID, friendID, userID).
+		Delete(&models.Friendship{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return service.ErrNotFriends
+	}
+	return nil
+}
+
+func (r *FriendRepositoryGorm) Block(ctx context.Context, userID, targetID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockUserPair(tx, userID, targetID); err != nil {
+			return err
+		}
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.UserBlock{UserID: userID, BlockedUserID: targetID}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", userID, targetID, targetID, userID).
+			Delete(&models.Friendship{}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.FriendRequest{}).
+			Where("((from_user_id = ? AND to_user_id = ?) OR (from_user_id = ? AND to_user_id = ?)) AND status = ?",
+				userID, targetID, targetID, userID, service.FriendRequestPending).
+			Updates(map[string]interface{}{
+				"status":       service.FriendRequestCancelled,
+				"responded_at": time.Now(),
+			}).Error
+	})
+}
+
+func (r *FriendRepositoryGorm) Unblock(ctx context.Context, userID, targetID uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND blocked_user_id = ?", userID, targetID).Delete(&models.UserBlock{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return service.ErrNotBlocked
+	}
+	return nil
+}
+
+func (r *FriendRepositoryGorm) ListFriends(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.FriendData, models.PageInfo, error) {
+	var records []models.Friendship
+	baseQuery := r.db.WithContext(ctx).Model(&models.Friendship{}).Where("user_id = ?", userID).Preload("Friend")
+	info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "friend_id", Field: "FriendID"}, &records)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	list := make([]dto.FriendData, 0, len(records))
+	for _, record := range records {
+		list = append(list, dto.FriendData{User: dto.BuildPublicUserData(record.Friend), Since: record.CreatedAt})
+	}
+	return list, info, nil
+}
+
+func (r *FriendRepositoryGorm) ListPendingRequests(ctx context.Context, userID uint, incoming bool, pagination models.Pagination) ([]dto.FriendRequestData, models.PageInfo, error) {
+	column := "from_user_id"
+	if incoming {
+		column = "to_user_id"
+	}
+	var records []models.FriendRequest
+	baseQuery := r.db.WithContext(ctx).Model(&models.FriendRequest{}).
+		Where(column+" = ? AND status = ?", userID, service.FriendRequestPending).
+		Preload("FromUser").Preload("ToUser")
+	//新的申请在前
+	order := utils.KeysetOrder{Key: utils.KeysetKey{Column: "id", Field: "ID"}, Desc: true}
+	info, err := utils.PaginateQueryOrdered(baseQuery, pagination, order, &records)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	list := make([]dto.FriendRequestData, 0, len(records))
+	for _, record := range records {
+		list = append(list, dto.BuildFriendRequestData(record))
+	}
+	return list, info, nil
+}
+
+func (r *FriendRepositoryGorm) ListBlocked(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.BlockedUserData, models.PageInfo, error) {
+	var records []models.UserBlock
+	baseQuery := r.db.WithContext(ctx).Model(&models.UserBlock{}).Where("user_id = ?", userID).Preload("BlockedUser")
+	info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "blocked_user_id", Field: "BlockedUserID"}, &records)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	list := make([]dto.BlockedUserData, 0, len(records))
+	for _, record := range records {
+		list = append(list, dto.BlockedUserData{User: dto.BuildPublicUserData(record.BlockedUser), BlockedAt: record.CreatedAt})
+	}
+	return list, info, nil
+}
+
+func (r *FriendRepositoryGorm) SearchUsers(ctx context.Context, userID uint, keyword string, pagination models.Pagination) ([]dto.PublicUserData, models.PageInfo, error) {
+	var users []models.User
+	blockedMe := r.db.WithContext(ctx).Model(&models.UserBlock{}).Select("user_id").Where("blocked_user_id = ?", userID)
+	baseQuery := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("id", "username", "head_image_path", "head_image_thumbnails").
+		Where(clause.Like{Column: clause.Column{Name: "username"}, Value: "%" + keyword + "%"}).
+		Where("id <> ?", userID).
+		Where("id NOT IN (?)", blockedMe)
+	info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "id", Field: "ID"}, &users)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	list := make([]dto.PublicUserData, 0, len(users))
+	for _, user := range users {
+		list = append(list, dto.BuildPublicUserData(user))
+	}
+	return list, info, nil
+}
+
+func (r *FriendRepositoryGorm) loadFriendRequest(ctx context.Context, requestID uint) (dto.FriendRequestData, error) {
+	var request models.FriendRequest
+	if err := r.db.WithContext(ctx).Preload("FromUser").Preload("ToUser").First(&request, requestID).Error; err != nil {
+		return dto.FriendRequestData{}, err
+	}
+	return dto.BuildFriendRequestData(request), nil
+}
+
+// lockUserPair 按id顺序锁住两个用户行，避免两边同时操作时死锁；有一个不存在时返回ErrUserNotFound
+func lockUserPair(tx *gorm.DB, userID, otherID uint) error {
+	var users []models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("id IN ?", []uint{userID, otherID}).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return err
+	}
+	if len(users) != 2 {
+		return service.ErrUserNotFound
+	}
+	return nil
+}
+
+// findBlocker 返回两人中拉黑了对方的那个，都没有拉黑时返回0
+func findBlocker(tx *gorm.DB, userID, otherID uint) (uint, error) {
+	var blocks []models.UserBlock
+	err := tx.Where("(user_id = ? AND blocked_user_id = ?) OR (user_id = ? AND blocked_user_id = ?)", userID, otherID, otherID, userID).
+		Find(&blocks).Error
+	if err != nil || len(blocks) == 0 {
+		return 0, err
+	}
+	//双方互相拉黑时优先提示自己拉黑了对方
+	for _, block := range blocks {
+		if block.UserID == userID {
+			return userID, nil
+		}
+	}
+	return otherID, nil
+}
+
+func areFriends(tx *gorm.DB, userID, otherID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&models.Friendship{}).Where("user_id = ? AND friend_id = ?", userID, otherID).Count(&count).Error
+	return count > 0, err
+}
+
+func checkFriendLimit(tx *gorm.DB, userID uint, maxFriends int, limitErr error) error {
+	var count int64
+	if err := tx.Model(&models.Friendship{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= int64(maxFriends) {
+		return limitErr
+	}
+	return nil
+}
+
+// acceptFriendRequest 需要已经锁住双方，写入双向的好友关系并把申请标为已同意
+func acceptFriendRequest(tx *gorm.DB, request *models.FriendRequest, maxFriends int) error {
+	if err := checkFriendLimit(tx, request.ToUserID, maxFriends, service.ErrFriendLimitReached); err != nil {
+		return err
+	}
+	if err := checkFriendLimit(tx, request.FromUserID, maxFriends, service.ErrFriendTargetLimitReached); err != nil {
+		return err
+	}
+
+	friendships := []models.Friendship{
+		{UserID: request.FromUserID, FriendID: request.ToUserID},
+		{UserID: request.ToUserID, FriendID: request.FromUserID},
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&friendships).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	return tx.Model(request).Updates(map[string]interface{}{
+		"status":       service.FriendRequestAccepted,
+		"responded_at": now,
+	}).Error
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/migration"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type HealthRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewHealthRepository(db *gorm.DB) *HealthRepositoryGorm {
+	return &HealthRepositoryGorm{db: db}
+}
+
+func (r *HealthRepositoryGorm) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *HealthRepositoryGorm) CheckMigrations(ctx context.Context) error {
+	migrator, err := migration.NewMigrator(r.db.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	return migrator.EnsureCurrent()
+}
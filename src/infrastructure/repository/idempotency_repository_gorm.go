@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepositoryGorm {
+	return &IdempotencyRepositoryGorm{db: db}
+}
+
+// Reserve 尝试占住record里的user_id+key，占住时返回nil，record.ID被填上
+// key已被占用(处理中或已完成)时返回已有的记录，过期的记录会先删掉再占
+func (r *IdempotencyRepositoryGorm) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	err := r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ? AND expires_at < ?", record.UserID, record.IdempotencyKey, time.Now()).
+		Delete(&models.IdempotencyRecord{}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	//并发的两个请求只有一个能插进去，靠唯一索引判断，不用先查再插
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	var existing models.IdempotencyRecord
+	err = r.db.WithContext(ctx).Where("user_id = ? AND idempotency_key = ?", record.UserID, record.IdempotencyKey).First(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (r *IdempotencyRepositoryGorm) Complete(ctx context.Context, id uint, statusCode int, contentType string, response []byte, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.IdempotencyRecord{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"completed":    true,
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"response":     response,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// Release 第一次请求没有产生确定结果(5xx)时删掉占位，让客户端可以用同一个key重试
+func (r *IdempotencyRepositoryGorm) Release(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.IdempotencyRecord{}).Error
+}
+
+func (r *IdempotencyRepositoryGorm) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
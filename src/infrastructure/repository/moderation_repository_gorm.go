@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ModerationRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) *ModerationRepositoryGorm {
+	return &ModerationRepositoryGorm{db: db}
+}
+
+func (r *ModerationRepositoryGorm) List(ctx context.Context, status, field string, userID uint, pagination models.Pagination) ([]models.ModerationRecord, models.PageInfo, error) {
+	query := r.db.WithContext(ctx).Model(&models.ModerationRecord{}).Where("status = ?", status)
+	if field != "" {
+		query = query.Where("field = ?", field)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var records []models.ModerationRecord
+	//新的在前
+	order := utils.KeysetOrder{Key: utils.KeysetKey{Column: "id", Field: "ID"}, Desc: true}
+	info, err := utils.PaginateQueryOrdered(query, pagination, order, &records)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	return records, info, nil
+}
+
+func (r *ModerationRepositoryGorm) Approve(ctx context.Context, moderationID, reviewerID uint) (*models.ModerationRecord, error) {
+	var record models.ModerationRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockPendingModeration(tx, moderationID, &record); err != nil {
+			return err
+		}
+		if record.Field == service.ModerationFieldHeadImage {
+			if err := releaseStoredFiles(tx, headImageFileKeys(record.PreviousValue, record.PreviousHeadThumbnails)); err != nil {
+				return err
+			}
+		}
+		return resolveModeration(tx, &record, service.ModerationApproved, reviewerID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *ModerationRepositoryGorm) Reject(ctx context.Context, moderationID, reviewerID uint, reason string) (*models.ModerationRecord, bool, error) {
+	var record models.ModerationRecord
+	reverted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		user, err := lockPendingModeration(tx, moderationID, &record)
+		if err != nil {
+			return err
+		}
+
+		//用户在审核前又改过(比如换成了预设头像、被管理员重置)时不动当前的值
+		updates := map[string]interface{}{}
+		switch record.Field {
+		case service.ModerationFieldUsername:
+			if user.Username == record.NewValue {
+				username, err := revertibleUsername(tx, user.ID, record.PreviousValue)
+				if err != nil {
+					return err
+				}
+				//清掉修改时间，被驳回后可以马上重新改
+				updates["username"] = username
+				updates["username_updated_at"] = nil
+			}
+		case service.ModerationFieldHeadImage:
+			if user.HeadImagePath == record.NewValue {
+				//记录持有的旧头像引用直接转给用户
+				if err = releaseStoredFiles(tx, headImageFileKeys(user.HeadImagePath, user.HeadImageThumbnails)); err != nil {
+					return err
+				}
+				updates["head_image_path"] = record.PreviousValue
+				updates["head_image_thumbnails"] = record.PreviousHeadThumbnails
+				updates["head_image_updated_at"] = nil
+			} else if err = releaseStoredFiles(tx, headImageFileKeys(record.PreviousValue, record.PreviousHeadThumbnails)); err != nil {
+				return err
+			}
+		}
+
+		if len(updates) > 0 {
+			updates["version"] = gorm.Expr("version + 1")
+			if err = tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err = recordUserChange(tx, user.ID, profileChange()); err != nil {
+				return err
+			}
+			reverted = true
+		}
+		return resolveModeration(tx, &record, service.ModerationRejected, reviewerID, reason)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &record, reverted, nil
+}
+
+// lockPendingModeration 先锁用户行再锁记录，和修改资料时submitModeration的加锁顺序一致
+func lockPendingModeration(tx *gorm.DB, moderationID uint, record *models.ModerationRecord) (*models.User, error) {
+	var userID uint
+	err := tx.Model(&models.ModerationRecord{}).Where("id = ?", moderationID).Pluck("user_id", &userID).Error
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 {
+		return nil, service.ErrModerationRecordNotFound
+	}
+
+	var user models.User
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "username", "head_image_path", "head_image_thumbnails").
+		First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, service.ErrModerationRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", moderationID, service.ModerationPending).
+		First(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, service.ErrModerationRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func resolveModeration(tx *gorm.DB, record *models.ModerationRecord, status string, reviewerID uint, reason string) error {
+	now := time.Now()
+	record.Status = status
+	record.ReviewerID = &reviewerID
+	record.ReviewedAt = &now
+	record.Reason = reason
+	return tx.Model(record).Select("status", "reviewer_id", "reviewed_at", "reason", "updated_at").Updates(record).Error
+}
+
+// revertibleUsername 原用户名在用户改名后可能被别人注册了，这时退回默认用户名
+func revertibleUsername(tx *gorm.DB, userID uint, previous string) (string, error) {
+	for _, username := range []string{previous, service.DefaultUsername(userID)} {
+		var count int64
+		err := tx.Model(&models.User{}).Where("username = ? AND id <> ?", username, userID).Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return username, nil
+		}
+	}
+	return "", service.ErrUsernameTaken
+}
+
+// submitModeration 在修改用户名/头像的事务里调用，调用方已经锁住用户行
+// 同一字段已有待审核记录时只更新NewValue，PreviousValue保持最后一次通过审核的值；改回了PreviousValue时删除记录
+func submitModeration(tx *gorm.DB, userID uint, field string, previousValue string, previousThumbnails models.HeadImageThumbnails, newValue string, newThumbnails models.HeadImageThumbnails) error {
+	var record models.ModerationRecord
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND field = ? AND status = ?", userID, field, service.ModerationPending).
+		First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if newValue == previousValue {
+			return nil
+		}
+		//驳回时要能改回旧头像，记录自己持有一份引用
+		if field == service.ModerationFieldHeadImage {
+			if err = acquireStoredFiles(tx, headImageFileKeys(previousValue, previousThumbnails)); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.ModerationRecord{
+			UserID:                 userID,
+			Field:                  field,
+			Status:                 service.ModerationPending,
+			PreviousValue:          previousValue,
+			PreviousHeadThumbnails: previousThumbnails,
+			NewValue:               newValue,
+			NewHeadThumbnails:      newThumbnails,
+		}).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if newValue == record.PreviousValue {
+		if field == service.ModerationFieldHeadImage {
+			if err = releaseStoredFiles(tx, headImageFileKeys(record.PreviousValue, record.PreviousHeadThumbnails)); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&record).Error
+	}
+	return tx.Model(&record).Updates(map[string]interface{}{
+		"new_value":           newValue,
+		"new_head_thumbnails": newThumbnails,
+		"updated_at":          time.Now(),
+	}).Error
+}
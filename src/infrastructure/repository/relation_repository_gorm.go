@@ -4,6 +4,8 @@ import (
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	"MuXi/2026-MuxiShooter-Backend/models"
 	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
 	"errors"
 	"time"
 
@@ -18,85 +20,140 @@ func NewRelationRepository(db *gorm.DB) *RelationRepositoryGorm {
 	return &RelationRepositoryGorm{db: db}
 }
 
-func (r *RelationRepositoryGorm) CreateUserRelation(userID uint, relationType service.UserRelationType, resourceID uint) (dto.CommonUserRelationData, error) {
-	operator, err := r.getOperator(relationType)
-	if err != nil {
-		return dto.CommonUserRelationData{}, err
-	}
-	return operator.Create(userID, resourceID)
+// CreateUserRelation/UpdateUserRelation/DeleteUserRelation 都和变更流水放在同一个事务里
+
+func (r *RelationRepositoryGorm) CreateUserRelation(ctx context.Context, userID uint, relationType service.UserRelationType, resourceID uint) (dto.CommonUserRelationData, error) {
+	var data dto.CommonUserRelationData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		operator, err := operatorFor(tx, relationType)
+		if err != nil {
+			return err
+		}
+		if data, err = operator.Create(userID, resourceID); err != nil {
+			return err
+		}
+		return recordUserChange(tx, userID, relationChange(relationType, resourceID, service.ChangeOpUpsert))
+	})
+	return data, err
 }
 
-func (r *RelationRepositoryGorm) UpdateUserRelation(userID uint, relationType service.UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error) {
-	operator, err := r.getOperator(relationType)
-	if err != nil {
-		return dto.CommonUserRelationData{}, err
-	}
-	return operator.Update(userID, req)
+func (r *RelationRepositoryGorm) UpdateUserRelation(ctx context.Context, userID uint, relationType service.UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error) {
+	var data dto.CommonUserRelationData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		operator, err := operatorFor(tx, relationType)
+		if err != nil {
+			return err
+		}
+		if data, err = operator.Update(userID, req); err != nil {
+			return err
+		}
+		return recordUserChange(tx, userID, relationChange(relationType, req.ResourceID, service.ChangeOpUpsert))
+	})
+	return data, err
 }
 
-func (r *RelationRepositoryGorm) DeleteUserRelation(userID uint, relationType service.UserRelationType, resourceID uint) error {
-	operator, err := r.getOperator(relationType)
-	if err != nil {
-		return err
-	}
-	return operator.Delete(userID, resourceID)
+func (r *RelationRepositoryGorm) DeleteUserRelation(ctx context.Context, userID uint, relationType service.UserRelationType, resourceID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		operator, err := operatorFor(tx, relationType)
+		if err != nil {
+			return err
+		}
+		if err = operator.Delete(userID, resourceID); err != nil {
+			return err
+		}
+		return recordUserChange(tx, userID, relationChange(relationType, resourceID, service.ChangeOpDelete))
+	})
 }
 
-func (r *RelationRepositoryGorm) QueryUserRelationsByType(userID uint, relationType service.UserRelationType, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
+func (r *RelationRepositoryGorm) QueryUserRelationsByType(ctx context.Context, userID uint, relationType service.UserRelationType, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error) {
 	switch relationType {
 	case service.UserRelationAchievement:
 		var records []models.UserAchievement
-		baseQuery := r.db.Model(&models.UserAchievement{}).Where("user_id = ?", userID).Preload("Achievement")
-		total, err := executePaginatedQuery(baseQuery, pagination, &records)
+		baseQuery := r.db.WithContext(ctx).Model(&models.UserAchievement{}).Where("user_id = ?", userID).Preload("Achievement")
+		info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "achievement_id", Field: "AchievementID"}, &records)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return dto.BuildCommonUserAchievementRelationList(records), total, nil
+		return dto.BuildCommonUserAchievementRelationList(records), info, nil
 	case service.UserRelationSkill:
 		var records []models.UserSkill
-		baseQuery := r.db.Model(&models.UserSkill{}).Where("user_id = ?", userID).Preload("Skill")
-		total, err := executePaginatedQuery(baseQuery, pagination, &records)
+		baseQuery := r.db.WithContext(ctx).Model(&models.UserSkill{}).Where("user_id = ?", userID).Preload("Skill")
+		info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "skill_id", Field: "SkillID"}, &records)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return dto.BuildCommonUserSkillRelationList(records), total, nil
+		return dto.BuildCommonUserSkillRelationList(records), info, nil
 	case service.UserRelationItem:
 		var records []models.UserItem
-		baseQuery := r.db.Model(&models.UserItem{}).Where("user_id = ?", userID).Preload("Item")
-		total, err := executePaginatedQuery(baseQuery, pagination, &records)
+		baseQuery := r.db.WithContext(ctx).Model(&models.UserItem{}).Where("user_id = ?", userID).Preload("Item")
+		info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "item_id", Field: "ItemID"}, &records)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return dto.BuildCommonUserItemRelationList(records), total, nil
+		return dto.BuildCommonUserItemRelationList(records), info, nil
 	case service.UserRelationCard:
 		var records []models.UserCard
-		baseQuery := r.db.Model(&models.UserCard{}).Where("user_id = ?", userID).Preload("Card")
-		total, err := executePaginatedQuery(baseQuery, pagination, &records)
+		baseQuery := r.db.WithContext(ctx).Model(&models.UserCard{}).Where("user_id = ?", userID).Preload("Card")
+		info, err := utils.PaginateQuery(baseQuery, pagination, utils.KeysetKey{Column: "card_id", Field: "CardID"}, &records)
 		if err != nil {
-			return nil, 0, err
+			return nil, models.PageInfo{}, err
 		}
-		return dto.BuildCommonUserCardRelationList(records), total, nil
+		return dto.BuildCommonUserCardRelationList(records), info, nil
 	default:
-		return nil, 0, service.ErrUnsupportedRelationType
+		return nil, models.PageInfo{}, service.ErrUnsupportedRelationType
 	}
 }
 
+// RelationListStamp 关联行和资源行取最新的updated_at，删除没有留下行，再和这类关联最新一条变更流水的时间比较
+// 每一项都只取一行，数据库可以走索引，不用把列表查出来
+func (r *RelationRepositoryGorm) RelationListStamp(ctx context.Context, userID uint, relationType service.UserRelationType) (service.ContentStamp, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return service.ContentStamp{}, err
+	}
+	db := r.db.WithContext(ctx)
+
+	var stamp service.ContentStamp
+	if err = db.Model(spec.relationModel()).Where("user_id = ?", userID).Count(&stamp.Count).Error; err != nil {
+		return service.ContentStamp{}, err
+	}
+	ownedResources := db.Model(spec.relationModel()).Select(spec.resourceColumn).Where("user_id = ?", userID)
+	latest := []struct {
+		query  *gorm.DB
+		column string
+	}{
+		{db.Model(spec.relationModel()).Where("user_id = ?", userID).Order("updated_at DESC"), "updated_at"},
+		{db.Model(spec.resourceModel()).Where("id IN (?)", ownedResources).Order("updated_at DESC"), "updated_at"},
+		{db.Model(&models.UserChange{}).Where("user_id = ? AND entity = ?", userID, string(relationType)).Order("revision DESC"), "created_at"},
+	}
+	for _, item := range latest {
+		var times []time.Time
+		if err = item.query.Limit(1).Pluck(item.column, &times).Error; err != nil {
+			return service.ContentStamp{}, err
+		}
+		if len(times) > 0 && times[0].After(stamp.UpdatedAt) {
+			stamp.UpdatedAt = times[0]
+		}
+	}
+	return stamp, nil
+}
+
 type relationOperator interface {
 	Create(userID uint, resourceID uint) (dto.CommonUserRelationData, error)
 	Update(userID uint, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error)
 	Delete(userID uint, resourceID uint) error
 }
 
-func (r *RelationRepositoryGorm) getOperator(relationType service.UserRelationType) (relationOperator, error) {
+func operatorFor(db *gorm.DB, relationType service.UserRelationType) (relationOperator, error) {
 	switch relationType {
 	case service.UserRelationAchievement:
-		return &achievementRelationOperator{db: r.db}, nil
+		return &achievementRelationOperator{db: db}, nil
 	case service.UserRelationSkill:
-		return &skillRelationOperator{db: r.db}, nil
+		return &skillRelationOperator{db: db}, nil
 	case service.UserRelationItem:
-		return &itemRelationOperator{db: r.db}, nil
+		return &itemRelationOperator{db: db}, nil
 	case service.UserRelationCard:
-		return &cardRelationOperator{db: r.db}, nil
+		return &cardRelationOperator{db: db}, nil
 	default:
 		return nil, service.ErrUnsupportedRelationType
 	}
@@ -140,7 +197,7 @@ func (op *achievementRelationOperator) Update(userID uint, req dto.UserRelationU
 	if err = op.db.Where("user_id = ? AND achievement_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
-	if err = op.db.Model(&record).Updates(updates).Error; err != nil {
+	if err = updateRelationWithVersion(op.db, &record, "achievement_id", userID, req, record.Version, updates); err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
 	if err = op.db.Preload("Achievement").Where("user_id = ? AND achievement_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
@@ -193,7 +250,7 @@ func (op *skillRelationOperator) Update(userID uint, req dto.UserRelationUpdateR
 	if err = op.db.Where("user_id = ? AND skill_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
-	if err = op.db.Model(&record).Updates(updates).Error; err != nil {
+	if err = updateRelationWithVersion(op.db, &record, "skill_id", userID, req, record.Version, updates); err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
 	if err = op.db.Preload("Skill").Where("user_id = ? AND skill_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
@@ -246,7 +303,7 @@ func (op *itemRelationOperator) Update(userID uint, req dto.UserRelationUpdateRe
 	if err = op.db.Where("user_id = ? AND item_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
-	if err = op.db.Model(&record).Updates(updates).Error; err != nil {
+	if err = updateRelationWithVersion(op.db, &record, "item_id", userID, req, record.Version, updates); err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
 	if err = op.db.Preload("Item").Where("user_id = ? AND item_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
@@ -299,7 +356,7 @@ func (op *cardRelationOperator) Update(userID uint, req dto.UserRelationUpdateRe
 	if err = op.db.Where("user_id = ? AND card_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
-	if err = op.db.Model(&record).Updates(updates).Error; err != nil {
+	if err = updateRelationWithVersion(op.db, &record, "card_id", userID, req, record.Version, updates); err != nil {
 		return dto.CommonUserRelationData{}, err
 	}
 	if err = op.db.Preload("Card").Where("user_id = ? AND card_id = ?", userID, req.ResourceID).First(&record).Error; err != nil {
@@ -357,26 +414,36 @@ func mapDeleteResult(result *gorm.DB) error {
 	return nil
 }
 
-func executePaginatedQuery(baseQuery *gorm.DB, pagination models.Pagination, dest interface{}) (int64, error) {
-	var total int64
-	if err := baseQuery.Count(&total).Error; err != nil {
-		return 0, err
+func createAndEnsureOneRow(db *gorm.DB, model interface{}) error {
+	result := db.Create(model)
+	if result.Error != nil {
+		return result.Error
 	}
-
-	if err := baseQuery.Limit(pagination.Limit).Offset(pagination.Offset).Find(dest).Error; err != nil {
-		return 0, err
+	if result.RowsAffected == 0 {
+		return service.ErrRelationCreateNoRows
 	}
-
-	return total, nil
+	return nil
 }
 
-func createAndEnsureOneRow(db *gorm.DB, model interface{}) error {
-	result := db.Create(model)
+// updateRelationWithVersion 先校验If-Match和客户端带的版本号，再按读到的版本号做条件更新
+// 读取和更新之间被别人改过时返回ErrVersionConflict
+func updateRelationWithVersion(db *gorm.DB, model interface{}, resourceColumn string, userID uint, req dto.UserRelationUpdateRequest, version uint64, updates map[string]interface{}) error {
+	if req.IfMatch != "" && !utils.ETagMatches(req.IfMatch, utils.VersionETag(version), false) {
+		return service.ErrPreconditionFailed
+	}
+	if req.Version != nil && *req.Version != version {
+		return service.ErrVersionConflict
+	}
+
+	updates["version"] = gorm.Expr("version + 1")
+	result := db.Model(model).
+		Where("user_id = ? AND "+resourceColumn+" = ? AND version = ?", userID, req.ResourceID, version).
+		Updates(updates)
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return service.ErrRelationCreateNoRows
+		return conflictOrNotFound(db.Model(model).Where("user_id = ? AND "+resourceColumn+" = ?", userID, req.ResourceID))
 	}
 	return nil
 }
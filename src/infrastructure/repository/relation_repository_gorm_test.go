@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openRepositoryDB 迁移脚本是MySQL语法，测试里用AutoMigrate在sqlite上建表
+func openRepositoryDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//内存库每个连接各是一个库
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	err = db.AutoMigrate(&models.User{}, &models.UserChange{},
+		&models.Achievement{}, &models.UserAchievement{}, &models.Skill{}, &models.UserSkill{},
+		&models.Item{}, &models.UserItem{}, &models.Card{}, &models.UserCard{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func createTestUsers(t *testing.T, db *gorm.DB, usernames ...string) []models.User {
+	t.Helper()
+	users := make([]models.User, 0, len(usernames))
+	for _, username := range usernames {
+		users = append(users, models.User{Username: username, Password: "x", Group: "user", Version: 1})
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+func TestRelationListStamp(t *testing.T) {
+	ctx := context.Background()
+	db := openRepositoryDB(t)
+	repo := NewRelationRepository(db)
+	users := createTestUsers(t, db, "a", "b")
+	me, other := users[0].ID, users[1].ID
+	items := []models.Item{{Name: "sword"}, {Name: "shield"}}
+	if err := db.Create(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Card{Name: "card"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	claimed := true
+
+	tests := []struct {
+		name        string
+		change      func() error
+		wantChanged bool
+		wantCount   int64
+	}{
+		{"没有关联", func() error { return nil }, false, 0},
+		{"新增关联", func() error {
+			_, err := repo.CreateUserRelation(ctx, me, service.UserRelationItem, items[0].ID)
+			return err
+		}, true, 1},
+		{"没有变化", func() error { return nil }, false, 1},
+		{"修改关联", func() error {
+			_, err := repo.UpdateUserRelation(ctx, me, service.UserRelationItem, dto.UserRelationUpdateRequest{ResourceID: items[0].ID, Claimed: &claimed})
+			return err
+		}, true, 1},
+		{"资源改名", func() error {
+			return db.Model(&models.Item{}).Where("id = ?", items[0].ID).Update("name", "long sword").Error
+		}, true, 1},
+		{"没拥有的资源改名", func() error {
+			return db.Model(&models.Item{}).Where("id = ?", items[1].ID).Update("name", "big shield").Error
+		}, false, 1},
+		{"别人的关联变化", func() error {
+			_, err := repo.CreateUserRelation(ctx, other, service.UserRelationItem, items[1].ID)
+			return err
+		}, false, 1},
+		{"别的类型变化", func() error {
+			_, err := repo.CreateUserRelation(ctx, me, service.UserRelationCard, 1)
+			return err
+		}, false, 1},
+		{"先删后加数量不变", func() error {
+			if err := repo.DeleteUserRelation(ctx, me, service.UserRelationItem, items[0].ID); err != nil {
+				return err
+			}
+			_, err := repo.CreateUserRelation(ctx, me, service.UserRelationItem, items[0].ID)
+			return err
+		}, true, 1},
+		{"删除关联", func() error {
+			return repo.DeleteUserRelation(ctx, me, service.UserRelationItem, items[0].ID)
+		}, true, 0},
+	}
+
+	previous, err := repo.RelationListStamp(ctx, me, service.UserRelationItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); err != nil {
+				t.Fatal(err)
+			}
+			stamp, err := repo.RelationListStamp(ctx, me, service.UserRelationItem)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := stamp != previous; changed != tt.wantChanged {
+				t.Fatalf("前后为%+v和%+v，期望变化=%v", previous, stamp, tt.wantChanged)
+			}
+			if stamp.Count != tt.wantCount {
+				t.Fatalf("数量为%d，期望%d", stamp.Count, tt.wantCount)
+			}
+			previous = stamp
+		})
+	}
+}
+
+func TestUpdateRelationWithVersion(t *testing.T) {
+	db := openRepositoryDB(t)
+	users := createTestUsers(t, db, "a")
+	if err := db.Create(&models.Item{Name: "sword"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	version := func(v uint64) *uint64 { return &v }
+
+	tests := []struct {
+		name string
+		//条件更新用的版本号，模拟读出记录之后被别人抢先改掉
+		readVersion uint64
+		req         dto.UserRelationUpdateRequest
+		wantErr     error
+		wantVersion uint64
+	}{
+		{"版本号一致", 1, dto.UserRelationUpdateRequest{ResourceID: 1}, nil, 2},
+		{"读到的版本号已过期", 1, dto.UserRelationUpdateRequest{ResourceID: 1}, service.ErrVersionConflict, 2},
+		{"客户端带的版本号过期", 2, dto.UserRelationUpdateRequest{ResourceID: 1, Version: version(1)}, service.ErrVersionConflict, 2},
+		{"If-Match不匹配", 2, dto.UserRelationUpdateRequest{ResourceID: 1, IfMatch: `"v1"`}, service.ErrPreconditionFailed, 2},
+		{"If-Match匹配", 2, dto.UserRelationUpdateRequest{ResourceID: 1, IfMatch: `"v2"`}, nil, 3},
+		{"记录不存在", 3, dto.UserRelationUpdateRequest{ResourceID: 99}, gorm.ErrRecordNotFound, 3},
+	}
+
+	if err := db.Create(&models.UserItem{UserID: users[0].ID, ItemID: 1, Version: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updates := map[string]interface{}{"claimed": true}
+			err := updateRelationWithVersion(db, &models.UserItem{}, "item_id", users[0].ID, tt.req, tt.readVersion, updates)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			var record models.UserItem
+			if err = db.Where("user_id = ? AND item_id = ?", users[0].ID, 1).First(&record).Error; err != nil {
+				t.Fatal(err)
+			}
+			if record.Version != tt.wantVersion {
+				t.Fatalf("版本号为%d，期望%d", record.Version, tt.wantVersion)
+			}
+		})
+	}
+}
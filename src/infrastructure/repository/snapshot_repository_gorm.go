@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// snapshotRelationTypes 存档里关联的固定顺序
+var snapshotRelationTypes = []service.UserRelationType{
+	service.UserRelationAchievement,
+	service.UserRelationSkill,
+	service.UserRelationItem,
+	service.UserRelationCard,
+}
+
+type SnapshotRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) *SnapshotRepositoryGorm {
+	return &SnapshotRepositoryGorm{db: db}
+}
+
+// snapshotRelationRow 比对存档差异时只关心的几列
+type snapshotRelationRow struct {
+	ResourceID uint
+	IsComplete bool
+	Claimed    bool
+	SkillGrade uint
+}
+
+func (r *SnapshotRepositoryGorm) LoadSnapshot(ctx context.Context, userID uint) (dto.ProfileSnapshotData, error) {
+	var data dto.ProfileSnapshotData
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		var err error
+		data, err = loadSnapshot(tx, user)
+		return err
+	})
+	return data, err
+}
+
+func (r *SnapshotRepositoryGorm) ApplySnapshot(ctx context.Context, userID uint, baseRevision uint64, patch service.SnapshotPatch) (dto.ProfileSnapshotData, service.CoinDeltas, error) {
+	var data dto.ProfileSnapshotData
+	deltas := service.CoinDeltas{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//锁住用户行，并发保存同一个存档时后来的会等前一个提交后再比对revision
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.Revision != baseRevision {
+			return service.ErrSnapshotRevisionConflict
+		}
+
+		//整个存档的改动共用一个新修订号
+		var changes []models.UserChange
+		for _, relationType := range snapshotRelationTypes {
+			entries, ok := patch.Relations[relationType]
+			if !ok {
+				continue
+			}
+			relationChanges, err := applySnapshotRelations(tx, userID, relationType, entries)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, relationChanges...)
+		}
+
+		updates := map[string]interface{}{}
+		if patch.StrengthCoin != nil && *patch.StrengthCoin != user.StrengthCoin {
+			updates["strength_coin"] = *patch.StrengthCoin
+			deltas["strength_coin"] = int64(*patch.StrengthCoin) - int64(user.StrengthCoin)
+		}
+		if patch.SelectCoin != nil && *patch.SelectCoin != user.SelectCoin {
+			updates["select_coin"] = *patch.SelectCoin
+			deltas["select_coin"] = int64(*patch.SelectCoin) - int64(user.SelectCoin)
+		}
+		if len(updates) > 0 {
+			updates["version"] = gorm.Expr("version + 1")
+			if err := tx.Model(&user).Updates(updates).Error; err != nil {
+				return err
+			}
+			changes = append(changes, profileChange())
+		}
+		if len(changes) > 0 {
+			if err := recordUserChange(tx, userID, changes...); err != nil {
+				return err
+			}
+		}
+
+		//重新读一次，拿到数据库生成的版本号和时间
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		var err error
+		data, err = loadSnapshot(tx, user)
+		return err
+	})
+	if err != nil {
+		return dto.ProfileSnapshotData{}, nil, err
+	}
+	return data, deltas, nil
+}
+
+// changedEntity 变更流水里去重后的实体
+type changedEntity struct {
+	Entity     string
+	ResourceID uint
+}
+
+func (r *SnapshotRepositoryGorm) LoadChanges(ctx context.Context, userID uint, since uint64, limit int) (dto.ProfileChangesData, error) {
+	data := dto.ProfileChangesData{Since: since, Relations: []dto.ChangedRelationData{}}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		data.Revision = user.Revision
+		if since >= user.Revision {
+			//比当前还新说明客户端的数据不是这个库里的(比如数据库回滚过)，只能整体重来
+			data.FullResync = since > user.Revision
+			return nil
+		}
+
+		//只看到user.Revision为止，之后提交的变更留给下一次同步
+		var entities []changedEntity
+		err := tx.Model(&models.UserChange{}).
+			Distinct("entity", "resource_id").
+			Where("user_id = ? AND revision > ? AND revision <= ?", userID, since, user.Revision).
+			Order("entity").Order("resource_id").
+			Limit(limit + 1).
+			Scan(&entities).Error
+		if err != nil {
+			return err
+		}
+		if len(entities) > limit {
+			data.FullResync = true
+			return nil
+		}
+
+		changedIDs := make(map[service.UserRelationType][]uint)
+		for _, entity := range entities {
+			if entity.Entity == service.ChangeEntityProfile {
+				profile := buildSnapshotProfile(user)
+				data.Profile = &profile
+				continue
+			}
+			relationType := service.UserRelationType(entity.Entity)
+			changedIDs[relationType] = append(changedIDs[relationType], entity.ResourceID)
+		}
+
+		for _, relationType := range snapshotRelationTypes {
+			ids := changedIDs[relationType]
+			if len(ids) == 0 {
+				continue
+			}
+			list, err := loadUserRelations(tx, userID, relationType, ids)
+			if err != nil {
+				return err
+			}
+			current := make(map[uint]dto.CommonUserRelationData, len(list))
+			for _, relation := range list {
+				current[relation.Resource.ResourceID] = relation
+			}
+			//流水里有但现在查不到的就是被删掉了
+			for _, id := range ids {
+				change := dto.ChangedRelationData{Type: string(relationType), ResourceID: id}
+				if relation, ok := current[id]; ok {
+					change.Relation = &relation
+				} else {
+					change.Deleted = true
+				}
+				data.Relations = append(data.Relations, change)
+			}
+		}
+		return nil
+	})
+	return data, err
+}
+
+// applySnapshotRelations 把一种关联整体覆盖成entries，返回有变化的关联
+func applySnapshotRelations(tx *gorm.DB, userID uint, relationType service.UserRelationType, entries []dto.SnapshotRelationEntry) ([]models.UserChange, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return nil, err
+	}
+	isSkill := relationType == service.UserRelationSkill
+
+	columns := spec.resourceColumn + " AS resource_id, is_complete, claimed"
+	if isSkill {
+		columns += ", skill_grade"
+	}
+	var rows []snapshotRelationRow
+	if err = tx.Model(spec.relationModel()).Select(columns).Where("user_id = ?", userID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	current := make(map[uint]snapshotRelationRow, len(rows))
+	for _, row := range rows {
+		current[row.ResourceID] = row
+	}
+
+	wanted := make(map[uint]bool, len(entries))
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		wanted[entry.ResourceID] = true
+		ids = append(ids, entry.ResourceID)
+	}
+	if len(ids) > 0 {
+		var existing []uint
+		if err = tx.Model(spec.resourceModel()).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+			return nil, err
+		}
+		if len(existing) != len(ids) {
+			found := make(map[uint]bool, len(existing))
+			for _, id := range existing {
+				found[id] = true
+			}
+			for _, id := range ids {
+				if !found[id] {
+					return nil, fmt.Errorf("%w:%s %d", service.ErrSnapshotResourceNotFound, relationType, id)
+				}
+			}
+		}
+	}
+
+	var changes []models.UserChange
+	toDelete := make([]uint, 0)
+	for _, row := range rows {
+		if !wanted[row.ResourceID] {
+			toDelete = append(toDelete, row.ResourceID)
+		}
+	}
+	if len(toDelete) > 0 {
+		err = tx.Where("user_id = ? AND "+spec.resourceColumn+" IN ?", userID, toDelete).Delete(spec.relationModel()).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, resourceID := range toDelete {
+			changes = append(changes, relationChange(relationType, resourceID, service.ChangeOpDelete))
+		}
+	}
+
+	for _, entry := range entries {
+		row, existed := current[entry.ResourceID]
+		if !existed {
+			if err = createAndEnsureOneRow(tx, spec.newRecords(entry.ResourceID, []uint{userID})); err != nil {
+				return nil, err
+			}
+			row = snapshotRelationRow{ResourceID: entry.ResourceID}
+			changes = append(changes, relationChange(relationType, entry.ResourceID, service.ChangeOpUpsert))
+		}
+
+		//只把和当前状态不同的字段写进去，complete_at/claimed_at跟着状态变化
+		req := dto.UserRelationUpdateRequest{ResourceID: entry.ResourceID}
+		if entry.IsComplete != row.IsComplete {
+			req.IsComplete = &entry.IsComplete
+		}
+		if entry.Claimed != row.Claimed {
+			req.Claimed = &entry.Claimed
+		}
+		if isSkill && entry.SkillGrade != row.SkillGrade {
+			req.SkillGrade = &entry.SkillGrade
+		}
+		if req.IsComplete == nil && req.Claimed == nil && req.SkillGrade == nil {
+			continue
+		}
+		if existed {
+			changes = append(changes, relationChange(relationType, entry.ResourceID, service.ChangeOpUpsert))
+		}
+		updates, err := buildRelationStatusUpdates(req, isSkill)
+		if err != nil {
+			return nil, err
+		}
+		updates["version"] = gorm.Expr("version + 1")
+		err = tx.Model(spec.relationModel()).
+			Where("user_id = ? AND "+spec.resourceColumn+" = ?", userID, entry.ResourceID).
+			Updates(updates).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// loadSnapshot 读出用户的全部关联，需要在事务里调用，和user对应同一个revision
+func loadSnapshot(tx *gorm.DB, user models.User) (dto.ProfileSnapshotData, error) {
+	relations := make(map[string][]dto.CommonUserRelationData, len(snapshotRelationTypes))
+	for _, relationType := range snapshotRelationTypes {
+		list, err := loadUserRelations(tx, user.ID, relationType, nil)
+		if err != nil {
+			return dto.ProfileSnapshotData{}, err
+		}
+		relations[string(relationType)] = list
+	}
+
+	return dto.ProfileSnapshotData{
+		Revision:  user.Revision,
+		Profile:   buildSnapshotProfile(user),
+		Relations: relations,
+	}, nil
+}
+
+func buildSnapshotProfile(user models.User) dto.CommonUserData {
+	return dto.CommonUserData{
+		UserID:              user.ID,
+		Username:            user.Username,
+		Group:               user.Group,
+		HeadImagePath:       dto.FileURL(user.HeadImagePath),
+		HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+		StrengthCoin:        user.StrengthCoin,
+		SelectCoin:          user.SelectCoin,
+		Version:             user.Version,
+	}
+}
+
+// loadUserRelations resourceIDs为nil时读出该类型的全部关联，否则只读这些资源的
+func loadUserRelations(tx *gorm.DB, userID uint, relationType service.UserRelationType, resourceIDs []uint) ([]dto.CommonUserRelationData, error) {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return nil, err
+	}
+	query := tx.Where("user_id = ?", userID).Order(spec.resourceColumn)
+	if resourceIDs != nil {
+		query = query.Where(spec.resourceColumn+" IN ?", resourceIDs)
+	}
+
+	switch relationType {
+	case service.UserRelationAchievement:
+		var records []models.UserAchievement
+		if err = query.Preload("Achievement").Find(&records).Error; err != nil {
+			return nil, err
+		}
+		return dto.BuildCommonUserAchievementRelationList(records), nil
+	case service.UserRelationSkill:
+		var records []models.UserSkill
+		if err = query.Preload("Skill").Find(&records).Error; err != nil {
+			return nil, err
+		}
+		return dto.BuildCommonUserSkillRelationList(records), nil
+	case service.UserRelationItem:
+		var records []models.UserItem
+		if err = query.Preload("Item").Find(&records).Error; err != nil {
+			return nil, err
+		}
+		return dto.BuildCommonUserItemRelationList(records), nil
+	default:
+		var records []models.UserCard
+		if err = query.Preload("Card").Find(&records).Error; err != nil {
+			return nil, err
+		}
+		return dto.BuildCommonUserCardRelationList(records), nil
+	}
+}
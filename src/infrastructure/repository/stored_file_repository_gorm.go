@@ -0,0 +1,209 @@
+package repository
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StoredFileRepositoryGorm struct {
+	db *gorm.DB
+}
+
+func NewStoredFileRepository(db *gorm.DB) *StoredFileRepositoryGorm {
+	return &StoredFileRepositoryGorm{db: db}
+}
+
+func (r *StoredFileRepositoryGorm) Acquire(ctx context.Context, keys []string) error {
+	return acquireStoredFiles(r.db.WithContext(ctx), keys)
+}
+
+// acquireStoredFiles 引用计数+1，没有记录时新建
+func acquireStoredFiles(tx *gorm.DB, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	now := time.Now()
+	files := make([]models.StoredFile, 0, len(keys))
+	for _, key := range keys {
+		files = append(files, models.StoredFile{FileKey: key, RefCount: 1, CreatedAt: now, UpdatedAt: now})
+	}
+	//已有记录时只加计数，垃圾回收正锁着这一行时会等它提交
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "file_key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&files).Error
+}
+
+func (r *StoredFileRepositoryGorm) Release(ctx context.Context, keys []string) error {
+	return releaseStoredFiles(r.db.WithContext(ctx), keys)
+}
+
+func (r *StoredFileRepositoryGorm) ListRefs(ctx context.Context) (map[string]service.StoredFileRef, error) {
+	var files []models.StoredFile
+	if err := r.db.WithContext(ctx).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	refs := make(map[string]service.StoredFileRef, len(files))
+	for _, file := range files {
+		refs[file.FileKey] = service.StoredFileRef{RefCount: file.RefCount, UpdatedAt: file.UpdatedAt}
+	}
+	return refs, nil
+}
+
+func (r *StoredFileRepositoryGorm) CountReferences(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+	var users []models.User
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("id", "head_image_path", "head_image_thumbnails").
+		FindInBatches(&users, 500, func(tx *gorm.DB, batch int) error {
+			for _, user := range users {
+				for _, key := range headImageFileKeys(user.HeadImagePath, user.HeadImageThumbnails) {
+					counts[key]++
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var presets []models.AvatarPreset
+	if err = r.db.WithContext(ctx).Select("id", "head_image_path", "head_image_thumbnails").Find(&presets).Error; err != nil {
+		return nil, err
+	}
+	for _, preset := range presets {
+		for _, key := range headImageFileKeys(preset.HeadImagePath, preset.HeadImageThumbnails) {
+			counts[key]++
+		}
+	}
+
+	//待审核的头像记录持有旧头像的引用
+	var records []models.ModerationRecord
+	err = r.db.WithContext(ctx).Select("id", "previous_value", "previous_head_thumbnails").
+		Where("field = ? AND status = ?", service.ModerationFieldHeadImage, service.ModerationPending).
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		for _, key := range headImageFileKeys(record.PreviousValue, record.PreviousHeadThumbnails) {
+			counts[key]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *StoredFileRepositoryGorm) SetRefCount(ctx context.Context, key string, refCount int, unchangedBefore time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.StoredFile{}).
+		Where("file_key = ? AND updated_at < ?", key, unchangedBefore).
+		Updates(map[string]interface{}{"ref_count": refCount, "updated_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+	//没更新到可能是记录不存在(旧版本上传的文件)，也可能是最近刚变过，后者插入会冲突什么都不做
+	result = r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.StoredFile{FileKey: key, RefCount: refCount, CreatedAt: now, UpdatedAt: now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *StoredFileRepositoryGorm) PurgeUnreferenced(ctx context.Context, remove func(key string) error) ([]string, error) {
+	var keys []string
+	if err := r.db.WithContext(ctx).Model(&models.StoredFile{}).Where("ref_count = 0").Pluck("file_key", &keys).Error; err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	var errs []error
+	for _, key := range keys {
+		//每个文件单独一个事务，锁的时间只覆盖删这一个对象
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var file models.StoredFile
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("file_key = ? AND ref_count = 0", key).
+				First(&file).Error
+			if err != nil {
+				return err
+			}
+			if err = remove(key); err != nil {
+				return err
+			}
+			return tx.Delete(&file).Error
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			//查出来之后又被引用了
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		purged = append(purged, key)
+	}
+	return purged, errors.Join(errs...)
+}
+
+// ReleaseUserHeadImage 给还没迁到service层的controller用，在同一个tx里删除用户之前调用
+// 待审核的头像记录会随用户级联删除，它持有的旧头像引用也在这里释放
+func ReleaseUserHeadImage(tx *gorm.DB, user *models.User) error {
+	if err := releaseStoredFiles(tx, headImageFileKeys(user.HeadImagePath, user.HeadImageThumbnails)); err != nil {
+		return err
+	}
+	var records []models.ModerationRecord
+	err := tx.Select("id", "previous_value", "previous_head_thumbnails").
+		Where("user_id = ? AND field = ? AND status = ?", user.ID, service.ModerationFieldHeadImage, service.ModerationPending).
+		Find(&records).Error
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err = releaseStoredFiles(tx, headImageFileKeys(record.PreviousValue, record.PreviousHeadThumbnails)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseStoredFiles 引用方更新的事务里调用，计数不会减到0以下；没有记录的(旧版本上传的)文件忽略，由垃圾回收处理
+func releaseStoredFiles(tx *gorm.DB, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return tx.Model(&models.StoredFile{}).
+		Where("file_key IN ? AND ref_count > 0", keys).
+		Updates(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count - 1"),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// headImageFileKeys 头像引用的存储文件，默认头像等static下的资源不在存储里
+func headImageFileKeys(headImagePath string, thumbnails models.HeadImageThumbnails) []string {
+	var keys []string
+	if strings.HasPrefix(headImagePath, config.UploadKeyPrefix) {
+		keys = append(keys, headImagePath)
+	}
+	for _, key := range thumbnails {
+		if strings.HasPrefix(key, config.UploadKeyPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func profileChange() models.UserChange {
+	return models.UserChange{Entity: service.ChangeEntityProfile, Op: service.ChangeOpUpsert}
+}
+
+func relationChange(relationType service.UserRelationType, resourceID uint, op string) models.UserChange {
+	return models.UserChange{Entity: string(relationType), ResourceID: resourceID, Op: op}
+}
+
+// recordUserChange 把用户的存档修订号+1，并按新的修订号把这次改动的实体记进变更流水
+// 必须和改动本身在同一个事务里；更新users行会锁住它，所以同一用户的修订号按提交顺序递增
+func recordUserChange(tx *gorm.DB, userID uint, changes ...models.UserChange) error {
+	return recordUsersChange(tx, []uint{userID}, changes...)
+}
+
+// maxUserChangeIDs 一条语句里IN列表的上限，MySQL一条语句最多65535个占位符
+const maxUserChangeIDs = 1000
+
+// recordUsersChange 批量发放/回收时用，每个用户各自+1并记同样的changes，ID多时分段执行
+func recordUsersChange(tx *gorm.DB, userIDs []uint, changes ...models.UserChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	for start := 0; start < len(userIDs); start += maxUserChangeIDs {
+		chunk := userIDs[start:min(start+maxUserChangeIDs, len(userIDs))]
+		if err := recordUsersChangeWhere(tx, "id IN ?", chunk, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordUsersChangeWhere 给满足条件的用户修订号+1，再用INSERT ... SELECT按新的修订号写变更流水，不把用户ID取到内存里
+func recordUsersChangeWhere(tx *gorm.DB, condition string, arg interface{}, changes []models.UserChange) error {
+	err := tx.Model(&models.User{}).
+		Where(condition, arg).
+		UpdateColumn("revision", gorm.Expr("revision + 1")).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, change := range changes {
+		err = tx.Exec("INSERT INTO user_changes (user_id, revision, entity, resource_id, op, created_at) "+
+			"SELECT id, revision, ?, ?, ?, ? FROM users WHERE "+condition,
+			change.Entity, change.ResourceID, change.Op, now, arg).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordResourceOwnersDeleted 删除资源会级联删掉所有人的关联，删之前给拥有者记删除变更
+// 拥有者可能很多，用子查询而不是先查出ID
+func recordResourceOwnersDeleted(tx *gorm.DB, relationType service.UserRelationType, spec relationSpec, resourceID uint) error {
+	owners := tx.Model(spec.relationModel()).Select("user_id").Where(spec.resourceColumn+" = ?", resourceID)
+	return recordUsersChangeWhere(tx, "id IN (?)", owners, []models.UserChange{relationChange(relationType, resourceID, service.ChangeOpDelete)})
+}
+
+// RecordProfileChange 给还没迁到service层的controller用，tx里改了用户资料后调用
+func RecordProfileChange(tx *gorm.DB, userID uint) error {
+	return recordUserChange(tx, userID, profileChange())
+}
+
+// RecordResourceDeleted 给还没迁到service层的controller用，在同一个tx里删除资源之前调用
+func RecordResourceDeleted(tx *gorm.DB, relationType service.UserRelationType, resourceID uint) error {
+	spec, err := relationSpecFor(relationType)
+	if err != nil {
+		return err
+	}
+	return recordResourceOwnersDeleted(tx, relationType, spec, resourceID)
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
 	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepositoryGorm struct {
@@ -16,9 +19,9 @@ func NewUserRepository(db *gorm.DB) *UserRepositoryGorm {
 	return &UserRepositoryGorm{db: db}
 }
 
-func (r *UserRepositoryGorm) FindByUsername(username string) (*models.User, bool, error) {
+func (r *UserRepositoryGorm) FindByUsername(ctx context.Context, username string) (*models.User, bool, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, false, nil
 	}
@@ -28,13 +31,13 @@ func (r *UserRepositoryGorm) FindByUsername(username string) (*models.User, bool
 	return &user, true, nil
 }
 
-func (r *UserRepositoryGorm) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *UserRepositoryGorm) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *UserRepositoryGorm) FindByID(userID uint) (*models.User, bool, error) {
+func (r *UserRepositoryGorm) FindByID(ctx context.Context, userID uint) (*models.User, bool, error) {
 	var user models.User
-	err := r.db.First(&user, userID).Error
+	err := r.db.WithContext(ctx).First(&user, userID).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, false, nil
 	}
@@ -44,58 +47,134 @@ func (r *UserRepositoryGorm) FindByID(userID uint) (*models.User, bool, error) {
 	return &user, true, nil
 }
 
-func (r *UserRepositoryGorm) UpdatePassword(userID uint, hashedPassword string, updatedAt time.Time) error {
-	result := r.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"password":            hashedPassword,
-			"password_updated_at": updatedAt,
-		})
-	if result.Error != nil {
-		return result.Error
+func (r *UserRepositoryGorm) FindStamp(ctx context.Context, userID uint) (service.ContentStamp, bool, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Select("id", "version", "updated_at").First(&user, userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return service.ContentStamp{}, false, nil
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if err != nil {
+		return service.ContentStamp{}, false, err
 	}
-	return nil
+	return service.ContentStamp{Version: user.Version, UpdatedAt: user.UpdatedAt}, true, nil
 }
 
-func (r *UserRepositoryGorm) UpdateUsername(userID uint, newUsername string, updatedAt time.Time) error {
-	result := r.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		Updates(map[string]interface{}{
-			"username":            newUsername,
-			"username_updated_at": updatedAt,
-		})
-	if result.Error != nil {
-		return result.Error
+func (r *UserRepositoryGorm) UpdatePassword(ctx context.Context, userID uint, hashedPassword string, updatedAt time.Time, version uint64) error {
+	return r.updateWithVersion(ctx, userID, version, map[string]interface{}{
+		"password":            hashedPassword,
+		"password_updated_at": updatedAt,
+	})
+}
+
+func (r *UserRepositoryGorm) UpdateUsername(ctx context.Context, userID uint, newUsername string, updatedAt time.Time, version uint64, moderate bool) error {
+	updates := map[string]interface{}{
+		"username":            newUsername,
+		"username_updated_at": updatedAt,
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	if !moderate {
+		return r.updateWithVersion(ctx, userID, version, updates)
 	}
-	return nil
+
+	//审核记录要写入修改前的用户名，先锁住用户行读出来
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "version", "username").
+			First(&user, userID).Error
+		if err != nil {
+			return err
+		}
+		if user.Version != version {
+			return service.ErrVersionConflict
+		}
+
+		updates["version"] = gorm.Expr("version + 1")
+		if err = tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err = submitModeration(tx, userID, service.ModerationFieldUsername, user.Username, nil, newUsername, nil); err != nil {
+			return err
+		}
+		return recordUserChange(tx, userID, profileChange())
+	})
 }
 
-func (r *UserRepositoryGorm) UpdateHeadImage(userID uint, newHeadImagePath string, updatedAt time.Time) error {
-	result := r.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		Updates(map[string]interface{}{
+// UpdateHeadImage 先锁住用户行读出旧头像，和更新在同一个事务里释放旧头像文件的引用
+func (r *UserRepositoryGorm) UpdateHeadImage(ctx context.Context, userID uint, newHeadImagePath string, thumbnails models.HeadImageThumbnails, updatedAt *time.Time, version uint64, moderate bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "version", "head_image_path", "head_image_thumbnails").
+			First(&user, userID).Error
+		if err != nil {
+			return err
+		}
+		if user.Version != version {
+			return service.ErrVersionConflict
+		}
+		if err = releaseStoredFiles(tx, headImageFileKeys(user.HeadImagePath, user.HeadImageThumbnails)); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
 			"head_image_path":       newHeadImagePath,
-			"head_image_updated_at": updatedAt,
-		})
-	if result.Error != nil {
-		return result.Error
+			"head_image_thumbnails": thumbnails,
+			"version":               gorm.Expr("version + 1"),
+		}
+		if updatedAt != nil {
+			updates["head_image_updated_at"] = *updatedAt
+		}
+		if err = tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if moderate {
+			err = submitModeration(tx, userID, service.ModerationFieldHeadImage, user.HeadImagePath, user.HeadImageThumbnails, newHeadImagePath, thumbnails)
+			if err != nil {
+				return err
+			}
+		}
+		return recordUserChange(tx, userID, profileChange())
+	})
+}
+
+func (r *UserRepositoryGorm) UpdateCoinByField(ctx context.Context, userID uint, field string, coin uint, version uint64) error {
+	return r.updateWithVersion(ctx, userID, version, map[string]interface{}{field: coin})
+}
+
+// updateWithVersion 只有版本号还是version时才更新，并把版本号+1，同时记一条profile变更
+// 没更新到行时再查一次，区分用户不存在和被别人抢先修改
+func (r *UserRepositoryGorm) updateWithVersion(ctx context.Context, userID uint, version uint64, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND version = ?", userID, version).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return conflictOrNotFound(tx.Model(&models.User{}).Where("id = ?", userID))
+		}
+		return recordUserChange(tx, userID, profileChange())
+	})
+}
+
+// conflictOrNotFound 条件更新没命中时调用，query是去掉版本号条件后的查询
+func conflictOrNotFound(query *gorm.DB) error {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
+	if count == 0 {
 		return gorm.ErrRecordNotFound
 	}
-	return nil
+	return service.ErrVersionConflict
 }
 
-func (r *UserRepositoryGorm) UpdateCoinByField(userID uint, field string, coin uint) error {
-	result := r.db.Model(&models.User{}).
+func (r *UserRepositoryGorm) IncrementTokenVersion(ctx context.Context, userID uint) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
 		Where("id = ?", userID).
-		Update(field, coin)
+		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
 	if result.Error != nil {
 		return result.Error
 	}
@@ -105,15 +184,88 @@ func (r *UserRepositoryGorm) UpdateCoinByField(userID uint, field string, coin u
 	return nil
 }
 
-func (r *UserRepositoryGorm) IncrementTokenVersion(userID uint) error {
-	result := r.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		UpdateColumn("token_version", gorm.Expr("token_version + 1"))
-	if result.Error != nil {
-		return result.Error
+func (r *UserRepositoryGorm) AdjustCoin(ctx context.Context, adjustment models.CoinAdjustment, field string) (*models.User, models.CoinAdjustment, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, adjustment.UserID).Error; err != nil {
+			return err
+		}
+
+		balance := user.StrengthCoin
+		if field == "select_coin" {
+			balance = user.SelectCoin
+		}
+		after := int64(balance) + adjustment.Delta
+		if after < 0 {
+			return service.ErrCoinAdjustUnderflow
+		}
+
+		err := tx.Model(&user).Updates(map[string]interface{}{
+			field:     uint(after),
+			"version": gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err = recordUserChange(tx, user.ID, profileChange()); err != nil {
+			return err
+		}
+		user.Version++
+		user.Revision++
+		if field == "select_coin" {
+			user.SelectCoin = uint(after)
+		} else {
+			user.StrengthCoin = uint(after)
+		}
+
+		adjustment.BalanceBefore = balance
+		adjustment.BalanceAfter = uint(after)
+		return tx.Create(&adjustment).Error
+	})
+	if err != nil {
+		return nil, models.CoinAdjustment{}, err
 	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+	return &user, adjustment, nil
+}
+
+func (r *UserRepositoryGorm) ResetProfile(ctx context.Context, userID uint, newUsername *string, newHeadImagePath *string) error {
+	updates := map[string]interface{}{}
+	//清掉修改时间，用户被重置后可以马上自己再改
+	if newUsername != nil {
+		updates["username"] = *newUsername
+		updates["username_updated_at"] = nil
 	}
-	return nil
+	if newHeadImagePath != nil {
+		updates["head_image_path"] = *newHeadImagePath
+		updates["head_image_thumbnails"] = nil
+		updates["head_image_updated_at"] = nil
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	updates["version"] = gorm.Expr("version + 1")
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if newHeadImagePath != nil {
+			var user models.User
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "head_image_path", "head_image_thumbnails").
+				First(&user, userID).Error
+			if err != nil {
+				return err
+			}
+			if err = releaseStoredFiles(tx, headImageFileKeys(user.HeadImagePath, user.HeadImageThumbnails)); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return recordUserChange(tx, userID, profileChange())
+	})
 }
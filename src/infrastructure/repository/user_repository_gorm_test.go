@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestUpdateWithVersion(t *testing.T) {
+	ctx := context.Background()
+	db := openRepositoryDB(t)
+	repo := NewUserRepository(db)
+	userID := createTestUsers(t, db, "a")[0].ID
+
+	tests := []struct {
+		name         string
+		userID       uint
+		version      uint64
+		wantErr      error
+		wantVersion  uint64
+		wantRevision uint64
+	}{
+		{"版本号一致", userID, 1, nil, 2, 1},
+		{"版本号已过期", userID, 1, service.ErrVersionConflict, 2, 1},
+		{"版本号超前", userID, 5, service.ErrVersionConflict, 2, 1},
+		{"再次用最新版本号", userID, 2, nil, 3, 2},
+		{"用户不存在", 99, 1, gorm.ErrRecordNotFound, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.UpdateCoinByField(ctx, tt.userID, "strength_coin", 10, tt.version)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			//冲突时整个事务回滚，不应该留下变更流水
+			var user models.User
+			if err = db.First(&user, userID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if user.Version != tt.wantVersion || user.Revision != tt.wantRevision {
+				t.Fatalf("版本号%d、修订号%d，期望%d、%d", user.Version, user.Revision, tt.wantVersion, tt.wantRevision)
+			}
+		})
+	}
+}
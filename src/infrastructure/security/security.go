@@ -27,14 +27,15 @@ func (h *BcryptPasswordHasher) Compare(hashedPassword, password string) error {
 
 type JWTTokenService struct {
 	secret []byte
+	ttl    time.Duration
 }
 
-func NewJWTTokenService(secret []byte) *JWTTokenService {
-	return &JWTTokenService{secret: secret}
+func NewJWTTokenService(secret []byte, ttl time.Duration) *JWTTokenService {
+	return &JWTTokenService{secret: secret, ttl: ttl}
 }
 
 func (s *JWTTokenService) GenerateToken(user models.User) (string, time.Time, error) {
-	return utils.GenerateToken(user, s.secret)
+	return utils.GenerateToken(user, s.secret, s.ttl)
 }
 
 func (s *JWTTokenService) ParseToken(tokenStr string) (jwt.MapClaims, error) {
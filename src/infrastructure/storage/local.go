@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage 存在本地目录，只适合单实例或者挂了共享卷的部署
+type LocalStorage struct {
+	dir           string
+	publicBaseURL string
+}
+
+// NewLocalStorage publicBaseURL为空时URL直接返回key，由前面的反向代理按相对路径提供文件
+func NewLocalStorage(dir, publicBaseURL string) *LocalStorage {
+	if dir == "" {
+		dir = "."
+	}
+	return &LocalStorage{dir: dir, publicBaseURL: publicBaseURL}
+}
+
+func (s *LocalStorage) Put(key string, data []byte, contentType string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	//先写临时文件再改名，读的一方不会看到写了一半的文件
+	//内容相同的上传key相同，临时文件名要每次不同，否则并发写同一个key会互相覆盖
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		//CreateTemp建的是0600，和以前WriteFile的权限保持一致，反向代理才能读
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filePath)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) Get(key string) ([]byte, error) {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, service.ErrFileNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.filePath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List prefix按key的前缀匹配，只遍历前缀所在的目录
+func (s *LocalStorage) List(prefix string) ([]service.StoredObject, error) {
+	root := filepath.Join(s.dir, filepath.FromSlash(path.Dir(prefix+"x")))
+	var objects []service.StoredObject
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, filePath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, service.StoredObject{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	if s.publicBaseURL == "" {
+		return key
+	}
+	return joinURL(s.publicBaseURL, key)
+}
+
+func (s *LocalStorage) filePath(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStorage 存在内存里的FileStorage，给测试和本地调试用，进程退出后数据就没了
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+	now     func() time.Time
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: map[string]memoryObject{}, now: time.Now}
+}
+
+func (s *MemoryStorage) Put(key string, data []byte, contentType string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[cleaned] = memoryObject{data: append([]byte(nil), data...), contentType: contentType, modTime: s.now()}
+	return nil
+}
+
+func (s *MemoryStorage) Get(key string) ([]byte, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[cleaned]
+	if !ok {
+		return nil, service.ErrFileNotFound
+	}
+	return append([]byte(nil), object.data...), nil
+}
+
+func (s *MemoryStorage) Delete(key string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, cleaned)
+	return nil
+}
+
+// List 按key排序返回，和S3的ListObjectsV2一致
+func (s *MemoryStorage) List(prefix string) ([]service.StoredObject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var objects []service.StoredObject
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, service.StoredObject{Key: key, Size: int64(len(object.data)), ModTime: object.modTime})
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// URL 直接返回key，和没配PublicBaseURL的LocalStorage一样
+func (s *MemoryStorage) URL(key string) string {
+	return key
+}
+
+// ContentType 测试里检查写入时带的Content-Type
+func (s *MemoryStorage) ContentType(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[key]
+	return object.contentType, ok
+}
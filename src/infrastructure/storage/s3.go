@@ -0,0 +1,380 @@
+package storage
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3RequestTimeout = 30 * time.Second
+	s3Service        = "s3"
+	s3Algorithm      = "AWS4-HMAC-SHA256"
+	s3UnsignedBody   = "UNSIGNED-PAYLOAD"
+	s3TimeFormat     = "20060102T150405Z"
+	s3DateFormat     = "20060102"
+	//预签名URL的签名时间按这个粒度取整，同一窗口内同一个key的URL不变，客户端缓存和ETag不会每次都失效
+	s3PresignWindow = 5 * time.Minute
+)
+
+// S3Storage S3兼容的对象存储(AWS S3、MinIO、各家云的S3接口)，只用到对象的PUT/GET/DELETE、ListObjectsV2和GET的预签名，签名按SigV4手写
+type S3Storage struct {
+	endpoint      *url.URL
+	region        string
+	bucket        string
+	accessKey     string
+	secretKey     string
+	pathStyle     bool
+	presign       bool
+	presignTTL    time.Duration
+	publicBaseURL string
+	client        *http.Client
+	now           func() time.Time
+}
+
+func NewS3Storage(settings config.StorageSettings) (*S3Storage, error) {
+	if settings.S3Endpoint == "" || settings.S3Bucket == "" {
+		return nil, errors.New("S3存储需要配置S3_ENDPOINT和S3_BUCKET")
+	}
+	if settings.S3AccessKey == "" || settings.S3SecretKey == "" {
+		return nil, errors.New("S3存储需要配置S3_ACCESS_KEY_ID和S3_SECRET_ACCESS_KEY")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(settings.S3Endpoint, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("S3_ENDPOINT格式错误:%s", settings.S3Endpoint)
+	}
+	presignTTL := settings.S3PresignTTL
+	if presignTTL <= 0 {
+		presignTTL = time.Hour
+	}
+	//SigV4预签名最长7天
+	presignTTL = min(presignTTL, 7*24*time.Hour)
+	return &S3Storage{
+		endpoint:      endpoint,
+		region:        settings.S3Region,
+		bucket:        settings.S3Bucket,
+		accessKey:     settings.S3AccessKey,
+		secretKey:     settings.S3SecretKey,
+		pathStyle:     settings.S3PathStyle,
+		presign:       settings.S3Presign,
+		presignTTL:    presignTTL,
+		publicBaseURL: settings.PublicBaseURL,
+		client:        &http.Client{Timeout: s3RequestTimeout},
+		now:           time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(key string, data []byte, contentType string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(cleaned).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	sum := sha256.Sum256(data)
+	s.signRequest(req, hex.EncodeToString(sum[:]), s.now())
+	return s.do(req, http.StatusOK)
+}
+
+func (s *S3Storage) Get(key string) ([]byte, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(cleaned).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	s.signRequest(req, hashHex(""), s.now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, service.ErrFileNotFound
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("S3 GET %s 返回%d:%s", req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// Delete S3的DELETE对不存在的对象也返回204，部分兼容实现会返回404，都当作成功
+func (s *S3Storage) Delete(key string) error {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(cleaned).String(), nil)
+	if err != nil {
+		return err
+	}
+	s.signRequest(req, hashHex(""), s.now())
+	return s.do(req, http.StatusOK, http.StatusNoContent, http.StatusNotFound)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List 用ListObjectsV2分页列出，每页最多1000个
+func (s *S3Storage) List(prefix string) ([]service.StoredObject, error) {
+	var objects []service.StoredObject
+	continuationToken := ""
+	for {
+		u := s.objectURL("")
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		u.RawQuery = canonicalQuery(query)
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		s.signRequest(req, hashHex(""), s.now())
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+			return nil, fmt.Errorf("S3 列出对象返回%d:%s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, service.StoredObject{Key: content.Key, Size: content.Size, ModTime: content.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// URL 配了PublicBaseURL(CDN或公开读的桶)时直接拼；开启预签名时返回有时效的GET地址
+func (s *S3Storage) URL(key string) string {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return key
+	}
+	if s.presign {
+		//TTL很短时窗口也跟着缩小，保证签出来的URL至少还有一半有效期
+		window := min(s3PresignWindow, s.presignTTL/2)
+		return s.presignGet(cleaned, s.now().UTC().Truncate(window))
+	}
+	if s.publicBaseURL != "" {
+		return joinURL(s.publicBaseURL, cleaned)
+	}
+	return s.objectURL(cleaned).String()
+}
+
+func (s *S3Storage) do(req *http.Request, okStatus ...int) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	for _, status := range okStatus {
+		if resp.StatusCode == status {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("S3 %s %s 返回%d:%s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// objectURL path-style为endpoint/bucket/key，否则为bucket.endpoint/key
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimRight(u.Path, "/")
+	if s.pathStyle {
+		u.Path = basePath + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u
+}
+
+// signRequest 按SigV4给请求加Authorization头，签名覆盖host和调用前req.Header里已有的全部请求头
+// User-Agent、Content-Length由Transport发送时才加，不在签名里
+func (s *S3Storage) signRequest(req *http.Request, payloadHash string, at time.Time) {
+	at = at.UTC()
+	req.Header.Set("X-Amz-Date", at.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if strings.EqualFold(name, "Authorization") {
+			continue
+		}
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(headers)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(at)
+	signature := s.signature(at, scope, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+// presignGet 生成查询参数形式签名的GET地址
+func (s *S3Storage) presignGet(key string, at time.Time) string {
+	at = at.UTC()
+	u := s.objectURL(key)
+	scope := s.scope(at)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", at.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(s.presignTTL/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(map[string]string{"host": u.Host})
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		canonicalURI(u.Path),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedBody,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(at, scope, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+func (s *S3Storage) scope(at time.Time) string {
+	return at.Format(s3DateFormat) + "/" + s.region + "/" + s3Service + "/aws4_request"
+}
+
+func (s *S3Storage) signature(at time.Time, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		at.Format(s3TimeFormat),
+		scope,
+		hashHex(canonicalRequest),
+	}, "\n")
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), at.Format(s3DateFormat))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		//值去掉首尾空白，中间连续的空白合并成一个
+		canonical.WriteString(name + ":" + strings.Join(strings.Fields(headers[name]), " ") + "\n")
+	}
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// canonicalURI S3的路径只编码一次，斜杠保留
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode SigV4要求除A-Za-z0-9-_.~以外的字节都编码成大写的%XX
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashHex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
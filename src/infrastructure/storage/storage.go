@@ -0,0 +1,37 @@
+package storage
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// New 按配置选择存储后端
+func New(settings config.StorageSettings) (service.FileStorage, error) {
+	switch settings.Backend {
+	case "", "local":
+		return NewLocalStorage(settings.LocalDir, settings.PublicBaseURL), nil
+	case "s3":
+		return NewS3Storage(settings)
+	default:
+		return nil, fmt.Errorf("不支持的存储后端:%s", settings.Backend)
+	}
+}
+
+// cleanKey key必须是不带..的相对路径，避免写到存储根目录外面或者拼出奇怪的URL
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", service.ErrInvalidFileKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", service.ErrInvalidFileKey
+	}
+	return cleaned, nil
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + key
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testFileStorage 每种存储后端都要满足的行为
+func testFileStorage(t *testing.T, s service.FileStorage) {
+	t.Helper()
+	if err := s.Put("uploads/HeadImg_a.jpg", []byte("a"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put("uploads/HeadImg_b.jpg", []byte("bb"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Put("other/c.jpg", []byte("c"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	//同一个key再写一次覆盖原内容
+	if err := s.Put("uploads/HeadImg_a.jpg", []byte("aaa"), "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	data, err := s.Get("uploads/HeadImg_a.jpg")
+	if err != nil || string(data) != "aaa" {
+		t.Fatalf("Get返回%q %v", data, err)
+	}
+	if _, err := s.Get("uploads/missing.jpg"); !errors.Is(err, service.ErrFileNotFound) {
+		t.Fatalf("不存在的key返回%v，期望ErrFileNotFound", err)
+	}
+
+	objects, err := s.List("uploads/HeadImg_")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	sizes := map[string]int64{}
+	for _, object := range objects {
+		sizes[object.Key] = object.Size
+	}
+	if len(sizes) != 2 || sizes["uploads/HeadImg_a.jpg"] != 3 || sizes["uploads/HeadImg_b.jpg"] != 2 {
+		t.Fatalf("List返回%v", sizes)
+	}
+
+	if err := s.Delete("uploads/HeadImg_a.jpg"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete("uploads/HeadImg_a.jpg"); err != nil {
+		t.Fatalf("删除不存在的key应该不报错: %v", err)
+	}
+	if _, err := s.Get("uploads/HeadImg_a.jpg"); !errors.Is(err, service.ErrFileNotFound) {
+		t.Fatalf("删除后Get返回%v", err)
+	}
+
+	for _, key := range []string{"", "/abs.jpg", "../escape.jpg", "uploads/../../escape.jpg", "uploads\\a.jpg", "uploads//a.jpg"} {
+		if err := s.Put(key, []byte("x"), ""); !errors.Is(err, service.ErrInvalidFileKey) {
+			t.Fatalf("key %q 返回%v，期望ErrInvalidFileKey", key, err)
+		}
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+	testFileStorage(t, s)
+	if contentType, _ := s.ContentType("uploads/HeadImg_b.jpg"); contentType != "image/jpeg" {
+		t.Fatalf("Content-Type为%q", contentType)
+	}
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "")
+	testFileStorage(t, s)
+
+	info, err := os.Stat(filepath.Join(dir, "uploads", "HeadImg_b.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Fatalf("文件权限为%v，期望0644", info.Mode().Perm())
+	}
+}
+
+// 内容相同的头像key相同，并发写同一个key不能互相覆盖临时文件
+func TestLocalStorageConcurrentPutSameKey(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocalStorage(dir, "")
+	data := bytes.Repeat([]byte("x"), 256<<10)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Go(func() {
+			errs <- s.Put("uploads/same.jpg", data, "image/jpeg")
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("并发Put失败: %v", err)
+		}
+	}
+
+	got, err := s.Get("uploads/same.jpg")
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("最终文件内容不完整: len=%d err=%v", len(got), err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "uploads"))
+	if len(entries) != 1 {
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Fatalf("留下了临时文件: %v", fmt.Sprint(names))
+	}
+}
+
+func TestLocalStorageURL(t *testing.T) {
+	if got := NewLocalStorage(".", "").URL("uploads/a.jpg"); got != "uploads/a.jpg" {
+		t.Fatalf("got %s", got)
+	}
+	if got := NewLocalStorage(".", "https://cdn.example.com/").URL("uploads/a.jpg"); got != "https://cdn.example.com/uploads/a.jpg" {
+		t.Fatalf("got %s", got)
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const lastModifiedContextKey = "conditional_last_modified"
+
+// bufferedResponseWriter 先把响应体攒在内存里，等handler跑完再决定返回200还是304
+type bufferedResponseWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferedResponseWriter) WriteHeaderNow() {}
+
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedResponseWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedResponseWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedResponseWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferedResponseWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// Validators 不加载响应内容就能算出的校验信息，LastModified为零值时不提供Last-Modified
+type Validators struct {
+	ETag         string
+	LastModified time.Time
+}
+
+// ValidatorFunc 在handler之前计算校验信息，返回错误时退回到按响应体计算ETag，错误由handler自己报告
+type ValidatorFunc func(c *gin.Context) (Validators, error)
+
+// ConditionalGET 给GET响应加上弱ETag和Last-Modified
+// validate不为nil时先用它算出校验信息，命中就直接返回304，不再执行handler
+// validate为nil或出错时退回到按响应体计算ETag，Last-Modified由handler通过SetLastModified提供
+// 请求带If-None-Match时只看ETag；没带时再看If-Modified-Since，命中则返回304且不带响应体
+// 只处理200响应，出错的响应原样返回
+func ConditionalGET(validate ValidatorFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		var validators Validators
+		hasValidators := false
+		if validate != nil {
+			if v, err := validate(c); err == nil {
+				validators, hasValidators = v, true
+			}
+		}
+		if hasValidators && notModified(c.Request, validators.ETag, validators.LastModified, !validators.LastModified.IsZero()) {
+			setCacheHeaders(c.Writer.Header(), validators.ETag, validators.LastModified, !validators.LastModified.IsZero())
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedResponseWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if writer.status != http.StatusOK {
+			original.WriteHeader(writer.status)
+			_, _ = original.Write(writer.body.Bytes())
+			return
+		}
+
+		etag, lastModified, hasLastModified := validators.ETag, validators.LastModified, !validators.LastModified.IsZero()
+		if !hasValidators {
+			etag = utils.WeakETag(writer.body.Bytes())
+			lastModified, hasLastModified = getLastModified(c)
+		}
+		setCacheHeaders(original.Header(), etag, lastModified, hasLastModified)
+
+		if notModified(c.Request, etag, lastModified, hasLastModified) {
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		original.WriteHeader(http.StatusOK)
+		_, _ = original.Write(writer.body.Bytes())
+	}
+}
+
+func setCacheHeaders(header http.Header, etag string, lastModified time.Time, hasLastModified bool) {
+	header.Set("ETag", etag)
+	//内容因人而异，只允许客户端私有缓存，并且每次都要回源校验
+	header.Set("Cache-Control", "private, no-cache")
+	header.Set("Vary", "Authorization")
+	if hasLastModified {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// SetLastModified handler告诉ConditionalGET这次响应内容的最后修改时间
+// 只有能保证"内容变了时间一定变"的接口才应该设置，否则只靠ETag
+func SetLastModified(c *gin.Context, t time.Time) {
+	if !t.IsZero() {
+		c.Set(lastModifiedContextKey, t)
+	}
+}
+
+func getLastModified(c *gin.Context) (time.Time, bool) {
+	val, exists := c.Get(lastModifiedContextKey)
+	if !exists {
+		return time.Time{}, false
+	}
+	t, ok := val.(time.Time)
+	return t, ok
+}
+
+func notModified(req *http.Request, etag string, lastModified time.Time, hasLastModified bool) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return utils.ETagMatches(ifNoneMatch, etag, true)
+	}
+	if !hasLastModified {
+		return false
+	}
+	ifModifiedSince := req.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	//HTTP日期只精确到秒
+	return !lastModified.Truncate(time.Second).After(since)
+}
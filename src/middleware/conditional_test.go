@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConditionalGET(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lastModified := time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC)
+	r := gin.New()
+	r.Use(ConditionalGET(nil))
+	r.GET("/profile", func(c *gin.Context) {
+		SetLastModified(c, lastModified)
+		c.JSON(http.StatusOK, gin.H{"username": "a"})
+	})
+	r.GET("/missing", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"message": "不存在"})
+	})
+
+	first := httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/profile", nil))
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("第一次请求状态%d，ETag %q，响应体%q", first.Code, etag, first.Body.String())
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		header     map[string]string
+		wantStatus int
+	}{
+		{"If-None-Match命中", "/profile", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"If-None-Match不命中", "/profile", map[string]string{"If-None-Match": `W/"stale"`}, http.StatusOK},
+		{"If-None-Match优先于If-Modified-Since", "/profile", map[string]string{
+			"If-None-Match":     `W/"stale"`,
+			"If-Modified-Since": lastModified.Add(time.Hour).Format(http.TimeFormat),
+		}, http.StatusOK},
+		{"If-Modified-Since同一秒", "/profile", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"If-Modified-Since早于修改时间", "/profile", map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		{"If-Modified-Since格式错误", "/profile", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"非200响应原样返回", "/missing", map[string]string{"If-None-Match": "*"}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Fatalf("304不应带响应体，实际为%q", w.Body.String())
+			}
+			if tt.wantStatus != http.StatusNotModified && w.Body.Len() == 0 {
+				t.Fatal("响应体为空")
+			}
+		})
+	}
+}
+
+func TestConditionalGETValidators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lastModified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	validators := Validators{ETag: `W/"stamp"`, LastModified: lastModified}
+	var handlerCalls int
+	var validateErr error
+	r := gin.New()
+	r.GET("/profile", ConditionalGET(func(c *gin.Context) (Validators, error) {
+		return validators, validateErr
+	}), func(c *gin.Context) {
+		handlerCalls++
+		c.JSON(http.StatusOK, gin.H{"username": "a"})
+	})
+
+	tests := []struct {
+		name        string
+		header      map[string]string
+		validateErr error
+		wantStatus  int
+		wantETag    string
+		wantHandler bool
+	}{
+		{"If-None-Match命中时不执行handler", map[string]string{"If-None-Match": `W/"stamp"`}, nil, http.StatusNotModified, `W/"stamp"`, false},
+		{"If-Modified-Since命中时不执行handler", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, nil, http.StatusNotModified, `W/"stamp"`, false},
+		{"不命中时用预先算出的ETag", map[string]string{"If-None-Match": `W/"old"`}, nil, http.StatusOK, `W/"stamp"`, true},
+		{"不带条件头", nil, nil, http.StatusOK, `W/"stamp"`, true},
+		{"计算出错时退回按响应体计算", map[string]string{"If-None-Match": `W/"stamp"`}, errors.New("db down"), http.StatusOK, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerCalls, validateErr = 0, tt.validateErr
+			req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if (handlerCalls > 0) != tt.wantHandler {
+				t.Fatalf("handler执行了%d次", handlerCalls)
+			}
+			etag := w.Header().Get("ETag")
+			if tt.wantETag != "" && etag != tt.wantETag {
+				t.Fatalf("ETag为%q，期望%q", etag, tt.wantETag)
+			}
+			if tt.wantETag == "" && (etag == "" || etag == validators.ETag) {
+				t.Fatalf("出错时应按响应体计算ETag，实际为%q", etag)
+			}
+			if tt.validateErr == nil && w.Header().Get("Last-Modified") != lastModified.Format(http.TimeFormat) {
+				t.Fatalf("Last-Modified为%q", w.Header().Get("Last-Modified"))
+			}
+		})
+	}
+}
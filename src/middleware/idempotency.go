@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, id uint, statusCode int, contentType string, response []byte, expiresAt time.Time) error
+	Release(ctx context.Context, id uint) error
+}
+
+// Idempotency 处理带Idempotency-Key请求头的写请求，需要挂在JWTAuth后面
+// 同一用户同一key在有效期内只会真正执行一次，重试时原样回放第一次的状态码和响应体，并带上Idempotent-Replayed: true
+// 同一个key换了请求内容返回422，第一次请求还没处理完时返回409
+// 第一次请求返回5xx时不保存结果，客户端可以用同一个key重试；不带请求头的请求不受影响
+// 请求体超过settings.MaxBodyBytes时返回413
+func Idempotency(store IdempotencyStore, settings config.IdempotencySettings, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if store == nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "幂等组件未初始化"})
+			return
+		}
+		if len(key) > config.MaxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "Idempotency-Key过长"})
+			return
+		}
+		userIDValue, exists := c.Get("user_id")
+		userID, ok := userIDValue.(uint)
+		if !exists || !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: "token用户ID参数缺失"})
+			return
+		}
+
+		//这里在handler之前把整个请求体读进内存，必须先限制大小
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, int64(settings.MaxBodyBytes)))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, dto.Response{Code: http.StatusRequestEntityTooLarge, Message: "请求体过大"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "读取请求体失败"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := &models.IdempotencyRecord{
+			UserID:         userID,
+			IdempotencyKey: key,
+			RequestHash:    requestHash(c.Request, body),
+			ExpiresAt:      time.Now().Add(settings.LockTimeout),
+		}
+		existing, err := store.Reserve(c.Request.Context(), record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.Response{Code: http.StatusInternalServerError, Message: "幂等记录保存失败：" + err.Error()})
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, record.RequestHash)
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedResponseWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		//客户端断开后ctx会被取消，但结果已经产生了，仍要写回幂等记录
+		ctx := context.WithoutCancel(c.Request.Context())
+		if writer.status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, record.ID); err != nil {
+				logger.ErrorContext(ctx, "释放幂等记录失败", "user_id", userID, "idempotency_key", key, "error", err)
+			}
+		} else {
+			err := store.Complete(ctx, record.ID, writer.status, original.Header().Get("Content-Type"), writer.body.Bytes(), time.Now().Add(settings.TTL))
+			if err != nil {
+				//结果已经产生了，保存失败也照常返回，只是这次无法回放
+				logger.ErrorContext(ctx, "保存幂等记录失败", "user_id", userID, "idempotency_key", key, "error", err)
+			}
+		}
+		original.WriteHeader(writer.status)
+		_, _ = original.Write(writer.body.Bytes())
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestHash method、带query的路径和请求体一起算，同一个key用在别的接口上也算换了请求
+// multipart表单每次重试的boundary都不一样，按解析出的字段算
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(req.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(req.URL.RequestURI()))
+	h.Write([]byte{0})
+	if digest, ok := multipartDigest(req.Header.Get("Content-Type"), body); ok {
+		h.Write(digest)
+	} else {
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// multipartDigest 对每个part的字段名、文件名和内容哈希排序后再算一次哈希，和boundary、part顺序无关
+// 不是multipart、解析失败或没有part时返回false，按原始请求体算
+func multipartDigest(contentType string, body []byte) ([]byte, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, false
+	}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return nil, false
+		}
+		parts = append(parts, part.FormName()+"\x00"+part.FileName()+"\x00"+hex.EncodeToString(content.Sum(nil)))
+	}
+	//找不到boundary时也是EOF，不能让不同的请求体都算成空表单
+	if len(parts) == 0 {
+		return nil, false
+	}
+	sort.Strings(parts)
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil), true
+}
+
+func replayIdempotent(c *gin.Context, existing *models.IdempotencyRecord, hash string) {
+	if existing.RequestHash != hash {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, dto.Response{Code: http.StatusUnprocessableEntity, Message: "Idempotency-Key已用于其他请求"})
+		return
+	}
+	if !existing.Completed {
+		c.AbortWithStatusJSON(http.StatusConflict, dto.Response{Code: http.StatusConflict, Message: "相同Idempotency-Key的请求正在处理，请稍后重试"})
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	contentType := existing.ContentType
+	if contentType == "" {
+		contentType = "application/json; charset=utf-8"
+	}
+	c.Data(existing.StatusCode, contentType, existing.Response)
+	c.Abort()
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryIdempotencyStore 按user_id+key保存记录，和数据库唯一索引的效果一样
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	nextID  uint
+	records map[uint]*models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[uint]*models.IdempotencyRecord{}}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.records {
+		if existing.UserID == record.UserID && existing.IdempotencyKey == record.IdempotencyKey {
+			copied := *existing
+			return &copied, nil
+		}
+	}
+	s.nextID++
+	record.ID = s.nextID
+	copied := *record
+	s.records[record.ID] = &copied
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, id uint, statusCode int, contentType string, response []byte, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record := s.records[id]
+	record.Completed = true
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Response = append([]byte(nil), response...)
+	record.ExpiresAt = expiresAt
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func newIdempotencyRouter(store IdempotencyStore, maxBodyBytes int, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setUser := func(c *gin.Context) { c.Set("user_id", uint(1)) }
+	settings := config.IdempotencySettings{MaxBodyBytes: maxBodyBytes}
+	r.Any("/do", setUser, Idempotency(store, settings, slog.New(slog.NewTextHandler(io.Discard, nil))), handler)
+	return r
+}
+
+func doIdempotent(r http.Handler, method, key, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/do", bytes.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(newMemoryIdempotencyStore(), 1<<10, func(c *gin.Context) {
+		calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, "call %d: %s", calls, body)
+	})
+
+	first := doIdempotent(r, http.MethodPost, "k1", "", []byte("a"))
+	second := doIdempotent(r, http.MethodPost, "k1", "", []byte("a"))
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("状态码 %d/%d，期望都是201", first.Code, second.Code)
+	}
+	if second.Body.String() != "call 1: a" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("第二次请求没有回放: %q replayed=%q", second.Body.String(), second.Header().Get("Idempotent-Replayed"))
+	}
+	if calls != 1 {
+		t.Fatalf("handler执行了%d次，期望1次", calls)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		body   string
+		status int
+		calls  int
+	}{
+		{"同一个key换了请求体", http.MethodPost, "k1", "b", http.StatusUnprocessableEntity, 1},
+		{"同一个key换了method", http.MethodPut, "k1", "a", http.StatusUnprocessableEntity, 1},
+		{"新的key", http.MethodPost, "k2", "a", http.StatusCreated, 2},
+		{"不带key不受影响", http.MethodPost, "", "a", http.StatusCreated, 3},
+		{"GET请求不受影响", http.MethodGet, "k1", "", http.StatusCreated, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doIdempotent(r, tt.method, tt.key, "", []byte(tt.body))
+			if w.Code != tt.status || calls != tt.calls {
+				t.Fatalf("状态码%d handler次数%d，期望%d/%d", w.Code, calls, tt.status, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	record := &models.IdempotencyRecord{UserID: 1, IdempotencyKey: "k", RequestHash: requestHash(httptest.NewRequest(http.MethodPost, "/do", nil), []byte("a"))}
+	if _, err := store.Reserve(context.Background(), record); err != nil {
+		t.Fatal(err)
+	}
+	r := newIdempotencyRouter(store, 1<<10, func(c *gin.Context) { c.Status(http.StatusOK) })
+	if w := doIdempotent(r, http.MethodPost, "k", "", []byte("a")); w.Code != http.StatusConflict {
+		t.Fatalf("第一次请求未完成时状态码%d，期望409", w.Code)
+	}
+}
+
+func TestIdempotencyServerErrorReleasesKey(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(newMemoryIdempotencyStore(), 1<<10, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	doIdempotent(r, http.MethodPost, "k", "", []byte("a"))
+	w := doIdempotent(r, http.MethodPost, "k", "", []byte("a"))
+	if w.Code != http.StatusOK || calls != 2 {
+		t.Fatalf("5xx后重试状态码%d handler次数%d，期望200/2", w.Code, calls)
+	}
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	calls := 0
+	r := newIdempotencyRouter(newMemoryIdempotencyStore(), 8, func(c *gin.Context) { calls++ })
+	w := doIdempotent(r, http.MethodPost, "k", "", []byte(strings.Repeat("x", 9)))
+	if w.Code != http.StatusRequestEntityTooLarge || calls != 0 {
+		t.Fatalf("超大请求体状态码%d handler次数%d，期望413/0", w.Code, calls)
+	}
+	if w := doIdempotent(r, http.MethodPost, "k2", "", []byte(strings.Repeat("x", 8))); w.Code != http.StatusOK {
+		t.Fatalf("刚好等于上限时状态码%d，期望200", w.Code)
+	}
+}
+
+func buildMultipart(t *testing.T, fields map[string]string, fileField, fileName string, file []byte) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fileField != "" {
+		part, err := writer.CreateFormFile(fileField, fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(file)
+	}
+	writer.Close()
+	return writer.FormDataContentType(), buf.Bytes()
+}
+
+func TestRequestHashMultipart(t *testing.T) {
+	hash := func(contentType string, body []byte) string {
+		req := httptest.NewRequest(http.MethodPut, "/api/profile/update/headimage", nil)
+		req.Header.Set("Content-Type", contentType)
+		return requestHash(req, body)
+	}
+	baseType, baseBody := buildMultipart(t, map[string]string{"note": "x"}, "new_head_image", "a.png", []byte("image-1"))
+	base := hash(baseType, baseBody)
+
+	tests := []struct {
+		name      string
+		fields    map[string]string
+		fileName  string
+		file      []byte
+		wantEqual bool
+	}{
+		{"重试时boundary不同", map[string]string{"note": "x"}, "a.png", []byte("image-1"), true},
+		{"文件内容不同", map[string]string{"note": "x"}, "a.png", []byte("image-2"), false},
+		{"文件名不同", map[string]string{"note": "x"}, "b.png", []byte("image-1"), false},
+		{"普通字段不同", map[string]string{"note": "y"}, "a.png", []byte("image-1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, body := buildMultipart(t, tt.fields, "new_head_image", tt.fileName, tt.file)
+			if contentType == baseType {
+				t.Fatal("两次生成的boundary相同，测不出boundary的影响")
+			}
+			if got := hash(contentType, body) == base; got != tt.wantEqual {
+				t.Fatalf("哈希相同=%v，期望%v", got, tt.wantEqual)
+			}
+		})
+	}
+
+	//解析失败时按原始请求体算
+	if hash("multipart/form-data; boundary=xyz", []byte("a")) == hash("multipart/form-data; boundary=xyz", []byte("b")) {
+		t.Fatal("解析失败时不同的请求体得到了相同的哈希")
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HTTPMetricsRecorder interface {
+	ObserveHTTPRequest(method, route string, status int, elapsed time.Duration)
+}
+
+// Metrics 按路由模板记录请求数和耗时，用模板而不是实际路径，/api/friends/requests/1和/2算同一条
+func Metrics(recorder HTTPMetricsRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			//404之类没匹配到路由的请求合在一起，防止被随便扫路径刷出大量标签
+			route = "unmatched"
+		}
+		recorder.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsToken /metrics挂在对外端口上时要求 Authorization: Bearer <token>
+func MetricsToken(token string) gin.HandlerFunc {
+	expected := []byte(token)
+	return func(c *gin.Context) {
+		provided := []byte(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if len(expected) == 0 || subtle.ConstantTimeCompare(provided, expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{Code: http.StatusUnauthorized, Message: "无权访问指标"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -4,7 +4,10 @@ import (
 	config "MuXi/2026-MuxiShooter-Backend/config"
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,7 +24,7 @@ type JWTTokenParser interface {
 }
 
 type JWTUserRepository interface {
-	FindByID(userID uint) (*models.User, bool, error)
+	FindByID(ctx context.Context, userID uint) (*models.User, bool, error)
 }
 
 func JWTAuth(tokenParser JWTTokenParser, userRepository JWTUserRepository) gin.HandlerFunc {
@@ -102,7 +105,7 @@ func JWTAuth(tokenParser JWTTokenParser, userRepository JWTUserRepository) gin.H
 			return
 		}
 
-		user, existed, err := userRepository.FindByID(userID)
+		user, existed, err := userRepository.FindByID(c.Request.Context(), userID)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, dto.Response{
 				Code:    http.StatusInternalServerError, //500
@@ -217,10 +220,11 @@ func AdminRequired() gin.HandlerFunc {
 	}
 }
 
-func Limiter() gin.HandlerFunc {
+// Limiter 按IP限制每秒请求数，limit为config.HTTPSettings.RateLimit
+func Limiter(limit int) gin.HandlerFunc {
 	rate := limiter.Rate{
 		Period: 1 * time.Second,
-		Limit:  config.NumLimter, //20
+		Limit:  int64(limit),
 	}
 	store := memory.NewStore()
 
@@ -228,14 +232,15 @@ func Limiter() gin.HandlerFunc {
 	return middleware
 }
 
-func PaginationMiddleware() gin.HandlerFunc {
+// PaginationMiddleware 默认每页数量和上限由settings决定，启动时已校验过
+func PaginationMiddleware(settings config.PaginationSettings) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var pagination models.Pagination
 
 		if err := c.ShouldBindQuery(&pagination); err != nil {
 			pagination = models.Pagination{
 				Page:     config.DefaultPage,
-				PageSize: config.DefaultPageSize,
+				PageSize: settings.DefaultPageSize,
 			}
 		}
 
@@ -243,14 +248,40 @@ func PaginationMiddleware() gin.HandlerFunc {
 			pagination.Page = config.DefaultPage
 		}
 		if pagination.PageSize <= 0 {
-			pagination.PageSize = config.DefaultPageSize
+			pagination.PageSize = settings.DefaultPageSize
 		}
-		if pagination.PageSize > config.MaxPageSize {
-			pagination.PageSize = config.MaxPageSize
+		if pagination.PageSize > settings.MaxPageSize {
+			pagination.PageSize = settings.MaxPageSize
 		}
 
 		pagination.Limit = pagination.PageSize
 		pagination.Offset = (pagination.Page - 1) * pagination.PageSize
+		pagination.WithTotal = true
+
+		//带了cursor参数就走游标模式，cursor为空表示第一页
+		pagination.CursorScope = cursorScope(c)
+		if rawCursor, exists := c.GetQuery("cursor"); exists {
+			pagination.UseCursor = true
+			pagination.WithTotal = false
+			pagination.Page = config.DefaultPage
+			pagination.Offset = 0
+			if rawCursor != "" {
+				cursor, err := utils.DecodeCursor(rawCursor, pagination.CursorScope)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: err.Error()})
+					return
+				}
+				pagination.After = &cursor
+			}
+		}
+		if withTotal := c.Query("with_total"); withTotal != "" {
+			parsed, err := strconv.ParseBool(withTotal)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{Code: http.StatusBadRequest, Message: "with_total参数格式错误"})
+				return
+			}
+			pagination.WithTotal = parsed
+		}
 
 		c.Set("pagination", pagination)
 
@@ -258,18 +289,33 @@ func PaginationMiddleware() gin.HandlerFunc {
 	}
 }
 
-func GetPagination(c *gin.Context) models.Pagination {
+// cursorScope 用实际请求路径区分列表，路径里的ID不同就是不同的列表；同一路由下type不同的也分开
+func cursorScope(c *gin.Context) models.CursorScope {
+	scope := models.CursorScope{List: c.Request.URL.Path}
+	if listType := c.Query("type"); listType != "" {
+		scope.List += "?type=" + listType
+	}
+	userIDValue, _ := c.Get("user_id")
+	if userID, ok := userIDValue.(uint); ok {
+		scope.UserID = userID
+	}
+	return scope
+}
+
+// GetPagination 读PaginationMiddleware解析好的分页参数，路由没挂中间件时按settings的默认每页数量返回第一页
+func GetPagination(c *gin.Context, settings config.PaginationSettings) models.Pagination {
 	if val, exists := c.Get("pagination"); exists {
 		if p, ok := val.(models.Pagination); ok {
 			return p
 		}
 	}
 
-	//否则返回一个安全的默认值
+	pageSize := settings.DefaultPageSize
 	return models.Pagination{
-		Page:     config.DefaultPage,
-		PageSize: config.DefaultPageSize,
-		Limit:    config.DefaultPageSize,
-		Offset:   0,
+		Page:      config.DefaultPage,
+		PageSize:  pageSize,
+		Limit:     pageSize,
+		Offset:    0,
+		WithTotal: true,
 	}
 }
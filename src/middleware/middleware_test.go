@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newPaginationRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	setUser := func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID == "1" {
+			c.Set("user_id", uint(1))
+		} else if userID == "2" {
+			c.Set("user_id", uint(2))
+		}
+	}
+	settings := config.DefaultSettings().Pagination
+	r.GET("/api/users/:id/relations", setUser, PaginationMiddleware(settings), func(c *gin.Context) {
+		pagination := GetPagination(c, settings)
+		c.JSON(http.StatusOK, gin.H{"scope": pagination.CursorScope, "after": pagination.After != nil})
+	})
+	return r
+}
+
+func TestPaginationCursorScope(t *testing.T) {
+	r := newPaginationRouter()
+	scope := models.CursorScope{List: "/api/users/5/relations?type=skills", UserID: 1}
+	cursor := utils.EncodeCursor(&models.PageCursor{Sort: "id", ID: 10, Scope: scope})
+
+	tests := []struct {
+		name     string
+		path     string
+		typ      string
+		user     string
+		wantCode int
+	}{
+		{"同一列表同一用户", "/api/users/5/relations", "skills", "1", http.StatusOK},
+		{"换了用户", "/api/users/5/relations", "skills", "2", http.StatusBadRequest},
+		{"没登录", "/api/users/5/relations", "skills", "", http.StatusBadRequest},
+		{"路径参数不同", "/api/users/6/relations", "skills", "1", http.StatusBadRequest},
+		{"type不同", "/api/users/5/relations", "items", "1", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{"cursor": {cursor}, "type": {tt.typ}}
+			req := httptest.NewRequest(http.MethodGet, tt.path+"?"+query.Encode(), nil)
+			req.Header.Set("X-Test-User", tt.user)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body.String(), tt.wantCode)
+			}
+		})
+	}
+}
+
+func newRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/json-error", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+	})
+	r.GET("/json-empty-object", func(c *gin.Context) {
+		c.Data(http.StatusNotFound, "application/json; charset=utf-8", []byte("{}"))
+	})
+	r.GET("/json-array", func(c *gin.Context) {
+		c.Data(http.StatusBadRequest, "application/json; charset=utf-8", []byte(`[1,2]`))
+	})
+	r.GET("/text-error", func(c *gin.Context) {
+		c.String(http.StatusForbidden, "forbidden")
+	})
+	r.GET("/abort", func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+	r.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"code": 200})
+	})
+	return r
+}
+
+func TestRequestIDErrorBody(t *testing.T) {
+	r := newRequestIDRouter()
+	const requestID = "req-1"
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		{"4xx的JSON带上request_id", "/json-error", http.StatusBadRequest, `{"code":400,"message":"参数错误","request_id":"req-1"}`},
+		{"空JSON对象", "/json-empty-object", http.StatusNotFound, `{"request_id":"req-1"}`},
+		{"JSON数组原样返回", "/json-array", http.StatusBadRequest, `[1,2]`},
+		{"非JSON原样返回", "/text-error", http.StatusForbidden, "forbidden"},
+		{"AbortWithStatus没有响应体", "/abort", http.StatusUnauthorized, ""},
+		{"成功响应不加", "/ok", http.StatusOK, `{"code":200}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(RequestIDHeader, requestID)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("got %d, want %d", rec.Code, tt.wantCode)
+			}
+			if rec.Body.String() != tt.wantBody {
+				t.Fatalf("响应体为%s，期望%s", rec.Body.String(), tt.wantBody)
+			}
+			if got := rec.Header().Get(RequestIDHeader); got != requestID {
+				t.Fatalf("响应头的请求ID为%q，期望%q", got, requestID)
+			}
+		})
+	}
+}
+
+func TestRequestIDHeaderValidation(t *testing.T) {
+	r := newRequestIDRouter()
+
+	tests := []struct {
+		name     string
+		incoming string
+		wantKeep bool
+	}{
+		{"合法的请求ID沿用", "gw-123_abc.def:9", true},
+		{"没带请求ID", "", false},
+		{"带换行", "abc\nINFO fake", false},
+		{"带空格", "abc def", false},
+		{"超过长度上限", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"正好到长度上限", strings.Repeat("a", maxRequestIDLength), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ok", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			got := rec.Header().Get(RequestIDHeader)
+			if tt.wantKeep {
+				if got != tt.incoming {
+					t.Fatalf("请求ID为%q，期望沿用%q", got, tt.incoming)
+				}
+				return
+			}
+			if got == tt.incoming || !validRequestID(got) {
+				t.Fatalf("请求ID为%q，期望重新生成", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/logging"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	requestIDContextKey = "request_id"
+	//客户端/网关传来的请求ID超过这个长度就不用，重新生成
+	maxRequestIDLength = 128
+)
+
+// errorResponseWriter 状态码>=400时先攒住响应体，handler跑完后把request_id加进JSON响应
+// 其他响应直接透传，事件流不受影响
+type errorResponseWriter struct {
+	gin.ResponseWriter
+	status    int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *errorResponseWriter) WriteHeader(code int) {
+	w.status = code
+	if code >= http.StatusBadRequest {
+		w.buffering = true
+		return
+	}
+	w.buffering = false
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *errorResponseWriter) WriteHeaderNow() {
+	if !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *errorResponseWriter) Write(data []byte) (int, error) {
+	if w.buffering {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorResponseWriter) WriteString(s string) (int, error) {
+	if w.buffering {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorResponseWriter) Status() int {
+	if w.buffering {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *errorResponseWriter) Size() int {
+	if w.buffering {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *errorResponseWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Unwrap 让http.ResponseController能拿到底层连接，事件流要靠它取消读写超时
+func (w *errorResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// RequestID 沿用请求头里的X-Request-ID(网关传下来的)，没有或不合法时生成一个
+// 请求ID写进响应头、请求的context(日志用)和gin上下文，出错的JSON响应体里也会带上request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		original := c.Writer
+		writer := &errorResponseWriter{ResponseWriter: original}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		if !writer.buffering {
+			return
+		}
+		body := writer.body.Bytes()
+		if strings.HasPrefix(original.Header().Get("Content-Type"), "application/json") {
+			body = appendRequestID(body, requestID)
+		}
+		original.WriteHeader(writer.status)
+		if len(body) > 0 {
+			_, _ = original.Write(body)
+		} else {
+			original.WriteHeaderNow()
+		}
+	}
+}
+
+// GetRequestID 没经过RequestID中间件时返回空字符串
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+// validRequestID 只接受字母数字和-_.:，防止日志注入
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// appendRequestID 在JSON对象末尾追加request_id，保持原有字段顺序；不是对象或已经有request_id时原样返回
+func appendRequestID(body []byte, requestID string) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' || bytes.Contains(trimmed, []byte(`"request_id"`)) {
+		return body
+	}
+	value, _ := json.Marshal(requestID)
+	result := make([]byte, 0, len(trimmed)+len(value)+16)
+	result = append(result, trimmed[:len(trimmed)-1]...)
+	if len(bytes.TrimSpace(trimmed[1:len(trimmed)-1])) > 0 {
+		result = append(result, ',')
+	}
+	result = append(result, `"request_id":`...)
+	result = append(result, value...)
+	return append(result, '}')
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/migration"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+const migrateUsage = `用法: main migrate <命令>
+  up            执行全部未执行的迁移
+  down [n]      回滚最近n个迁移，默认1
+  status        查看迁移状态
+  to <version>  迁移到指定版本(0表示全部回滚)`
+
+var errMigrateUsage = errors.New(migrateUsage)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	logger := config.NewLogger(settings.Log)
+	db, err := config.OpenDB(settings, logger)
+	if err != nil {
+		return err
+	}
+	migrator, err := migration.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	var done []migration.Migration
+	switch args[0] {
+	case "up":
+		done, err = migrator.Up()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("回滚步数不合法: %s", args[1])
+			}
+		}
+		done, err = migrator.Down(steps)
+	case "to":
+		if len(args) < 2 {
+			return errMigrateUsage
+		}
+		target, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("目标版本不合法: %s", args[1])
+		}
+		done, err = migrator.To(uint(target))
+	case "status":
+		return printMigrationStatus(migrator)
+	default:
+		return errMigrateUsage
+	}
+
+	for _, m := range done {
+		fmt.Printf("%04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		fmt.Println("没有需要执行的迁移")
+	}
+	return nil
+}
+
+func printMigrationStatus(migrator *migration.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED_AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", "-"
+		if status.Applied {
+			state = "applied"
+			appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	return w.Flush()
+}
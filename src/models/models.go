@@ -2,6 +2,9 @@ package models
 
 //	@externalDocs	description="GORM Documentation" url="https://gorm.io/docs/"
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -12,26 +15,96 @@ type Pagination struct {
 	Limit int `json:"limit"`
 	//实际offset
 	Offset int `json:"offset"`
+	//游标模式，带了cursor参数(可以为空)时开启，不再使用page/offset
+	UseCursor bool `form:"-" json:"use_cursor"`
+	//上一页返回的游标，游标模式的第一页为nil
+	After *PageCursor `form:"-" json:"-"`
+	//是否统计总数，页码模式默认统计，游标模式默认不统计
+	WithTotal bool `form:"-" json:"with_total"`
+	//游标所属的列表和用户，签名时带上
+	CursorScope CursorScope `form:"-" json:"-"`
+}
+
+// CursorScope 游标只在发出它的列表和用户下有效
+// List一般是路由加上区分列表的查询参数，UserID为0表示未登录
+type CursorScope struct {
+	List   string
+	UserID uint
+}
+
+// PageCursor 游标里保存的内容，Sort为排序列，防止拿别的列表的游标来查
+// Scope不写进游标，只参与签名
+type PageCursor struct {
+	Sort  string      `json:"s"`
+	Value string      `json:"v,omitempty"`
+	ID    uint        `json:"id"`
+	Scope CursorScope `json:"-"`
+}
+
+// PageInfo 分页查询除列表外的结果
+type PageInfo struct {
+	//未统计时为nil
+	Total *int64
+	//没有下一页时为nil
+	Next *PageCursor
+}
+
+// HeadImageThumbnails 缩略图边长(字符串)到文件路径，库里存JSON
+type HeadImageThumbnails map[string]string
+
+func (t HeadImageThumbnails) Value() (driver.Value, error) {
+	if len(t) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+func (t *HeadImageThumbnails) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("head_image_thumbnails字段类型错误")
+	}
+	if len(data) == 0 {
+		*t = nil
+		return nil
+	}
+	return json.Unmarshal(data, t)
 }
 
 type User struct {
-	ID                 uint              `gorm:"primaryKey;autoIncrement" json:"user_id"`
-	TokenVersion       uint64            `gorm:"default:1" json:"token_version"`
-	Username           string            `gorm:"unique;not null" json:"username"`
-	UsernameUpdatedAt  *time.Time        `json:"username_updated_at"`
-	Password           string            `gorm:"not null" json:"-"`
-	PasswordUpdatedAt  *time.Time        `json:"password_updated_at"`
-	Group              string            `gorm:"default:'user'" json:"group"`
-	HeadImagePath      string            `json:"head_image_path"`
-	HeadImageUpdatedAt *time.Time        `json:"head_image_updated_at"`
-	StrengthCoin       uint              `gorm:"default:0" json:"strength_coin"`
-	SelectCoin         uint              `gorm:"default:0" json:"select_coin"`
-	UserAchievements   []UserAchievement `gorm:"foreignKey:UserID" json:"-"`
-	UserSkills         []UserSkill       `gorm:"foreignKey:UserID" json:"-"`
-	UserCards          []UserCard        `gorm:"foreignKey:UserID" json:"-"`
-	UserItems          []UserItem        `gorm:"foreignKey:UserID" json:"-"`
-	CreatedAt          time.Time         `json:"created_at"`
-	UpdatedAt          time.Time         `json:"updated_at"`
+	ID                 uint       `gorm:"primaryKey;autoIncrement" json:"user_id"`
+	TokenVersion       uint64     `gorm:"default:1" json:"token_version"`
+	Username           string     `gorm:"unique;not null" json:"username"`
+	UsernameUpdatedAt  *time.Time `json:"username_updated_at"`
+	Password           string     `gorm:"not null" json:"-"`
+	PasswordUpdatedAt  *time.Time `json:"password_updated_at"`
+	Group              string     `gorm:"default:'user'" json:"group"`
+	HeadImagePath      string     `json:"head_image_path"`
+	HeadImageUpdatedAt *time.Time `json:"head_image_updated_at"`
+	StrengthCoin       uint       `gorm:"default:0" json:"strength_coin"`
+	SelectCoin         uint       `gorm:"default:0" json:"select_coin"`
+	//乐观锁版本号，每次修改+1
+	Version uint64 `gorm:"not null;default:1" json:"version"`
+	//存档修订号，用户资料或任何关联(成就/技能/物品/卡牌)变化都+1，客户端同步存档时用来检测冲突
+	Revision         uint64            `gorm:"not null;default:0" json:"revision"`
+	UserAchievements []UserAchievement `gorm:"foreignKey:UserID" json:"-"`
+	UserSkills       []UserSkill       `gorm:"foreignKey:UserID" json:"-"`
+	UserCards        []UserCard        `gorm:"foreignKey:UserID" json:"-"`
+	UserItems        []UserItem        `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+
+	//头像缩略图，默认头像和旧版本上传的头像没有
+	HeadImageThumbnails HeadImageThumbnails `gorm:"type:text" json:"head_image_thumbnails"`
 }
 
 type Achievement struct {
@@ -49,6 +122,7 @@ type UserAchievement struct {
 	CompleteAt    *time.Time `json:"complete_at,omitempty"`
 	Claimed       bool       `gorm:"default:false" json:"claimed"`
 	ClaimedAt     *time.Time `json:"claimed_at,omitempty"`
+	Version       uint64     `gorm:"not null;default:1" json:"version"`
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 
@@ -75,6 +149,7 @@ type UserSkill struct {
 	SkillGrade uint       `gorm:"default:0" json:"skill_grade"`
 	Claimed    bool       `gorm:"default:false" json:"claimed"`
 	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	Version    uint64     `gorm:"not null;default:1" json:"version"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 
@@ -98,6 +173,7 @@ type UserCard struct {
 	CompleteAt *time.Time `json:"complete_at,omitempty"`
 	Claimed    bool       `gorm:"default:false" json:"claimed"`
 	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	Version    uint64     `gorm:"not null;default:1" json:"version"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 
@@ -121,9 +197,145 @@ type UserItem struct {
 	CompleteAt *time.Time `json:"complete_at,omitempty"`
 	Claimed    bool       `gorm:"default:false" json:"claimed"`
 	ClaimedAt  *time.Time `json:"claimed_at,omitempty"`
+	Version    uint64     `gorm:"not null;default:1" json:"version"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
 
 	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Item Item `gorm:"foreignKey:ItemID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
+
+// CoinAdjustment 管理员调整金币的流水
+type CoinAdjustment struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint      `gorm:"not null;index" json:"user_id"`
+	AdminID       uint      `gorm:"not null" json:"admin_id"`
+	CoinType      string    `gorm:"not null" json:"coin_type"`
+	Delta         int64     `gorm:"not null" json:"delta"`
+	BalanceBefore uint      `gorm:"not null" json:"balance_before"`
+	BalanceAfter  uint      `gorm:"not null" json:"balance_after"`
+	Reason        string    `gorm:"not null" json:"reason"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// IdempotencyRecord 带Idempotency-Key的写请求的处理记录，同一用户同一key重试时直接回放Response
+type IdempotencyRecord struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID         uint   `gorm:"not null;uniqueIndex:uk_idempotency_user_key" json:"user_id"`
+	IdempotencyKey string `gorm:"size:128;not null;uniqueIndex:uk_idempotency_user_key" json:"idempotency_key"`
+	//method+path+body的sha256，同一个key换了请求内容时拒绝
+	RequestHash string `gorm:"size:64;not null" json:"request_hash"`
+	//为false表示第一次请求还在处理中
+	Completed   bool   `gorm:"not null;default:false" json:"completed"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `gorm:"size:128" json:"content_type"`
+	Response    []byte `json:"-"`
+	//处理中时是占位的过期时间，处理完后是回放的过期时间
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// UserChange 用户存档的变更流水，每次修改都按修改后的revision记下改动的实体，供客户端增量同步
+type UserChange struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID   uint   `gorm:"not null;index:idx_user_changes_user_revision,priority:1" json:"user_id"`
+	Revision uint64 `gorm:"not null;index:idx_user_changes_user_revision,priority:2" json:"revision"`
+	//profile或关联类型(achievements/skills/items/cards)
+	Entity string `gorm:"size:32;not null" json:"entity"`
+	//关联的资源ID，profile为0
+	ResourceID uint `gorm:"not null;default:0" json:"resource_id"`
+	//upsert或delete
+	Op        string    `gorm:"size:16;not null" json:"op"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// FriendRequest 好友申请，同一方向的一对用户只保留一条，被拒绝/取消后重新申请时复用
+type FriendRequest struct {
+	ID         uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	FromUserID uint `gorm:"not null;uniqueIndex:uk_friend_requests_pair,priority:1" json:"from_user_id"`
+	ToUserID   uint `gorm:"not null;uniqueIndex:uk_friend_requests_pair,priority:2;index:idx_friend_requests_to_status,priority:1" json:"to_user_id"`
+	//pending/accepted/declined/cancelled
+	Status string `gorm:"size:16;not null;index:idx_friend_requests_to_status,priority:2" json:"status"`
+	//附言
+	Message     string     `gorm:"size:100;not null;default:''" json:"message"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	FromUser User `gorm:"foreignKey:FromUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	ToUser   User `gorm:"foreignKey:ToUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// Friendship 好友关系，双向各存一行，查某人的好友只需要按user_id查
+type Friendship struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	FriendID  uint      `gorm:"primaryKey;index" json:"friend_id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User   User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	Friend User `gorm:"foreignKey:FriendID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// UserBlock 拉黑，单向；被拉黑的人不能再发好友申请，也搜不到拉黑他的人
+type UserBlock struct {
+	UserID        uint      `gorm:"primaryKey" json:"user_id"`
+	BlockedUserID uint      `gorm:"primaryKey;index" json:"blocked_user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	User        User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	BlockedUser User `gorm:"foreignKey:BlockedUserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
+
+// StoredFile 存储里按内容寻址的上传文件的引用计数，计数为0的文件由垃圾回收删除
+type StoredFile struct {
+	FileKey   string    `gorm:"primaryKey;size:255" json:"file_key"`
+	RefCount  int       `gorm:"not null;default:0;index" json:"ref_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AvatarPreset 管理员维护的预设头像，用户选用时不上传新文件，也不受头像修改间隔限制
+// 图片和上传的头像一样按内容存在存储里，预设本身持有一份引用
+type AvatarPreset struct {
+	ID                  uint                `gorm:"primaryKey;autoIncrement" json:"preset_id"`
+	Name                string              `gorm:"size:50;unique;not null" json:"name"`
+	HeadImagePath       string              `gorm:"size:255;not null" json:"head_image_path"`
+	HeadImageThumbnails HeadImageThumbnails `gorm:"type:text" json:"head_image_thumbnails"`
+	//解锁条件，为空表示所有人可用；achievements/cards表示要先完成UnlockResourceID对应的成就/卡牌
+	UnlockType       string    `gorm:"size:16;not null;default:''" json:"unlock_type"`
+	UnlockResourceID *uint     `json:"unlock_resource_id"`
+	SortOrder        int       `gorm:"not null;default:0" json:"sort_order"`
+	Enabled          bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ModerationRecord 开启审核时用户名/头像的修改记录，修改先生效，管理员驳回时改回PreviousValue
+// 每个用户每个字段最多一条pending记录，审核前再次修改时只更新NewValue，PreviousValue保持最后一次通过审核的值
+// 头像的pending记录持有PreviousValue对应文件的一份引用，驳回时转给用户，通过时释放
+type ModerationRecord struct {
+	ID     uint `gorm:"primaryKey;autoIncrement" json:"moderation_id"`
+	UserID uint `gorm:"not null;index:idx_moderation_records_user_field,priority:1" json:"user_id"`
+	//username或head_image
+	Field string `gorm:"size:16;not null;index:idx_moderation_records_user_field,priority:2" json:"field"`
+	//pending/approved/rejected
+	Status                 string              `gorm:"size:16;not null;index:idx_moderation_records_status" json:"status"`
+	PreviousValue          string              `gorm:"size:255;not null" json:"previous_value"`
+	PreviousHeadThumbnails HeadImageThumbnails `gorm:"type:text" json:"previous_head_thumbnails"`
+	NewValue               string              `gorm:"size:255;not null" json:"new_value"`
+	NewHeadThumbnails      HeadImageThumbnails `gorm:"type:text" json:"new_head_thumbnails"`
+	//驳回原因，会推送给用户
+	Reason     string     `gorm:"size:255;not null;default:''" json:"reason"`
+	ReviewerID *uint      `json:"reviewer_id"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+}
@@ -9,6 +9,11 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+type HealthHTTPHandler interface {
+	Livez(c *gin.Context)
+	Readyz(c *gin.Context)
+}
+
 type AuthHTTPHandler interface {
 	Register(c *gin.Context)
 	Login(c *gin.Context)
@@ -25,63 +30,225 @@ type ProfileHTTPHandler interface {
 	DeleteSelfRelationByType(c *gin.Context)
 	GetSelfProfile(c *gin.Context)
 	GetSelfRelationsByType(c *gin.Context)
+	SelfProfileValidators(c *gin.Context) (middleware.Validators, error)
+	SelfRelationsValidators(c *gin.Context) (middleware.Validators, error)
+}
+
+type SnapshotHTTPHandler interface {
+	GetSnapshot(c *gin.Context)
+	SaveSnapshot(c *gin.Context)
+	GetChanges(c *gin.Context)
+}
+
+type FriendHTTPHandler interface {
+	ListFriends(c *gin.Context)
+	RemoveFriend(c *gin.Context)
+	SendRequest(c *gin.Context)
+	ListPendingRequests(c *gin.Context)
+	AcceptRequest(c *gin.Context)
+	DeclineRequest(c *gin.Context)
+	CancelRequest(c *gin.Context)
+	ListBlocked(c *gin.Context)
+	Block(c *gin.Context)
+	Unblock(c *gin.Context)
+	SearchUsers(c *gin.Context)
+}
+
+type EventHTTPHandler interface {
+	Stream(c *gin.Context)
+}
+
+type ContentHTTPHandler interface {
+	ExportContent(c *gin.Context)
+	ImportContent(c *gin.Context)
+}
+
+type CatalogueHTTPHandler interface {
+	GetCatalogue(c *gin.Context)
+}
+
+type AdminResourceHTTPHandler interface {
+	BulkCreateResources(c *gin.Context)
+	BulkUpdateResources(c *gin.Context)
+	BulkDeleteResources(c *gin.Context)
+	BulkGrantResource(c *gin.Context)
+	BulkRevokeResource(c *gin.Context)
+}
+
+type AdminUserHTTPHandler interface {
+	CreateUserRelation(c *gin.Context)
+	UpdateUserRelation(c *gin.Context)
+	DeleteUserRelation(c *gin.Context)
+	AdjustCoin(c *gin.Context)
+	ResetUserProfile(c *gin.Context)
+}
+
+type AvatarPresetHTTPHandler interface {
+	ListPresets(c *gin.Context)
+	SelectPreset(c *gin.Context)
+	ListPresetsForAdmin(c *gin.Context)
+	CreatePreset(c *gin.Context)
+	UpdatePreset(c *gin.Context)
+	DeletePreset(c *gin.Context)
+}
+
+type ModerationHTTPHandler interface {
+	ListModeration(c *gin.Context)
+	ApproveModeration(c *gin.Context)
+	RejectModeration(c *gin.Context)
+}
+
+// Handlers 注册路由用到的全部handler，都不能为nil
+type Handlers struct {
+	Health        HealthHTTPHandler
+	Auth          AuthHTTPHandler
+	Profile       ProfileHTTPHandler
+	Snapshot      SnapshotHTTPHandler
+	Friend        FriendHTTPHandler
+	Event         EventHTTPHandler
+	Content       ContentHTTPHandler
+	Catalogue     CatalogueHTTPHandler
+	AdminResource AdminResourceHTTPHandler
+	AdminUser     AdminUserHTTPHandler
+	AvatarPreset  AvatarPresetHTTPHandler
+	Moderation    ModerationHTTPHandler
+}
+
+// Middlewares 需要在路由里按组挂载的中间件，都不能为nil
+type Middlewares struct {
+	JWTAuth     gin.HandlerFunc
+	Idempotency gin.HandlerFunc
+	Pagination  gin.HandlerFunc
 }
 
-func RegisterRoutes(r *gin.Engine, authHandler AuthHTTPHandler, profileHandler ProfileHTTPHandler, jwtAuthMiddleware gin.HandlerFunc) {
+func RegisterRoutes(r *gin.Engine, handlers Handlers, middlewares Middlewares) {
+	//health保留给旧的探测配置，只表示进程还活着，新的探测用livez/readyz
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, dto.Response{
 			Code:    http.StatusOK, //200
 			Message: "I'm OK.",
 		})
 	})
-	if authHandler == nil {
+	if handlers.Health == nil {
+		panic("health handler is nil")
+	}
+	r.GET("/livez", handlers.Health.Livez)
+	r.GET("/readyz", handlers.Health.Readyz)
+	if handlers.Auth == nil {
 		panic("auth handler is nil")
 	}
-	if profileHandler == nil {
+	if handlers.Profile == nil {
 		panic("profile handler is nil")
 	}
-	if jwtAuthMiddleware == nil {
+	if handlers.Snapshot == nil {
+		panic("snapshot handler is nil")
+	}
+	if handlers.Friend == nil {
+		panic("friend handler is nil")
+	}
+	if handlers.Event == nil {
+		panic("event handler is nil")
+	}
+	if handlers.Content == nil {
+		panic("content handler is nil")
+	}
+	if handlers.Catalogue == nil {
+		panic("catalogue handler is nil")
+	}
+	if handlers.AdminResource == nil {
+		panic("admin resource handler is nil")
+	}
+	if handlers.AdminUser == nil {
+		panic("admin user handler is nil")
+	}
+	if handlers.AvatarPreset == nil {
+		panic("avatar preset handler is nil")
+	}
+	if handlers.Moderation == nil {
+		panic("moderation handler is nil")
+	}
+	if middlewares.JWTAuth == nil {
 		panic("jwt auth middleware is nil")
 	}
+	if middlewares.Idempotency == nil {
+		panic("idempotency middleware is nil")
+	}
+	if middlewares.Pagination == nil {
+		panic("pagination middleware is nil")
+	}
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/register", handlers.Auth.Register)
+			auth.POST("/login", handlers.Auth.Login)
 		}
 
 		authGroup := api.Group("/")
-		authGroup.Use(jwtAuthMiddleware)
+		//幂等中间件要拿到user_id，必须在JWTAuth后面
+		authGroup.Use(middlewares.JWTAuth, middlewares.Idempotency)
 		{
 			profile := authGroup.Group("/profile")
 			{
 				update := profile.Group("/update")
 				{
-					update.PUT("/password", profileHandler.UpdatePassword)
-					update.PUT("/username", profileHandler.UpdateUsername)
-					update.PUT("/headimage", profileHandler.UpdateHeadImage)
-					update.PUT("/coin", profileHandler.UpdateCoinByType)
-					update.PUT("/relations", profileHandler.UpdateSelfRelationByType)
+					update.PUT("/password", handlers.Profile.UpdatePassword)
+					update.PUT("/username", handlers.Profile.UpdateUsername)
+					update.PUT("/headimage", handlers.Profile.UpdateHeadImage)
+					update.PUT("/headimage/preset", handlers.AvatarPreset.SelectPreset)
+					update.PUT("/coin", handlers.Profile.UpdateCoinByType)
+					update.PUT("/relations", handlers.Profile.UpdateSelfRelationByType)
 				}
+				profile.GET("/snapshot", middleware.ConditionalGET(nil), handlers.Snapshot.GetSnapshot)
+				profile.PUT("/snapshot", handlers.Snapshot.SaveSnapshot)
+				profile.GET("/changes", handlers.Snapshot.GetChanges)
+				profile.GET("/avatars", handlers.AvatarPreset.ListPresets)
+				profile.GET("/users/search", middlewares.Pagination, handlers.Friend.SearchUsers)
+
+				friends := profile.Group("/friends")
+				{
+					friends.GET("", middlewares.Pagination, handlers.Friend.ListFriends)
+					friends.DELETE("", handlers.Friend.RemoveFriend)
+					friends.GET("/requests", middlewares.Pagination, handlers.Friend.ListPendingRequests)
+					friends.POST("/requests", handlers.Friend.SendRequest)
+					friends.POST("/requests/:id/accept", handlers.Friend.AcceptRequest)
+					friends.POST("/requests/:id/decline", handlers.Friend.DeclineRequest)
+					friends.DELETE("/requests/:id", handlers.Friend.CancelRequest)
+				}
+				blocks := profile.Group("/blocks")
+				{
+					blocks.GET("", middlewares.Pagination, handlers.Friend.ListBlocked)
+					blocks.POST("", handlers.Friend.Block)
+					blocks.DELETE("", handlers.Friend.Unblock)
+				}
+
 				operation := profile.Group("/operation")
 				{
-					operation.GET("/logout", profileHandler.Logout)
-					operation.POST("/relations", profileHandler.CreateSelfRelationByType)
-					operation.DELETE("/relations", profileHandler.DeleteSelfRelationByType)
+					operation.GET("/logout", handlers.Profile.Logout)
+					operation.POST("/relations", handlers.Profile.CreateSelfRelationByType)
+					operation.DELETE("/relations", handlers.Profile.DeleteSelfRelationByType)
 				}
+				//这两个接口能在查询前算出ETag/Last-Modified，命中时不用加载内容
 				get := profile.Group("/get")
 				{
-					get.GET("/self", profileHandler.GetSelfProfile)
+					get.GET("/self", middleware.ConditionalGET(handlers.Profile.SelfProfileValidators), handlers.Profile.GetSelfProfile)
 
 					paginatedGet := get.Group("/")
-					paginatedGet.Use(middleware.PaginationMiddleware())
+					paginatedGet.Use(middlewares.Pagination)
 					{
-						paginatedGet.GET("/relations", profileHandler.GetSelfRelationsByType)
+						paginatedGet.GET("/relations", middleware.ConditionalGET(handlers.Profile.SelfRelationsValidators), handlers.Profile.GetSelfRelationsByType)
 					}
 				}
 			}
 
+			authGroup.GET("/events", handlers.Event.Stream)
+
+			catalogue := authGroup.Group("/catalogue")
+			catalogue.Use(middleware.ConditionalGET(nil))
+			{
+				catalogue.GET("/:type", handlers.Catalogue.GetCatalogue)
+			}
+
 			adminGroup := authGroup.Group("/admin")
 			adminGroup.Use(middleware.AdminRequired())
 			{
@@ -90,22 +257,42 @@ func RegisterRoutes(r *gin.Engine, authHandler AuthHTTPHandler, profileHandler P
 					operationGroup.DELETE("/deleteuser", controller.DeleteUserByAdmin)
 					operationGroup.POST("/resources", controller.CreateResourceByTypeForAdmin)
 					operationGroup.DELETE("/resources", controller.DeleteResourceByTypeForAdmin)
+					operationGroup.POST("/content", handlers.Content.ImportContent)
+					operationGroup.POST("/resources/bulk", handlers.AdminResource.BulkCreateResources)
+					operationGroup.DELETE("/resources/bulk", handlers.AdminResource.BulkDeleteResources)
+					operationGroup.POST("/user-relations/bulk", handlers.AdminResource.BulkGrantResource)
+					operationGroup.DELETE("/user-relations/bulk", handlers.AdminResource.BulkRevokeResource)
+					operationGroup.POST("/user-relations", handlers.AdminUser.CreateUserRelation)
+					operationGroup.DELETE("/user-relations", handlers.AdminUser.DeleteUserRelation)
+					operationGroup.POST("/avatar-presets", handlers.AvatarPreset.CreatePreset)
+					operationGroup.DELETE("/avatar-presets", handlers.AvatarPreset.DeletePreset)
+					operationGroup.POST("/moderation/approve", handlers.Moderation.ApproveModeration)
+					operationGroup.POST("/moderation/reject", handlers.Moderation.RejectModeration)
 				}
 
 				updateGroup := adminGroup.Group("/update")
 				{
 					updateGroup.PUT("/usergroup", controller.UpdateUserGroupByAdmin)
 					updateGroup.PUT("/resources", controller.UpdateResourceByTypeForAdmin)
+					updateGroup.PUT("/resources/bulk", handlers.AdminResource.BulkUpdateResources)
+					updateGroup.PUT("/user-relations", handlers.AdminUser.UpdateUserRelation)
+					updateGroup.PUT("/coin", handlers.AdminUser.AdjustCoin)
+					updateGroup.PUT("/user-profile", handlers.AdminUser.ResetUserProfile)
+					updateGroup.PUT("/avatar-presets", handlers.AvatarPreset.UpdatePreset)
 				}
 
 				getGroup := adminGroup.Group("/get")
 				{
+					getGroup.GET("/content", handlers.Content.ExportContent)
+					getGroup.GET("/avatar-presets", handlers.AvatarPreset.ListPresetsForAdmin)
+
 					paginatedGroup := getGroup.Group("/")
-					paginatedGroup.Use(middleware.PaginationMiddleware())
+					paginatedGroup.Use(middlewares.Pagination)
 					{
 						paginatedGroup.GET("/getusers", controller.GetUsers)
 						paginatedGroup.GET("/resources", controller.GetResourcesByTypeForAdmin)
 						paginatedGroup.GET("/user-relations", controller.GetUserRelationsByTypeForAdmin)
+						paginatedGroup.GET("/moderation", handlers.Moderation.ListModeration)
 					}
 				}
 			}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/controller"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/handler"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/metrics"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/realtime"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/repository"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/security"
+	"MuXi/2026-MuxiShooter-Backend/infrastructure/storage"
+	"MuXi/2026-MuxiShooter-Backend/middleware"
+	routes "MuXi/2026-MuxiShooter-Backend/routes"
+	"MuXi/2026-MuxiShooter-Backend/service"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+func runServe(args []string) error {
+	if len(args) > 0 {
+		return errors.New("serve 不接受额外参数")
+	}
+
+	appState, err := bootstrapApp()
+	if err != nil {
+		return err
+	}
+	logger := appState.Logger
+	defer func() {
+		if err := appState.Close(); err != nil {
+			logger.Error("关闭数据库连接失败", "error", err)
+		}
+	}()
+
+	fileStorage, err := storage.New(appState.Settings.Storage)
+	if err != nil {
+		return fmt.Errorf("文件存储初始化失败: %w", err)
+	}
+	dto.SetFileURLResolver(fileStorage.URL)
+
+	controller.SetDB(appState.DB)
+	controller.SetJWTSecret(appState.JWTSecret)
+	controller.SetTokenTTL(appState.Settings.Auth.TokenTTL)
+	controller.SetPaginationSettings(appState.Settings.Pagination)
+	utils.SetCursorSecret(appState.CursorSecret)
+	if err := controller.ValidateDependencies(); err != nil {
+		return fmt.Errorf("controller依赖初始化失败: %w", err)
+	}
+
+	//请求ID要最先设置，后面的访问日志和panic日志才能带上
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(logger), middleware.Recovery(logger))
+
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     appState.Settings.HTTP.CORSAllowOrigins,                                                                               // 允许的请求源
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},                                                                   // 允许的请求方法
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Idempotency-Key", "If-Match", "If-None-Match"}, // 允许的请求头
+		AllowCredentials: true,
+		MaxAge:           1 * time.Hour,
+	}))
+	gameMetrics, metricsServer, err := setupMetrics(r, appState)
+	if err != nil {
+		return err
+	}
+	// r.Use(middleware.Limiter(appState.Settings.HTTP.RateLimit))
+	// r.Use(func(c *gin.Context) {
+	// 	c.Next()
+	// 	if c.Writer.Status() == http.StatusTooManyRequests {
+	// 		c.AbortWithStatusJSON(http.StatusTooManyRequests, dto.Response{
+	// 			Code:    http.StatusTooManyRequests,
+	// 			Message: "请求过于频繁，请稍后重试(1s)",
+	// 		})
+	// 	}
+	// })
+	//这边限流器直接扔给Caddy了，go这边不需要非常精细的限流
+	//也不涉及权限组的限流
+	//r.Use(gzip.Gzip(gzip.DefaultCompression))
+	//使用gzip传输
+	//这里用Caddy的gzip压缩就ok了
+
+	// r.Static("/uploads", "./uploads")
+	// r.Static("/static", "./static")
+	//gin的Static是Gin框架中用来提供静态文件服务的功能，就像在餐厅里设置一个自助区
+	//让顾客可以自己取用饮料和小食，而不需要每次都找服务员点单。
+	//同样,Caddy能干这活
+
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	bannedWords, err := appState.Settings.Moderation.LoadBannedWords()
+	if err != nil {
+		return err
+	}
+	moderationOptions := service.ModerationOptions{
+		Enabled:        appState.Settings.Moderation.Enabled,
+		UsernameFilter: service.NewUsernameFilter(bannedWords),
+	}
+
+	userRepository := repository.NewUserRepository(appState.DB)
+	relationRepository := repository.NewRelationRepository(appState.DB)
+	passwordHasher := security.NewBcryptPasswordHasher()
+	tokenService := security.NewJWTTokenService(appState.JWTSecret, appState.Settings.Auth.TokenTTL)
+	authService := service.NewAuthService(userRepository, passwordHasher, tokenService, config.DefaultHeadImagePath, moderationOptions.UsernameFilter, gameMetrics, logger)
+	authHandler := handler.NewAuthHandler(authService)
+	eventHub := realtime.NewHub()
+	controller.SetEventPublisher(eventHub)
+	eventHandler := handler.NewEventHandler(eventHub)
+	storedFileRepository := repository.NewStoredFileRepository(appState.DB)
+	profileService := service.NewProfileService(userRepository, relationRepository, passwordHasher, service.ProfileServiceOptions{
+		FileStorage:          fileStorage,
+		StoredFileRepository: storedFileRepository,
+		EventPublisher:       eventHub,
+		Moderation:           moderationOptions,
+		Limits: service.ProfileLimits{
+			PasswordUpdateInterval:  appState.Settings.Profile.PasswordUpdateInterval,
+			UsernameUpdateInterval:  appState.Settings.Profile.UsernameUpdateInterval,
+			HeadImageUpdateInterval: appState.Settings.Profile.HeadImageUpdateInterval,
+		},
+		Metrics: gameMetrics,
+	}, logger)
+	imageOptions := appState.Settings.HeadImage.ProcessOptions()
+	profileHandler := handler.NewProfileHandler(profileService, logger, imageOptions, appState.Settings.Pagination)
+	snapshotService := service.NewSnapshotService(repository.NewSnapshotRepository(appState.DB), appState.Settings.Sync.MaxChangeFeedEntities, gameMetrics, logger)
+	snapshotHandler := handler.NewSnapshotHandler(snapshotService)
+	friendService := service.NewFriendService(repository.NewFriendRepository(appState.DB), appState.Settings.Friend.MaxFriends, logger)
+	friendHandler := handler.NewFriendHandler(friendService, appState.Settings.Pagination)
+	contentService := service.NewContentService(repository.NewContentRepository(appState.DB), logger)
+	contentHandler := handler.NewContentHandler(contentService)
+	catalogueService := service.NewCatalogueService(repository.NewCatalogueRepository(appState.DB))
+	catalogueHandler := handler.NewCatalogueHandler(catalogueService)
+	adminResourceRepository := repository.NewAdminResourceRepository(appState.DB, appState.Settings.Bulk.GrantBatchSize)
+	adminResourceService := service.NewAdminResourceService(adminResourceRepository, eventHub, appState.Settings.Bulk.MaxRows, logger)
+	adminResourceHandler := handler.NewAdminResourceHandler(adminResourceService)
+	adminUserService := service.NewAdminUserService(userRepository, relationRepository, eventHub, gameMetrics, logger)
+	adminUserHandler := handler.NewAdminUserHandler(adminUserService, logger)
+	avatarPresetService := service.NewAvatarPresetService(repository.NewAvatarPresetRepository(appState.DB), userRepository, storedFileRepository, fileStorage, logger)
+	avatarPresetHandler := handler.NewAvatarPresetHandler(avatarPresetService, logger, imageOptions)
+	moderationService := service.NewModerationService(repository.NewModerationRepository(appState.DB), eventHub, logger)
+	moderationHandler := handler.NewModerationHandler(moderationService, logger, appState.Settings.Pagination)
+	healthHandler := handler.NewHealthHandler(service.NewHealthService(repository.NewHealthRepository(appState.DB), fileStorage, config.ReadinessCheckTimeout, config.ReadinessStorageCacheTTL))
+	jwtAuthMiddleware := middleware.JWTAuth(tokenService, userRepository)
+	idempotencyRepository := repository.NewIdempotencyRepository(appState.DB)
+	idempotencyMiddleware := middleware.Idempotency(idempotencyRepository, appState.Settings.Idempotency, logger)
+	//后台任务在服务器关闭后停止，只在两轮之间检查workerCtx
+	//每一轮用不会被取消的context，正在执行的一轮会做完，数据库在等它们结束后才关闭
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	defer func() {
+		stopWorkers()
+		workers.Wait()
+	}()
+	workers.Go(func() { purgeExpiredIdempotencyRecords(workerCtx, idempotencyRepository, logger) })
+	fileGCService := service.NewFileGCService(storedFileRepository, fileStorage, logger)
+	workers.Go(func() { collectFileGarbage(workerCtx, fileGCService, appState.Settings.Storage, logger) })
+
+	routes.RegisterRoutes(r, routes.Handlers{
+		Health:        healthHandler,
+		Auth:          authHandler,
+		Profile:       profileHandler,
+		Snapshot:      snapshotHandler,
+		Friend:        friendHandler,
+		Event:         eventHandler,
+		Content:       contentHandler,
+		Catalogue:     catalogueHandler,
+		AdminResource: adminResourceHandler,
+		AdminUser:     adminUserHandler,
+		AvatarPreset:  avatarPresetHandler,
+		Moderation:    moderationHandler,
+	}, routes.Middlewares{
+		JWTAuth:     jwtAuthMiddleware,
+		Idempotency: idempotencyMiddleware,
+		Pagination:  middleware.PaginationMiddleware(appState.Settings.Pagination),
+	})
+
+	// test.TestReferenceTableWithDB(appState.DB)
+	// test.CleanTestData(appState.DB)
+
+	httpSettings := appState.Settings.HTTP
+	srv := &http.Server{
+		Addr:              httpSettings.Addr,
+		Handler:           r,
+		ReadTimeout:       httpSettings.ReadTimeout,
+		ReadHeaderTimeout: httpSettings.ReadHeaderTimeout,
+		WriteTimeout:      httpSettings.WriteTimeout,
+		IdleTimeout:       httpSettings.IdleTimeout,
+	}
+	//事件流是长连接，不先断开的话Shutdown会一直等到超时
+	srv.RegisterOnShutdown(eventHub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	logger.Info("服务器启动", "addr", httpSettings.Addr)
+	if metricsServer != nil {
+		go func() {
+			serveErr <- metricsServer.ListenAndServe()
+		}()
+		logger.Info("指标服务启动", "addr", metricsServer.Addr)
+	}
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("服务器启动失败: %w", err)
+	case <-ctx.Done():
+	}
+	//再收到一次信号就按默认行为直接退出
+	stop()
+
+	logger.Info("收到退出信号，等待处理中的请求完成", "timeout", httpSettings.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), httpSettings.ShutdownTimeout)
+	defer cancel()
+	if metricsServer != nil {
+		//抓取请求很快，和主服务器共用一个超时
+		defer func() {
+			if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+				logger.Error("关闭指标服务失败", "error", err)
+			}
+		}()
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("服务器关闭超时，未完成的请求已被中断: %w", err)
+	}
+	logger.Info("服务器已关闭")
+	return nil
+}
+
+// setupMetrics 指标关闭时返回nil，业务代码按不记录处理
+// metrics.addr不为空时返回单独监听的服务器，由调用方启动和关闭；为空时把/metrics挂在主端口上并要求token
+func setupMetrics(r *gin.Engine, appState *config.AppState) (service.GameMetrics, *http.Server, error) {
+	settings := appState.Settings.Metrics
+	if !settings.Enabled {
+		return nil, nil, nil
+	}
+
+	promMetrics := metrics.New()
+	if err := promMetrics.InstrumentDB(appState.DB, appState.Settings.DBName); err != nil {
+		return nil, nil, fmt.Errorf("数据库指标初始化失败: %w", err)
+	}
+	r.Use(middleware.Metrics(promMetrics))
+
+	if settings.Addr == "" {
+		r.GET("/metrics", middleware.MetricsToken(settings.Token), gin.WrapH(promMetrics.Handler()))
+		return promMetrics, nil, nil
+	}
+	metricsServer := &http.Server{
+		Addr:              settings.Addr,
+		Handler:           promMetrics.Handler(),
+		ReadHeaderTimeout: appState.Settings.HTTP.ReadHeaderTimeout,
+	}
+	return promMetrics, metricsServer, nil
+}
+
+// purgeExpiredIdempotencyRecords 定期清掉过期的幂等记录，过期的key在下次使用时也会被删，这里只是防止表一直变大
+func purgeExpiredIdempotencyRecords(ctx context.Context, idempotencyRepository *repository.IdempotencyRepositoryGorm, logger *slog.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		deleted, err := idempotencyRepository.DeleteExpired(context.WithoutCancel(ctx), time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "清理过期幂等记录失败", "error", err)
+			continue
+		}
+		if deleted > 0 {
+			logger.InfoContext(ctx, "已清理过期幂等记录", "deleted", deleted)
+		}
+	}
+}
+
+func collectFileGarbage(ctx context.Context, fileGCService *service.FileGCService, settings config.StorageSettings, logger *slog.Logger) {
+	ticker := time.NewTicker(settings.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := fileGCService.Collect(context.WithoutCancel(ctx), false, settings.GCGracePeriod)
+		if err != nil {
+			logger.ErrorContext(ctx, "上传文件垃圾回收失败", "error", err)
+			continue
+		}
+		if len(report.RepairedRefs) > 0 || len(report.Purged) > 0 || len(report.Orphans) > 0 || report.Failed > 0 {
+			logger.InfoContext(ctx, "上传文件垃圾回收完成", "repaired", len(report.RepairedRefs), "purged", len(report.Purged), "orphans", len(report.Orphans), "failed", report.Failed)
+		}
+	}
+}
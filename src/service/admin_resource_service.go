@@ -0,0 +1,163 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrBulkTooManyRows          = errors.New("单次批量操作的行数超过上限")
+	ErrBulkInvalidRow           = errors.New("批量请求中存在不合法的行")
+	ErrBulkUserSelectorRequired = errors.New("需要指定user_ids或筛选条件，作用于全部用户时需设置all_users=true")
+	ErrBulkRegisteredRange      = errors.New("registered_after不能晚于registered_before")
+	ErrPrqSkillNotFound         = errors.New("前置技能不存在")
+	ErrPrqSkillCycle            = errors.New("前置技能不能是自己或形成循环")
+)
+
+const (
+	BulkRowSucceeded = "succeeded"
+	BulkRowFailed    = "failed"
+	BulkRowSkipped   = "skipped"
+)
+
+// AdminResourceRepository 的批量创建/更新/删除都在一个事务里执行，每一行单独一个savepoint
+// atomic为true时只要有一行失败就整体回滚
+// 发放/回收也在一个事务里，按配置的批大小分批写入，atomic时处理完全部用户后有失败行就整体回滚
+type AdminResourceRepository interface {
+	BulkCreateResources(ctx context.Context, resourceType UserRelationType, rows []dto.CommonResourceCreateRequest, atomic bool) (dto.BulkOperationResult, error)
+	BulkUpdateResources(ctx context.Context, resourceType UserRelationType, rows []dto.CommonResourceUpdateRequest, atomic bool) (dto.BulkOperationResult, error)
+	BulkDeleteResources(ctx context.Context, resourceType UserRelationType, ids []uint, atomic bool) (dto.BulkOperationResult, error)
+	BulkGrantResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error)
+	BulkRevokeResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error)
+}
+
+type AdminResourceService struct {
+	adminResourceRepository AdminResourceRepository
+	eventPublisher          EventPublisher
+	maxRows                 int
+	logger                  *slog.Logger
+}
+
+// NewAdminResourceService maxRows为批量创建/更新/删除一次最多的行数
+func NewAdminResourceService(adminResourceRepository AdminResourceRepository, eventPublisher EventPublisher, maxRows int, logger *slog.Logger) *AdminResourceService {
+	return &AdminResourceService{
+		adminResourceRepository: adminResourceRepository,
+		eventPublisher:          eventPublisherOrNoop(eventPublisher),
+		maxRows:                 maxRows,
+		logger:                  logger,
+	}
+}
+
+func (s *AdminResourceService) BulkCreateResources(ctx context.Context, resourceType UserRelationType, rows []dto.CommonResourceCreateRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if len(rows) > s.maxRows {
+		return dto.BulkOperationResult{}, fmt.Errorf("%w:最多%d条", ErrBulkTooManyRows, s.maxRows)
+	}
+	//格式问题直接拒绝整个请求，和单个创建时binding校验失败返回400保持一致
+	for i, row := range rows {
+		if err := validateBulkResourceName(row.Name); err != nil {
+			return dto.BulkOperationResult{}, fmt.Errorf("%w:第%d行%v", ErrBulkInvalidRow, i, err)
+		}
+	}
+	result, err := s.adminResourceRepository.BulkCreateResources(ctx, resourceType, rows, atomic)
+	s.logBulkResult(ctx, "批量创建资源", resourceType, result, err)
+	return result, err
+}
+
+func (s *AdminResourceService) BulkUpdateResources(ctx context.Context, resourceType UserRelationType, rows []dto.CommonResourceUpdateRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if len(rows) > s.maxRows {
+		return dto.BulkOperationResult{}, fmt.Errorf("%w:最多%d条", ErrBulkTooManyRows, s.maxRows)
+	}
+	for i, row := range rows {
+		if row.ID == 0 {
+			return dto.BulkOperationResult{}, fmt.Errorf("%w:第%d行缺少id", ErrBulkInvalidRow, i)
+		}
+		if row.Name != nil {
+			if err := validateBulkResourceName(*row.Name); err != nil {
+				return dto.BulkOperationResult{}, fmt.Errorf("%w:第%d行%v", ErrBulkInvalidRow, i, err)
+			}
+		}
+	}
+	result, err := s.adminResourceRepository.BulkUpdateResources(ctx, resourceType, rows, atomic)
+	s.logBulkResult(ctx, "批量更新资源", resourceType, result, err)
+	return result, err
+}
+
+func (s *AdminResourceService) BulkDeleteResources(ctx context.Context, resourceType UserRelationType, ids []uint, atomic bool) (dto.BulkOperationResult, error) {
+	if len(ids) > s.maxRows {
+		return dto.BulkOperationResult{}, fmt.Errorf("%w:最多%d条", ErrBulkTooManyRows, s.maxRows)
+	}
+	result, err := s.adminResourceRepository.BulkDeleteResources(ctx, resourceType, ids, atomic)
+	s.logBulkResult(ctx, "批量删除资源", resourceType, result, err)
+	return result, err
+}
+
+func (s *AdminResourceService) BulkGrantResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if err := validateBulkUserSelector(req); err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	result, err := s.adminResourceRepository.BulkGrantResource(ctx, relationType, req, atomic)
+	s.logBulkResult(ctx, "批量发放资源", relationType, result, err)
+	s.publishBulkRelationEvents(EventRelationCreated, relationType, req.ResourceID, result)
+	return result, err
+}
+
+func (s *AdminResourceService) BulkRevokeResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	if err := validateBulkUserSelector(req); err != nil {
+		return dto.BulkOperationResult{}, err
+	}
+	result, err := s.adminResourceRepository.BulkRevokeResource(ctx, relationType, req, atomic)
+	s.logBulkResult(ctx, "批量回收资源", relationType, result, err)
+	s.publishBulkRelationEvents(EventRelationDeleted, relationType, req.ResourceID, result)
+	return result, err
+}
+
+// logBulkResult 成功和失败都记下来，失败时带上错误
+func (s *AdminResourceService) logBulkResult(ctx context.Context, operation string, resourceType UserRelationType, result dto.BulkOperationResult, err error) {
+	attrs := []any{"type", resourceType, "total", result.Total, "succeeded", result.Succeeded, "failed", result.Failed,
+		"skipped", result.Skipped, "batches", result.Batches, "rolled_back", result.RolledBack}
+	if err != nil {
+		s.logger.ErrorContext(ctx, operation+"失败", append(attrs, "error", err)...)
+		return
+	}
+	s.logger.InfoContext(ctx, operation, attrs...)
+}
+
+// publishBulkRelationEvents 给发放/回收成功的每个用户推一条事件，行里的ID是用户ID
+// 出错时也会调用，只要没有整体回滚，返回的成功行都已经提交
+// 批量发放不带关联详情，客户端需要时通过增量同步拉取
+func (s *AdminResourceService) publishBulkRelationEvents(eventType string, relationType UserRelationType, resourceID uint, result dto.BulkOperationResult) {
+	if result.RolledBack {
+		return
+	}
+	for _, row := range result.Rows {
+		if row.Status == BulkRowSucceeded {
+			s.eventPublisher.Publish(row.ID, relationEvent(eventType, relationType, resourceID, nil))
+		}
+	}
+}
+
+func validateBulkResourceName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return ErrContentNameRequired
+	}
+	if utf8.RuneCountInString(name) > maxContentNameLen {
+		return ErrContentNameTooLong
+	}
+	return nil
+}
+
+func validateBulkUserSelector(req dto.AdminBulkRelationRequest) error {
+	hasFilter := req.RegisteredBefore != nil || req.RegisteredAfter != nil || req.Group != ""
+	if len(req.UserIDs) == 0 && !hasFilter && !req.AllUsers {
+		return ErrBulkUserSelectorRequired
+	}
+	if req.RegisteredBefore != nil && req.RegisteredAfter != nil && req.RegisteredAfter.After(*req.RegisteredBefore) {
+		return ErrBulkRegisteredRange
+	}
+	return nil
+}
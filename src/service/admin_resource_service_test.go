@@ -0,0 +1,89 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type fakeAdminResourceRepository struct {
+	AdminResourceRepository
+	result dto.BulkOperationResult
+	err    error
+}
+
+func (r *fakeAdminResourceRepository) BulkGrantResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	return r.result, r.err
+}
+
+func (r *fakeAdminResourceRepository) BulkRevokeResource(ctx context.Context, relationType UserRelationType, req dto.AdminBulkRelationRequest, atomic bool) (dto.BulkOperationResult, error) {
+	return r.result, r.err
+}
+
+type recordingPublisher struct {
+	userIDs []uint
+	types   []string
+}
+
+func (p *recordingPublisher) Publish(userID uint, event dto.PushEvent) {
+	p.userIDs = append(p.userIDs, userID)
+	p.types = append(p.types, event.Type)
+}
+
+func TestBulkRelationEvents(t *testing.T) {
+	rows := []dto.BulkRowResult{
+		{Index: 0, ID: 1, Status: BulkRowSucceeded},
+		{Index: 1, ID: 2, Status: BulkRowSkipped},
+		{Index: 2, ID: 3, Status: BulkRowFailed},
+		{Index: 3, ID: 4, Status: BulkRowSucceeded},
+	}
+	dbErr := errors.New("db down")
+
+	tests := []struct {
+		name        string
+		result      dto.BulkOperationResult
+		err         error
+		wantUserIDs []uint
+	}{
+		{"成功行都推送", dto.BulkOperationResult{Rows: rows}, nil, []uint{1, 4}},
+		{"整体回滚不推送", dto.BulkOperationResult{RolledBack: true, Rows: rows}, nil, nil},
+		{"出错但已提交的行仍推送", dto.BulkOperationResult{Rows: rows[:1]}, dbErr, []uint{1}},
+		{"出错且没有提交任何行", dto.BulkOperationResult{}, dbErr, nil},
+	}
+	operations := []struct {
+		eventType string
+		run       func(s *AdminResourceService) (dto.BulkOperationResult, error)
+	}{
+		{EventRelationCreated, func(s *AdminResourceService) (dto.BulkOperationResult, error) {
+			return s.BulkGrantResource(context.Background(), UserRelationItem, dto.AdminBulkRelationRequest{ResourceID: 1, AllUsers: true}, false)
+		}},
+		{EventRelationDeleted, func(s *AdminResourceService) (dto.BulkOperationResult, error) {
+			return s.BulkRevokeResource(context.Background(), UserRelationItem, dto.AdminBulkRelationRequest{ResourceID: 1, AllUsers: true}, false)
+		}},
+	}
+	for _, op := range operations {
+		for _, tt := range tests {
+			t.Run(op.eventType+"/"+tt.name, func(t *testing.T) {
+				publisher := &recordingPublisher{}
+				repo := &fakeAdminResourceRepository{result: tt.result, err: tt.err}
+				s := NewAdminResourceService(repo, publisher, 100, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+				if _, err := op.run(s); err != tt.err {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				if !reflect.DeepEqual(publisher.userIDs, tt.wantUserIDs) {
+					t.Fatalf("推送给了%v，期望%v", publisher.userIDs, tt.wantUserIDs)
+				}
+				for _, eventType := range publisher.types {
+					if eventType != op.eventType {
+						t.Fatalf("事件类型为%s，期望%s", eventType, op.eventType)
+					}
+				}
+			})
+		}
+	}
+}
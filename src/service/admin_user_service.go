@@ -0,0 +1,202 @@
+package service
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
+	"errors"
+	"log/slog"
+)
+
+var (
+	ErrCoinAdjustUnderflow = errors.New("调整后金币不能为负数")
+	ErrNothingToReset      = errors.New("reset_username和reset_head_image至少需要一个为true")
+	ErrUsernameTaken       = errors.New("用户名已被占用")
+)
+
+type AdminUserRepository interface {
+	FindByID(ctx context.Context, userID uint) (*models.User, bool, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, bool, error)
+	// AdjustCoin 在事务里锁住用户行完成增减并写流水，余额不足时返回ErrCoinAdjustUnderflow
+	AdjustCoin(ctx context.Context, adjustment models.CoinAdjustment, field string) (*models.User, models.CoinAdjustment, error)
+	// ResetProfile 重置用户名/头像并清掉对应的修改时间，nil表示不修改；重置头像时释放旧头像文件的引用
+	ResetProfile(ctx context.Context, userID uint, newUsername *string, newHeadImagePath *string) error
+}
+
+type AdminUserService struct {
+	userRepository     AdminUserRepository
+	relationRepository ProfileRelationRepository
+	eventPublisher     EventPublisher
+	metrics            GameMetrics
+	logger             *slog.Logger
+}
+
+// NewAdminUserService metrics可以传nil，此时不记录指标
+func NewAdminUserService(userRepository AdminUserRepository, relationRepository ProfileRelationRepository, eventPublisher EventPublisher, metrics GameMetrics, logger *slog.Logger) *AdminUserService {
+	return &AdminUserService{
+		userRepository:     userRepository,
+		relationRepository: relationRepository,
+		eventPublisher:     eventPublisherOrNoop(eventPublisher),
+		metrics:            gameMetricsOrNoop(metrics),
+		logger:             logger,
+	}
+}
+
+func (s *AdminUserService) CreateUserRelation(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) (dto.CommonUserRelationData, error) {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return dto.CommonUserRelationData{}, err
+	}
+	data, err := s.relationRepository.CreateUserRelation(ctx, userID, relationType, resourceID)
+	if err != nil {
+		return data, err
+	}
+	s.eventPublisher.Publish(userID, relationEvent(EventRelationCreated, relationType, resourceID, &data))
+	return data, nil
+}
+
+func (s *AdminUserService) UpdateUserRelation(ctx context.Context, relationType UserRelationType, req dto.AdminUserRelationUpdateRequest) (dto.CommonUserRelationData, error) {
+	if err := s.ensureUserExists(ctx, req.UserID); err != nil {
+		return dto.CommonUserRelationData{}, err
+	}
+	data, err := s.relationRepository.UpdateUserRelation(ctx, req.UserID, relationType, dto.UserRelationUpdateRequest{
+		ResourceID: req.ResourceID,
+		IsComplete: req.IsComplete,
+		Claimed:    req.Claimed,
+		SkillGrade: req.SkillGrade,
+		Version:    req.Version,
+		IfMatch:    req.IfMatch,
+	})
+	if err != nil {
+		return data, err
+	}
+	s.eventPublisher.Publish(req.UserID, relationEvent(EventRelationUpdated, relationType, req.ResourceID, &data))
+	return data, nil
+}
+
+func (s *AdminUserService) DeleteUserRelation(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) error {
+	if err := s.ensureUserExists(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.relationRepository.DeleteUserRelation(ctx, userID, relationType, resourceID); err != nil {
+		return err
+	}
+	s.eventPublisher.Publish(userID, relationEvent(EventRelationDeleted, relationType, resourceID, nil))
+	return nil
+}
+
+func (s *AdminUserService) AdjustCoin(ctx context.Context, adminID uint, req dto.AdminAdjustCoinRequest) (dto.AdminCoinAdjustData, error) {
+	field, err := coinFieldByType(req.CoinType)
+	if err != nil {
+		return dto.AdminCoinAdjustData{}, err
+	}
+	if err = s.ensureUserExists(ctx, req.UserID); err != nil {
+		return dto.AdminCoinAdjustData{}, err
+	}
+
+	user, adjustment, err := s.userRepository.AdjustCoin(ctx, models.CoinAdjustment{
+		UserID:   req.UserID,
+		AdminID:  adminID,
+		CoinType: req.CoinType,
+		Delta:    req.Delta,
+		Reason:   req.Reason,
+	}, field)
+	if err != nil {
+		return dto.AdminCoinAdjustData{}, err
+	}
+
+	userData := buildCommonUserData(user)
+	s.eventPublisher.Publish(user.ID, coinsChangedEvent(userData))
+	s.metrics.CoinsChanged(field, CoinSourceAdmin, adjustment.Delta)
+	s.logger.InfoContext(ctx, "写入金币调整流水", "adjustment_id", adjustment.ID, "user_id", adjustment.UserID,
+		"coin_type", adjustment.CoinType, "balance_before", adjustment.BalanceBefore, "balance_after", adjustment.BalanceAfter)
+	return dto.AdminCoinAdjustData{
+		User: userData,
+		Adjustment: dto.CoinAdjustmentData{
+			ID:            adjustment.ID,
+			UserID:        adjustment.UserID,
+			AdminID:       adjustment.AdminID,
+			CoinType:      adjustment.CoinType,
+			Delta:         adjustment.Delta,
+			BalanceBefore: adjustment.BalanceBefore,
+			BalanceAfter:  adjustment.BalanceAfter,
+			Reason:        adjustment.Reason,
+			CreatedAt:     adjustment.CreatedAt,
+		},
+	}, nil
+}
+
+// ResetUserProfile 重置头像时旧头像文件的引用由ResetProfile释放
+func (s *AdminUserService) ResetUserProfile(ctx context.Context, req dto.AdminResetUserProfileRequest) (dto.CommonUserData, error) {
+	if !req.ResetUsername && !req.ResetHeadImage {
+		return dto.CommonUserData{}, ErrNothingToReset
+	}
+
+	user, existed, err := s.userRepository.FindByID(ctx, req.UserID)
+	if err != nil {
+		return dto.CommonUserData{}, err
+	}
+	if !existed || user == nil {
+		return dto.CommonUserData{}, ErrUserNotFound
+	}
+
+	var newUsername, newHeadImagePath *string
+	previousUsername := user.Username
+	if req.ResetUsername {
+		username := req.NewUsername
+		if username == "" {
+			username = DefaultUsername(user.ID)
+		}
+		if username != user.Username {
+			other, taken, err := s.userRepository.FindByUsername(ctx, username)
+			if err != nil {
+				return dto.CommonUserData{}, err
+			}
+			if taken && other.ID != user.ID {
+				return dto.CommonUserData{}, ErrUsernameTaken
+			}
+		}
+		newUsername = &username
+		user.Username = username
+	}
+
+	if req.ResetHeadImage {
+		headImagePath := config.DefaultHeadImagePath
+		newHeadImagePath = &headImagePath
+		user.HeadImagePath = headImagePath
+		user.HeadImageThumbnails = nil
+	}
+
+	if err = s.userRepository.ResetProfile(ctx, user.ID, newUsername, newHeadImagePath); err != nil {
+		return dto.CommonUserData{}, err
+	}
+	if newUsername != nil {
+		s.logger.InfoContext(ctx, "重置用户名", "user_id", user.ID, "from", previousUsername, "to", *newUsername)
+	}
+	user.Version++
+	return buildCommonUserData(user), nil
+}
+
+func (s *AdminUserService) ensureUserExists(ctx context.Context, userID uint) error {
+	_, existed, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func buildCommonUserData(user *models.User) dto.CommonUserData {
+	return dto.CommonUserData{
+		UserID:              user.ID,
+		Username:            user.Username,
+		Group:               user.Group,
+		HeadImagePath:       dto.FileURL(user.HeadImagePath),
+		HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+		StrengthCoin:        user.StrengthCoin,
+		SelectCoin:          user.SelectCoin,
+		Version:             user.Version,
+	}
+}
@@ -3,7 +3,9 @@ package service
 import (
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
 	"errors"
+	"log/slog"
 	"time"
 )
 
@@ -14,8 +16,8 @@ var (
 )
 
 type UserRepository interface {
-	FindByUsername(username string) (*models.User, bool, error)
-	Create(user *models.User) error
+	FindByUsername(ctx context.Context, username string) (*models.User, bool, error)
+	Create(ctx context.Context, user *models.User) error
 }
 
 type PasswordHasher interface {
@@ -32,19 +34,29 @@ type AuthService struct {
 	passwordHasher       PasswordHasher
 	tokenService         TokenService
 	defaultHeadImagePath string
+	usernameFilter       *UsernameFilter
+	metrics              GameMetrics
+	logger               *slog.Logger
 }
 
-func NewAuthService(userRepository UserRepository, passwordHasher PasswordHasher, tokenService TokenService, defaultHeadImagePath string) *AuthService {
+// NewAuthService usernameFilter可以传nil，此时注册不检查敏感词；metrics可以传nil，此时不记录指标
+func NewAuthService(userRepository UserRepository, passwordHasher PasswordHasher, tokenService TokenService, defaultHeadImagePath string, usernameFilter *UsernameFilter, metrics GameMetrics, logger *slog.Logger) *AuthService {
 	return &AuthService{
 		userRepository:       userRepository,
 		passwordHasher:       passwordHasher,
 		tokenService:         tokenService,
 		defaultHeadImagePath: defaultHeadImagePath,
+		usernameFilter:       usernameFilter,
+		metrics:              gameMetricsOrNoop(metrics),
+		logger:               logger,
 	}
 }
 
-func (s *AuthService) Register(req dto.RegisterRequest) (dto.AuthData, error) {
-	existedUser, existed, err := s.userRepository.FindByUsername(req.UserName)
+func (s *AuthService) Register(ctx context.Context, req dto.RegisterRequest) (dto.AuthData, error) {
+	if err := s.usernameFilter.Check(req.UserName); err != nil {
+		return dto.AuthData{}, err
+	}
+	existedUser, existed, err := s.userRepository.FindByUsername(ctx, req.UserName)
 	if err != nil {
 		return dto.AuthData{}, err
 	}
@@ -62,12 +74,16 @@ func (s *AuthService) Register(req dto.RegisterRequest) (dto.AuthData, error) {
 		Password:      hashedPsw,
 		Group:         "user",
 		HeadImagePath: s.defaultHeadImagePath,
+		Version:       1,
 	}
 
-	if err = s.userRepository.Create(&newUser); err != nil {
+	if err = s.userRepository.Create(ctx, &newUser); err != nil {
 		return dto.AuthData{}, err
 	}
 
+	s.metrics.Registered()
+	s.logger.InfoContext(ctx, "用户注册", "user_id", newUser.ID, "username", newUser.Username)
+
 	token, expirationTime, err := s.tokenService.GenerateToken(newUser)
 	if err != nil {
 		return dto.AuthData{}, err
@@ -75,28 +91,34 @@ func (s *AuthService) Register(req dto.RegisterRequest) (dto.AuthData, error) {
 
 	return dto.AuthData{
 		User: dto.CommonUserData{
-			UserID:        newUser.ID,
-			Username:      newUser.Username,
-			Group:         newUser.Group,
-			HeadImagePath: newUser.HeadImagePath,
-			StrengthCoin:  newUser.StrengthCoin,
-			SelectCoin:    newUser.SelectCoin,
+			UserID:              newUser.ID,
+			Username:            newUser.Username,
+			Group:               newUser.Group,
+			HeadImagePath:       dto.FileURL(newUser.HeadImagePath),
+			HeadImageThumbnails: dto.FileURLs(newUser.HeadImageThumbnails),
+			StrengthCoin:        newUser.StrengthCoin,
+			SelectCoin:          newUser.SelectCoin,
+			Version:             newUser.Version,
 		},
 		Token:     token,
 		ExpiresAt: expirationTime.Unix(),
 	}, nil
 }
 
-func (s *AuthService) Login(req dto.LoginRequest) (dto.AuthData, error) {
-	user, existed, err := s.userRepository.FindByUsername(req.UserName)
+func (s *AuthService) Login(ctx context.Context, req dto.LoginRequest) (dto.AuthData, error) {
+	user, existed, err := s.userRepository.FindByUsername(ctx, req.UserName)
 	if err != nil {
 		return dto.AuthData{}, err
 	}
 	if !existed || user == nil {
+		s.metrics.LoginFailed()
+		s.logger.InfoContext(ctx, "登录失败：用户不存在", "username", req.UserName)
 		return dto.AuthData{}, ErrUserNotFound
 	}
 
 	if err = s.passwordHasher.Compare(user.Password, req.Password); err != nil {
+		s.metrics.LoginFailed()
+		s.logger.WarnContext(ctx, "登录失败：密码错误", "user_id", user.ID)
 		return dto.AuthData{}, ErrInvalidPassword
 	}
 
@@ -104,15 +126,19 @@ func (s *AuthService) Login(req dto.LoginRequest) (dto.AuthData, error) {
 	if err != nil {
 		return dto.AuthData{}, err
 	}
+	s.metrics.LoginSucceeded()
+	s.logger.InfoContext(ctx, "用户登录", "user_id", user.ID)
 
 	return dto.AuthData{
 		User: dto.CommonUserData{
-			UserID:        user.ID,
-			Username:      user.Username,
-			Group:         user.Group,
-			HeadImagePath: user.HeadImagePath,
-			StrengthCoin:  user.StrengthCoin,
-			SelectCoin:    user.SelectCoin,
+			UserID:              user.ID,
+			Username:            user.Username,
+			Group:               user.Group,
+			HeadImagePath:       dto.FileURL(user.HeadImagePath),
+			HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+			StrengthCoin:        user.StrengthCoin,
+			SelectCoin:          user.SelectCoin,
+			Version:             user.Version,
 		},
 		Token:     token,
 		ExpiresAt: expirationTime.Unix(),
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrAvatarPresetNotFound   = errors.New("预设头像不存在")
+	ErrAvatarPresetLocked     = errors.New("尚未解锁该预设头像")
+	ErrAvatarPresetNameExists = errors.New("预设头像名称已存在")
+	ErrUnsupportedUnlockType  = errors.New("unlock_type仅支持achievements/cards")
+	ErrUnlockResourceRequired = errors.New("设置了unlock_type时必须指定unlock_resource_id")
+	ErrUnlockResourceNotFound = errors.New("解锁条件对应的资源不存在")
+)
+
+type AvatarPresetRepository interface {
+	// List 按sort_order、id排序
+	List(ctx context.Context, enabledOnly bool) ([]models.AvatarPreset, error)
+	FindByID(ctx context.Context, presetID uint) (*models.AvatarPreset, bool, error)
+	// CompletedResourceIDs 返回resourceIDs中userID已完成(is_complete)的资源
+	CompletedResourceIDs(ctx context.Context, userID uint, relationType UserRelationType, resourceIDs []uint) (map[uint]bool, error)
+	ResourceExists(ctx context.Context, relationType UserRelationType, resourceID uint) (bool, error)
+	// Create 和Update在名称重复时返回ErrAvatarPresetNameExists
+	Create(ctx context.Context, preset *models.AvatarPreset) error
+	Update(ctx context.Context, preset *models.AvatarPreset) error
+	// Delete 同时释放预设持有的文件引用，不存在时返回ErrAvatarPresetNotFound
+	Delete(ctx context.Context, presetID uint) error
+}
+
+type AvatarPresetService struct {
+	presetRepository     AvatarPresetRepository
+	userRepository       ProfileUserRepository
+	storedFileRepository StoredFileRepository
+	fileStorage          FileStorage
+	logger               *slog.Logger
+}
+
+func NewAvatarPresetService(presetRepository AvatarPresetRepository, userRepository ProfileUserRepository, storedFileRepository StoredFileRepository, fileStorage FileStorage, logger *slog.Logger) *AvatarPresetService {
+	return &AvatarPresetService{
+		presetRepository:     presetRepository,
+		userRepository:       userRepository,
+		storedFileRepository: storedFileRepository,
+		fileStorage:          fileStorage,
+		logger:               logger,
+	}
+}
+
+// ListForUser 只列出启用的预设，并标出当前用户是否已解锁
+func (s *AvatarPresetService) ListForUser(ctx context.Context, userID uint) ([]dto.AvatarPresetData, error) {
+	if userID == 0 {
+		return nil, ErrMissingUserContext
+	}
+	presets, err := s.presetRepository.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	required := make(map[UserRelationType][]uint)
+	for _, preset := range presets {
+		if preset.UnlockType != "" && preset.UnlockResourceID != nil {
+			relationType := UserRelationType(preset.UnlockType)
+			required[relationType] = append(required[relationType], *preset.UnlockResourceID)
+		}
+	}
+	completed := make(map[UserRelationType]map[uint]bool, len(required))
+	for relationType, resourceIDs := range required {
+		if completed[relationType], err = s.presetRepository.CompletedResourceIDs(ctx, userID, relationType, resourceIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	list := make([]dto.AvatarPresetData, 0, len(presets))
+	for i := range presets {
+		preset := &presets[i]
+		unlocked := preset.UnlockType == "" || (preset.UnlockResourceID != nil && completed[UserRelationType(preset.UnlockType)][*preset.UnlockResourceID])
+		list = append(list, dto.BuildAvatarPresetData(preset, unlocked))
+	}
+	return list, nil
+}
+
+// Select 把头像换成预设，不写新文件，也不更新头像修改时间
+func (s *AvatarPresetService) Select(ctx context.Context, userID uint, req dto.SelectAvatarPresetRequest) (dto.HeadImageData, error) {
+	if userID == 0 {
+		return dto.HeadImageData{}, ErrMissingUserContext
+	}
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return dto.HeadImageData{}, err
+	}
+	if !existed || user == nil {
+		return dto.HeadImageData{}, ErrUserNotFound
+	}
+	if err = checkExpectedVersion(req.Version, user.Version); err != nil {
+		return dto.HeadImageData{}, err
+	}
+
+	preset, existed, err := s.presetRepository.FindByID(ctx, req.PresetID)
+	if err != nil {
+		return dto.HeadImageData{}, err
+	}
+	if !existed || !preset.Enabled {
+		return dto.HeadImageData{}, ErrAvatarPresetNotFound
+	}
+	if preset.UnlockType != "" {
+		if preset.UnlockResourceID == nil {
+			return dto.HeadImageData{}, ErrAvatarPresetLocked
+		}
+		completed, err := s.presetRepository.CompletedResourceIDs(ctx, userID, UserRelationType(preset.UnlockType), []uint{*preset.UnlockResourceID})
+		if err != nil {
+			return dto.HeadImageData{}, err
+		}
+		if !completed[*preset.UnlockResourceID] {
+			return dto.HeadImageData{}, ErrAvatarPresetLocked
+		}
+	}
+
+	//用户也持有一份引用，预设之后被删掉时用户的头像文件还在
+	keys := presetFileKeys(preset)
+	if err = s.storedFileRepository.Acquire(ctx, keys); err != nil {
+		return dto.HeadImageData{}, err
+	}
+	//预设头像由管理员维护，不用再审核
+	if err = s.userRepository.UpdateHeadImage(ctx, userID, preset.HeadImagePath, preset.HeadImageThumbnails, nil, user.Version, false); err != nil {
+		if releaseErr := s.storedFileRepository.Release(ctx, keys); releaseErr != nil {
+			s.logger.WarnContext(ctx, "撤销头像文件引用失败，引用计数等垃圾回收修正", "keys", keys, "error", releaseErr)
+		}
+		return dto.HeadImageData{}, err
+	}
+	return dto.HeadImageData{
+		HeadImagePath:       dto.FileURL(preset.HeadImagePath),
+		HeadImageThumbnails: dto.FileURLs(preset.HeadImageThumbnails),
+	}, nil
+}
+
+// ListForAdmin 包括未启用的预设
+func (s *AvatarPresetService) ListForAdmin(ctx context.Context) ([]dto.AvatarPresetData, error) {
+	presets, err := s.presetRepository.List(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]dto.AvatarPresetData, 0, len(presets))
+	for i := range presets {
+		list = append(list, dto.BuildAvatarPresetData(&presets[i], false))
+	}
+	return list, nil
+}
+
+func (s *AvatarPresetService) Create(ctx context.Context, req dto.AdminAvatarPresetCreateRequest, processed *utils.ProcessedImage) (dto.AvatarPresetData, error) {
+	preset := &models.AvatarPreset{
+		Name:       req.Name,
+		UnlockType: req.UnlockType,
+		SortOrder:  req.SortOrder,
+		Enabled:    true,
+	}
+	if req.UnlockResourceID > 0 {
+		preset.UnlockResourceID = &req.UnlockResourceID
+	}
+	if err := s.validateUnlock(ctx, preset); err != nil {
+		return dto.AvatarPresetData{}, err
+	}
+
+	headImagePath, thumbnails, keys, err := storeHeadImage(ctx, s.fileStorage, s.storedFileRepository, processed, s.logger)
+	if err != nil {
+		return dto.AvatarPresetData{}, err
+	}
+	preset.HeadImagePath = headImagePath
+	preset.HeadImageThumbnails = thumbnails
+	if err = s.presetRepository.Create(ctx, preset); err != nil {
+		if releaseErr := s.storedFileRepository.Release(ctx, keys); releaseErr != nil {
+			s.logger.WarnContext(ctx, "撤销头像文件引用失败，引用计数等垃圾回收修正", "keys", keys, "error", releaseErr)
+		}
+		return dto.AvatarPresetData{}, err
+	}
+	return dto.BuildAvatarPresetData(preset, false), nil
+}
+
+func (s *AvatarPresetService) Update(ctx context.Context, req dto.AdminAvatarPresetUpdateRequest) (dto.AvatarPresetData, error) {
+	if req.Name == nil && req.UnlockType == nil && req.UnlockResourceID == nil && req.SortOrder == nil && req.Enabled == nil {
+		return dto.AvatarPresetData{}, ErrNoUpdateFields
+	}
+	preset, existed, err := s.presetRepository.FindByID(ctx, req.PresetID)
+	if err != nil {
+		return dto.AvatarPresetData{}, err
+	}
+	if !existed {
+		return dto.AvatarPresetData{}, ErrAvatarPresetNotFound
+	}
+
+	if req.Name != nil {
+		preset.Name = *req.Name
+	}
+	if req.UnlockType != nil {
+		preset.UnlockType = *req.UnlockType
+		if preset.UnlockType == "" {
+			preset.UnlockResourceID = nil
+		}
+	}
+	if req.UnlockResourceID != nil {
+		preset.UnlockResourceID = req.UnlockResourceID
+	}
+	if req.SortOrder != nil {
+		preset.SortOrder = *req.SortOrder
+	}
+	if req.Enabled != nil {
+		preset.Enabled = *req.Enabled
+	}
+	if err = s.validateUnlock(ctx, preset); err != nil {
+		return dto.AvatarPresetData{}, err
+	}
+
+	if err = s.presetRepository.Update(ctx, preset); err != nil {
+		return dto.AvatarPresetData{}, err
+	}
+	return dto.BuildAvatarPresetData(preset, false), nil
+}
+
+func (s *AvatarPresetService) Delete(ctx context.Context, presetID uint) error {
+	return s.presetRepository.Delete(ctx, presetID)
+}
+
+// validateUnlock 没有解锁条件时清掉resource id，有时检查类型和资源是否存在
+func (s *AvatarPresetService) validateUnlock(ctx context.Context, preset *models.AvatarPreset) error {
+	switch UserRelationType(preset.UnlockType) {
+	case "":
+		preset.UnlockResourceID = nil
+		return nil
+	case UserRelationAchievement, UserRelationCard:
+	default:
+		return ErrUnsupportedUnlockType
+	}
+	if preset.UnlockResourceID == nil || *preset.UnlockResourceID == 0 {
+		return ErrUnlockResourceRequired
+	}
+	existed, err := s.presetRepository.ResourceExists(ctx, UserRelationType(preset.UnlockType), *preset.UnlockResourceID)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		return fmt.Errorf("%w:%s %d", ErrUnlockResourceNotFound, preset.UnlockType, *preset.UnlockResourceID)
+	}
+	return nil
+}
+
+func presetFileKeys(preset *models.AvatarPreset) []string {
+	keys := []string{preset.HeadImagePath}
+	for _, key := range preset.HeadImageThumbnails {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+)
+
+type CatalogueRepository interface {
+	// ListCatalogue 返回该类型的全部资源定义(按id正序)，并合并userID的关联状态
+	ListCatalogue(ctx context.Context, userID uint, relationType UserRelationType) ([]dto.CatalogueEntryData, error)
+}
+
+type CatalogueService struct {
+	catalogueRepository CatalogueRepository
+}
+
+func NewCatalogueService(catalogueRepository CatalogueRepository) *CatalogueService {
+	return &CatalogueService{catalogueRepository: catalogueRepository}
+}
+
+func (s *CatalogueService) GetCatalogue(ctx context.Context, userID uint, relationTypeStr string) (dto.CatalogueData, error) {
+	if userID == 0 {
+		return dto.CatalogueData{}, ErrMissingUserContext
+	}
+	relationType, err := ParseUserRelationType(relationTypeStr)
+	if err != nil {
+		return dto.CatalogueData{}, err
+	}
+
+	list, err := s.catalogueRepository.ListCatalogue(ctx, userID, relationType)
+	if err != nil {
+		return dto.CatalogueData{}, err
+	}
+
+	owned := 0
+	for _, entry := range list {
+		if entry.Owned {
+			owned++
+		}
+	}
+	return dto.CatalogueData{Type: string(relationType), List: list, Total: len(list), Owned: owned}, nil
+}
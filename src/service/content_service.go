@@ -0,0 +1,177 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/goccy/go-yaml"
+)
+
+var (
+	ErrContentNameRequired       = errors.New("资源名称不能为空")
+	ErrContentNameDuplicated     = errors.New("内容文件中存在重复的资源名称")
+	ErrContentPrerequisiteCycle  = errors.New("前置技能不能是自身或形成循环")
+	ErrContentPrerequisiteAbsent = errors.New("前置技能不存在")
+	ErrUnsupportedContentFormat  = errors.New("format参数仅支持json/yaml")
+	ErrInvalidContentFile        = errors.New("内容文件解析失败")
+	ErrContentNameTooLong        = errors.New("资源名称不能超过50个字符")
+)
+
+const (
+	ContentFormatJSON = "json"
+	ContentFormatYAML = "yaml"
+
+	ContentActionCreate    = "create"
+	ContentActionUpdate    = "update"
+	ContentActionUnchanged = "unchanged"
+
+	//与管理员单个创建资源时的校验保持一致
+	maxContentNameLen = 50
+)
+
+type ContentRepository interface {
+	ImportResources(ctx context.Context, content dto.ContentFile, dryRun bool) (dto.ContentImportResult, error)
+	ExportResources(ctx context.Context) (dto.ContentFile, error)
+}
+
+type ContentService struct {
+	contentRepository ContentRepository
+	logger            *slog.Logger
+}
+
+func NewContentService(contentRepository ContentRepository, logger *slog.Logger) *ContentService {
+	return &ContentService{contentRepository: contentRepository, logger: logger}
+}
+
+// Import 按名称upsert内容文件里的资源，整个文件在一个事务里执行
+// dryRun为true时只返回差异，不落库
+func (s *ContentService) Import(ctx context.Context, content dto.ContentFile, dryRun bool) (dto.ContentImportResult, error) {
+	if err := validateContentFile(content); err != nil {
+		return dto.ContentImportResult{}, err
+	}
+	result, err := s.contentRepository.ImportResources(ctx, content, dryRun)
+	if err != nil {
+		return dto.ContentImportResult{}, err
+	}
+	s.logger.InfoContext(ctx, "导入内容文件", "dry_run", dryRun, "created", result.Created, "updated", result.Updated, "unchanged", result.Unchanged)
+	return result, nil
+}
+
+func (s *ContentService) Export(ctx context.Context) (dto.ContentFile, error) {
+	return s.contentRepository.ExportResources(ctx)
+}
+
+func ParseContentFormat(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", ContentFormatJSON:
+		return ContentFormatJSON, nil
+	case ContentFormatYAML, "yml":
+		return ContentFormatYAML, nil
+	default:
+		return "", ErrUnsupportedContentFormat
+	}
+}
+
+func DecodeContentFile(raw []byte, format string) (dto.ContentFile, error) {
+	var content dto.ContentFile
+	var err error
+	if format == ContentFormatYAML {
+		err = yaml.UnmarshalWithOptions(raw, &content, yaml.DisallowUnknownField())
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&content)
+	}
+	if err != nil {
+		return dto.ContentFile{}, fmt.Errorf("%w:%v", ErrInvalidContentFile, err)
+	}
+	return content, nil
+}
+
+func EncodeContentFile(content dto.ContentFile, format string) ([]byte, error) {
+	if format == ContentFormatYAML {
+		return yaml.Marshal(content)
+	}
+	return json.MarshalIndent(content, "", "  ")
+}
+
+func validateContentFile(content dto.ContentFile) error {
+	checkNames := func(relationType UserRelationType, names []string) error {
+		seen := make(map[string]struct{}, len(names))
+		for _, name := range names {
+			if strings.TrimSpace(name) == "" {
+				return fmt.Errorf("%w(%s)", ErrContentNameRequired, relationType)
+			}
+			if utf8.RuneCountInString(name) > maxContentNameLen {
+				return fmt.Errorf("%w(%s:%s)", ErrContentNameTooLong, relationType, name)
+			}
+			if _, ok := seen[name]; ok {
+				return fmt.Errorf("%w(%s:%s)", ErrContentNameDuplicated, relationType, name)
+			}
+			seen[name] = struct{}{}
+		}
+		return nil
+	}
+
+	if err := checkNames(UserRelationAchievement, contentResourceNames(content.Achievements)); err != nil {
+		return err
+	}
+	skillNames := make([]string, 0, len(content.Skills))
+	for _, skill := range content.Skills {
+		skillNames = append(skillNames, skill.Name)
+	}
+	if err := checkNames(UserRelationSkill, skillNames); err != nil {
+		return err
+	}
+	if err := checkPrerequisiteCycles(content.Skills); err != nil {
+		return err
+	}
+	if err := checkNames(UserRelationItem, contentResourceNames(content.Items)); err != nil {
+		return err
+	}
+	return checkNames(UserRelationCard, contentResourceNames(content.Cards))
+}
+
+// checkPrerequisiteCycles 每个技能最多一个前置，从每个技能顺着前置往上走，走回当前路径上的技能就是有环
+// 只检查文件内部，和库里已有技能连成的环由repository在写入时检查
+func checkPrerequisiteCycles(skills []dto.ContentSkill) error {
+	prerequisites := make(map[string]string, len(skills))
+	for _, skill := range skills {
+		prerequisites[skill.Name] = skill.Prerequisite
+	}
+
+	//checked里的技能往上走不会成环，后面走到时直接停下
+	checked := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		var path []string
+		onPath := map[string]bool{}
+		for name := skill.Name; name != "" && !checked[name]; name = prerequisites[name] {
+			if onPath[name] {
+				cycle := append(path[slices.Index(path, name):], name)
+				return fmt.Errorf("%w:%s", ErrContentPrerequisiteCycle, strings.Join(cycle, "->"))
+			}
+			onPath[name] = true
+			path = append(path, name)
+		}
+		for _, name := range path {
+			checked[name] = true
+		}
+	}
+	return nil
+}
+
+func contentResourceNames(resources []dto.ContentResource) []string {
+	names := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	return names
+}
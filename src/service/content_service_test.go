@@ -0,0 +1,44 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateContentFile(t *testing.T) {
+	skill := func(name, prerequisite string) dto.ContentSkill {
+		return dto.ContentSkill{Name: name, Prerequisite: prerequisite}
+	}
+
+	tests := []struct {
+		name      string
+		content   dto.ContentFile
+		wantErr   error
+		wantCycle string
+	}{
+		{"空文件", dto.ContentFile{}, nil, ""},
+		{"前置链", dto.ContentFile{Skills: []dto.ContentSkill{skill("a", ""), skill("b", "a"), skill("c", "b")}}, nil, ""},
+		{"前置引用库里的技能", dto.ContentFile{Skills: []dto.ContentSkill{skill("a", "outside")}}, nil, ""},
+		{"前置引用后面的技能", dto.ContentFile{Skills: []dto.ContentSkill{skill("c", "b"), skill("b", "a"), skill("a", "")}}, nil, ""},
+		{"多个技能共用前置", dto.ContentFile{Skills: []dto.ContentSkill{skill("a", ""), skill("b", "a"), skill("c", "a")}}, nil, ""},
+		{"自身为前置", dto.ContentFile{Skills: []dto.ContentSkill{skill("a", "a")}}, ErrContentPrerequisiteCycle, "a->a"},
+		{"两个技能互为前置", dto.ContentFile{Skills: []dto.ContentSkill{skill("a", "b"), skill("b", "a")}}, ErrContentPrerequisiteCycle, "a->b->a"},
+		{"三个技能成环", dto.ContentFile{Skills: []dto.ContentSkill{skill("x", "a"), skill("a", "b"), skill("b", "c"), skill("c", "a")}}, ErrContentPrerequisiteCycle, "a->b->c->a"},
+		{"重复名称", dto.ContentFile{Items: []dto.ContentResource{{Name: "a"}, {Name: "a"}}}, ErrContentNameDuplicated, ""},
+		{"名称为空", dto.ContentFile{Cards: []dto.ContentResource{{Name: " "}}}, ErrContentNameRequired, ""},
+		{"名称过长", dto.ContentFile{Achievements: []dto.ContentResource{{Name: strings.Repeat("长", 51)}}}, ErrContentNameTooLong, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateContentFile(tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantCycle != "" && !strings.HasSuffix(err.Error(), ":"+tt.wantCycle) {
+				t.Fatalf("错误里的环为%q，期望%q", err.Error(), tt.wantCycle)
+			}
+		})
+	}
+}
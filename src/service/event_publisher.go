@@ -0,0 +1,60 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"time"
+)
+
+// 推送给客户端的事件类型
+const (
+	EventCoinsChanged    = "coins_changed"
+	EventRelationCreated = "relation_created"
+	EventRelationUpdated = "relation_updated"
+	EventRelationDeleted = "relation_deleted"
+	//账号被管理员封禁/删除，收到后连接会被关闭
+	EventBanned = "banned"
+	//token被吊销(登出、改密码、重置密码)，收到后连接会被关闭，客户端需要重新登录
+	EventForcedLogout = "forced_logout"
+)
+
+// EventPublisher 把事件推给userID当前在线的连接，不在线就丢弃，不能阻塞调用方
+// 只能在数据已经提交之后调用，事件只是提醒，客户端以增量同步/存档接口的数据为准
+type EventPublisher interface {
+	Publish(userID uint, event dto.PushEvent)
+}
+
+type noopEventPublisher struct{}
+
+func (noopEventPublisher) Publish(uint, dto.PushEvent) {}
+
+// eventPublisherOrNoop 命令行等不需要推送的地方可以传nil
+func eventPublisherOrNoop(publisher EventPublisher) EventPublisher {
+	if publisher == nil {
+		return noopEventPublisher{}
+	}
+	return publisher
+}
+
+func newPushEvent(eventType string, data interface{}) dto.PushEvent {
+	return dto.PushEvent{Type: eventType, Data: data, At: time.Now()}
+}
+
+func coinsChangedEvent(user dto.CommonUserData) dto.PushEvent {
+	return newPushEvent(EventCoinsChanged, dto.CoinsChangedEventData{
+		StrengthCoin: user.StrengthCoin,
+		SelectCoin:   user.SelectCoin,
+		Version:      user.Version,
+	})
+}
+
+func relationEvent(eventType string, relationType UserRelationType, resourceID uint, relation *dto.CommonUserRelationData) dto.PushEvent {
+	return newPushEvent(eventType, dto.RelationEventData{
+		Type:       string(relationType),
+		ResourceID: resourceID,
+		Relation:   relation,
+	})
+}
+
+func forcedLogoutEvent(reason string) dto.PushEvent {
+	return newPushEvent(EventForcedLogout, dto.SessionEventData{Reason: reason})
+}
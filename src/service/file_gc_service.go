@@ -0,0 +1,117 @@
+package service
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// FileGCService 对账上传文件：按实际引用(用户头像和预设头像)修正引用计数，删除计数为0的文件和存储里没人引用的孤儿文件
+// 最近gracePeriod内变动过的计数和新写入的对象不处理，避免和进行中的上传冲突
+type FileGCService struct {
+	storedFileRepository StoredFileRepository
+	fileStorage          FileStorage
+	logger               *slog.Logger
+}
+
+func NewFileGCService(storedFileRepository StoredFileRepository, fileStorage FileStorage, logger *slog.Logger) *FileGCService {
+	return &FileGCService{storedFileRepository: storedFileRepository, fileStorage: fileStorage, logger: logger}
+}
+
+func (s *FileGCService) Collect(ctx context.Context, dryRun bool, gracePeriod time.Duration) (dto.FileGCReport, error) {
+	report := dto.FileGCReport{DryRun: dryRun, RepairedRefs: []dto.FileRefRepair{}, Purged: []string{}, Orphans: []string{}}
+	cutoff := time.Now().Add(-gracePeriod)
+
+	//先读计数再统计引用，统计期间发生的Acquire/Release都会让UpdatedAt晚于cutoff，不会被误修正
+	refs, err := s.storedFileRepository.ListRefs(ctx)
+	if err != nil {
+		return report, err
+	}
+	actualRefs, err := s.storedFileRepository.CountReferences(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	repairs := make(map[string]int)
+	for key, count := range actualRefs {
+		ref, ok := refs[key]
+		if !ok || (ref.RefCount != count && ref.UpdatedAt.Before(cutoff)) {
+			repairs[key] = count
+		}
+	}
+	for key, ref := range refs {
+		if _, ok := actualRefs[key]; !ok && ref.RefCount > 0 && ref.UpdatedAt.Before(cutoff) {
+			repairs[key] = 0
+		}
+	}
+	for _, key := range sortedKeys(repairs) {
+		if !dryRun {
+			changed, err := s.storedFileRepository.SetRefCount(ctx, key, repairs[key], cutoff)
+			if err != nil {
+				return report, err
+			}
+			if !changed {
+				continue
+			}
+		}
+		report.RepairedRefs = append(report.RepairedRefs, dto.FileRefRepair{Key: key, From: refs[key].RefCount, To: repairs[key]})
+		refs[key] = StoredFileRef{RefCount: repairs[key], UpdatedAt: time.Now()}
+	}
+
+	if dryRun {
+		for key, ref := range refs {
+			if ref.RefCount == 0 {
+				report.Purged = append(report.Purged, key)
+			}
+		}
+		sort.Strings(report.Purged)
+	} else {
+		purged, err := s.storedFileRepository.PurgeUnreferenced(ctx, func(key string) error {
+			if err := s.fileStorage.Delete(key); err != nil {
+				report.Failed++
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.WarnContext(ctx, "删除无引用文件时部分失败", "error", err)
+		}
+		report.Purged = append(report.Purged, purged...)
+	}
+
+	objects, err := s.fileStorage.List(config.UploadKeyPrefix)
+	if err != nil {
+		return report, err
+	}
+	report.Scanned = len(objects)
+	for _, object := range objects {
+		//有记录的文件归引用计数管，计数为0的上面已经处理过
+		if _, tracked := refs[object.Key]; tracked || actualRefs[object.Key] > 0 {
+			continue
+		}
+		if !object.ModTime.Before(cutoff) {
+			continue
+		}
+		if !dryRun {
+			if err := s.fileStorage.Delete(object.Key); err != nil {
+				s.logger.WarnContext(ctx, "删除孤儿文件失败", "key", object.Key, "error", err)
+				report.Failed++
+				continue
+			}
+		}
+		report.Orphans = append(report.Orphans, object.Key)
+	}
+	return report, nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidFileKey = errors.New("文件key不合法")
+	ErrFileNotFound   = errors.New("文件不存在")
+)
+
+// FileStorage 上传文件的存储，key形如uploads/HeadImg_xxx.jpg，库里只存key
+type FileStorage interface {
+	Put(key string, data []byte, contentType string) error
+	// Get 对象不存在时返回ErrFileNotFound
+	Get(key string) ([]byte, error)
+	// Delete 对象不存在时不报错
+	Delete(key string) error
+	// URL 客户端访问用的地址，可能是公开地址也可能是有时效的预签名地址
+	URL(key string) string
+	// List 列出key以prefix开头的全部对象，垃圾回收用
+	List(prefix string) ([]StoredObject, error)
+}
+
+type StoredObject struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// StoredFileRef 一个文件当前的引用计数，UpdatedAt为计数最后一次变化的时间
+type StoredFileRef struct {
+	RefCount  int
+	UpdatedAt time.Time
+}
+
+// StoredFileRepository 上传文件的引用计数，引用方(用户头像、预设头像)更新时在同一个事务里释放旧文件的引用
+type StoredFileRepository interface {
+	// Acquire 写存储之前先给新文件加引用，防止同内容的文件正被垃圾回收删掉
+	Acquire(ctx context.Context, keys []string) error
+	// Release 上传失败时撤销Acquire加的引用
+	Release(ctx context.Context, keys []string) error
+	ListRefs(ctx context.Context) (map[string]StoredFileRef, error)
+	// CountReferences 按users表和预设头像统计每个文件实际被引用的次数
+	CountReferences(ctx context.Context) (map[string]int, error)
+	// SetRefCount 修正引用计数，只改UpdatedAt早于unchangedBefore的记录，没有记录时新建；返回是否改了
+	SetRefCount(ctx context.Context, key string, refCount int, unchangedBefore time.Time) (bool, error)
+	// PurgeUnreferenced 删除引用计数为0的文件，remove在持有记录行锁时调用，成功后才删记录
+	// 并发的Acquire会等删除提交后重新建记录，所以文件不会在被重新引用后才被删掉
+	PurgeUnreferenced(ctx context.Context, remove func(key string) error) ([]string, error)
+}
+
+// headImageKeys 头像按内容寻址：同一张图重新编码的结果相同，多次上传只存一份
+// 缩略图和主图同名加上_<边长>后缀，返回主图key、按边长索引的缩略图key和全部key
+func headImageKeys(processed *utils.ProcessedImage) (string, map[string]string, []string) {
+	sum := sha256.Sum256(processed.Main)
+	baseKey := config.UploadKeyPrefix + config.PrefixHeadImg + "_" + hex.EncodeToString(sum[:])
+
+	mainKey := baseKey + ".jpg"
+	keys := []string{mainKey}
+	thumbnails := make(map[string]string, len(processed.Thumbnails))
+	for size := range processed.Thumbnails {
+		key := fmt.Sprintf("%s_%d.jpg", baseKey, size)
+		thumbnails[strconv.Itoa(size)] = key
+		keys = append(keys, key)
+	}
+	return mainKey, thumbnails, keys
+}
+
+// storeHeadImage 先加引用再写存储，写失败时撤销引用；内容相同的对象重复写入是幂等的
+func storeHeadImage(ctx context.Context, storage FileStorage, storedFileRepository StoredFileRepository, processed *utils.ProcessedImage, logger *slog.Logger) (string, map[string]string, []string, error) {
+	mainKey, thumbnails, keys := headImageKeys(processed)
+	if err := storedFileRepository.Acquire(ctx, keys); err != nil {
+		return "", nil, nil, err
+	}
+
+	err := storage.Put(mainKey, processed.Main, "image/jpeg")
+	for size, data := range processed.Thumbnails {
+		if err != nil {
+			break
+		}
+		err = storage.Put(thumbnails[strconv.Itoa(size)], data, "image/jpeg")
+	}
+	if err != nil {
+		if releaseErr := storedFileRepository.Release(ctx, keys); releaseErr != nil {
+			logger.WarnContext(ctx, "撤销头像文件引用失败，引用计数等垃圾回收修正", "keys", keys, "error", releaseErr)
+		}
+		return "", nil, nil, err
+	}
+	return mainKey, thumbnails, keys, nil
+}
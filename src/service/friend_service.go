@@ -0,0 +1,184 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
+	"errors"
+	"log/slog"
+)
+
+var (
+	ErrFriendSelf               = errors.New("不能对自己进行好友操作")
+	ErrAlreadyFriends           = errors.New("对方已经是你的好友")
+	ErrNotFriends               = errors.New("对方不是你的好友")
+	ErrFriendRequestPending     = errors.New("已发送过好友申请，请等待对方处理")
+	ErrFriendRequestNotFound    = errors.New("好友申请不存在或已处理")
+	ErrFriendLimitReached       = errors.New("好友数量已达上限")
+	ErrFriendTargetLimitReached = errors.New("对方好友数量已达上限")
+	ErrFriendTargetBlocked      = errors.New("你已拉黑对方，请先取消拉黑")
+	ErrFriendRequestRejected    = errors.New("对方不接受你的好友申请")
+	ErrNotBlocked               = errors.New("你没有拉黑该用户")
+	ErrInvalidSearchKeyword     = errors.New("搜索关键字不能为空或只包含通配符")
+	ErrInvalidFriendDirection   = errors.New("direction参数仅支持incoming/outgoing")
+)
+
+// 好友申请状态
+const (
+	FriendRequestPending   = "pending"
+	FriendRequestAccepted  = "accepted"
+	FriendRequestDeclined  = "declined"
+	FriendRequestCancelled = "cancelled"
+)
+
+// FriendRepository 的写方法都在事务里锁住双方的用户行，同一对用户的操作和好友数上限检查按顺序执行
+// 涉及的用户不存在时返回ErrUserNotFound
+type FriendRepository interface {
+	// SendRequest 对方有待处理的反向申请时直接成为好友，第二个返回值为true
+	SendRequest(ctx context.Context, fromUserID, toUserID uint, message string, maxFriends int) (dto.FriendRequestData, bool, error)
+	// RespondRequest 只能处理发给userID的待处理申请，否则返回ErrFriendRequestNotFound
+	RespondRequest(ctx context.Context, userID, requestID uint, accept bool, maxFriends int) (dto.FriendRequestData, error)
+	// CancelRequest 只能取消userID自己发出的待处理申请
+	CancelRequest(ctx context.Context, userID, requestID uint) error
+	RemoveFriend(ctx context.Context, userID, friendID uint) error
+	// Block 同时解除好友关系并取消双方之间待处理的申请，重复拉黑不报错
+	Block(ctx context.Context, userID, targetID uint) error
+	Unblock(ctx context.Context, userID, targetID uint) error
+	ListFriends(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.FriendData, models.PageInfo, error)
+	// ListPendingRequests incoming为true时是发给userID的，否则是userID发出的
+	ListPendingRequests(ctx context.Context, userID uint, incoming bool, pagination models.Pagination) ([]dto.FriendRequestData, models.PageInfo, error)
+	ListBlocked(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.BlockedUserData, models.PageInfo, error)
+	// SearchUsers 按用户名模糊搜索，keyword已转义，结果不含自己和拉黑了自己的人
+	SearchUsers(ctx context.Context, userID uint, keyword string, pagination models.Pagination) ([]dto.PublicUserData, models.PageInfo, error)
+}
+
+type FriendService struct {
+	friendRepository FriendRepository
+	maxFriends       int
+	logger           *slog.Logger
+}
+
+// NewFriendService maxFriends为每个玩家的好友上限
+func NewFriendService(friendRepository FriendRepository, maxFriends int, logger *slog.Logger) *FriendService {
+	return &FriendService{friendRepository: friendRepository, maxFriends: maxFriends, logger: logger}
+}
+
+func (s *FriendService) SendRequest(ctx context.Context, userID uint, req dto.FriendRequestCreateRequest) (dto.FriendRequestData, bool, error) {
+	if userID == 0 {
+		return dto.FriendRequestData{}, false, ErrMissingUserContext
+	}
+	if userID == req.UserID {
+		return dto.FriendRequestData{}, false, ErrFriendSelf
+	}
+	data, accepted, err := s.friendRepository.SendRequest(ctx, userID, req.UserID, req.Message, s.maxFriends)
+	if err != nil {
+		return dto.FriendRequestData{}, false, err
+	}
+	if accepted {
+		s.logger.InfoContext(ctx, "双方互相申请，直接成为好友", "user_id", userID, "friend_id", req.UserID, "request_id", data.RequestID)
+	} else {
+		s.logger.InfoContext(ctx, "发送好友申请", "user_id", userID, "target_id", req.UserID, "request_id", data.RequestID)
+	}
+	return data, accepted, nil
+}
+
+func (s *FriendService) RespondRequest(ctx context.Context, userID, requestID uint, accept bool) (dto.FriendRequestData, error) {
+	if userID == 0 {
+		return dto.FriendRequestData{}, ErrMissingUserContext
+	}
+	data, err := s.friendRepository.RespondRequest(ctx, userID, requestID, accept, s.maxFriends)
+	if err != nil {
+		return dto.FriendRequestData{}, err
+	}
+	s.logger.InfoContext(ctx, "处理好友申请", "user_id", userID, "request_id", requestID, "accept", accept)
+	return data, nil
+}
+
+func (s *FriendService) CancelRequest(ctx context.Context, userID, requestID uint) error {
+	if userID == 0 {
+		return ErrMissingUserContext
+	}
+	return s.friendRepository.CancelRequest(ctx, userID, requestID)
+}
+
+func (s *FriendService) RemoveFriend(ctx context.Context, userID, friendID uint) error {
+	if userID == 0 {
+		return ErrMissingUserContext
+	}
+	if userID == friendID {
+		return ErrFriendSelf
+	}
+	if err := s.friendRepository.RemoveFriend(ctx, userID, friendID); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "删除好友", "user_id", userID, "friend_id", friendID)
+	return nil
+}
+
+func (s *FriendService) Block(ctx context.Context, userID, targetID uint) error {
+	if userID == 0 {
+		return ErrMissingUserContext
+	}
+	if userID == targetID {
+		return ErrFriendSelf
+	}
+	if err := s.friendRepository.Block(ctx, userID, targetID); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "拉黑用户", "user_id", userID, "target_id", targetID)
+	return nil
+}
+
+func (s *FriendService) Unblock(ctx context.Context, userID, targetID uint) error {
+	if userID == 0 {
+		return ErrMissingUserContext
+	}
+	if err := s.friendRepository.Unblock(ctx, userID, targetID); err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "取消拉黑", "user_id", userID, "target_id", targetID)
+	return nil
+}
+
+func (s *FriendService) ListFriends(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.FriendData, models.PageInfo, error) {
+	if userID == 0 {
+		return nil, models.PageInfo{}, ErrMissingUserContext
+	}
+	return s.friendRepository.ListFriends(ctx, userID, pagination)
+}
+
+// ListPendingRequests direction为空时默认incoming
+func (s *FriendService) ListPendingRequests(ctx context.Context, userID uint, direction string, pagination models.Pagination) ([]dto.FriendRequestData, models.PageInfo, error) {
+	if userID == 0 {
+		return nil, models.PageInfo{}, ErrMissingUserContext
+	}
+	var incoming bool
+	switch direction {
+	case "", "incoming":
+		incoming = true
+	case "outgoing":
+		incoming = false
+	default:
+		return nil, models.PageInfo{}, ErrInvalidFriendDirection
+	}
+	return s.friendRepository.ListPendingRequests(ctx, userID, incoming, pagination)
+}
+
+func (s *FriendService) ListBlocked(ctx context.Context, userID uint, pagination models.Pagination) ([]dto.BlockedUserData, models.PageInfo, error) {
+	if userID == 0 {
+		return nil, models.PageInfo{}, ErrMissingUserContext
+	}
+	return s.friendRepository.ListBlocked(ctx, userID, pagination)
+}
+
+func (s *FriendService) SearchUsers(ctx context.Context, userID uint, keyword string, pagination models.Pagination) ([]dto.PublicUserData, models.PageInfo, error) {
+	if userID == 0 {
+		return nil, models.PageInfo{}, ErrMissingUserContext
+	}
+	safeKeyword := utils.SqlSafeLikeKeyword(keyword)
+	if safeKeyword == "" {
+		return nil, models.PageInfo{}, ErrInvalidSearchKeyword
+	}
+	return s.friendRepository.SearchUsers(ctx, userID, safeKeyword, pagination)
+}
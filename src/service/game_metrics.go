@@ -0,0 +1,45 @@
+package service
+
+// 金币变化的来源
+const (
+	CoinSourcePlayer = "player"
+	CoinSourceAdmin  = "admin"
+)
+
+// GameMetrics 业务指标，实现不能阻塞调用方，只在操作成功提交后调用
+type GameMetrics interface {
+	Registered()
+	LoginSucceeded()
+	LoginFailed()
+	// CoinsChanged delta为正表示获得，为负表示消耗
+	CoinsChanged(coinType, source string, delta int64)
+	// Drew 服务端没有抽卡接口，客户端上报的抽卡货币减少一次记为一次抽卡
+	Drew()
+}
+
+type noopGameMetrics struct{}
+
+func (noopGameMetrics) Registered()                        {}
+func (noopGameMetrics) LoginSucceeded()                    {}
+func (noopGameMetrics) LoginFailed()                       {}
+func (noopGameMetrics) CoinsChanged(string, string, int64) {}
+func (noopGameMetrics) Drew()                              {}
+
+// recordPlayerCoinChange 玩家自己上报的金币变化，field为strength_coin/select_coin，select_coin减少记一次抽卡
+func recordPlayerCoinChange(metrics GameMetrics, field string, delta int64) {
+	if delta == 0 {
+		return
+	}
+	metrics.CoinsChanged(field, CoinSourcePlayer, delta)
+	if field == "select_coin" && delta < 0 {
+		metrics.Drew()
+	}
+}
+
+// gameMetricsOrNoop 命令行或关闭了指标时传nil
+func gameMetricsOrNoop(metrics GameMetrics) GameMetrics {
+	if metrics == nil {
+		return noopGameMetrics{}
+	}
+	return metrics
+}
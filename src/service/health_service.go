@@ -0,0 +1,152 @@
+package service
+
+import (
+	config "MuXi/2026-MuxiShooter-Backend/config"
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 依赖检查结果
+const (
+	HealthStatusOK    = "ok"
+	HealthStatusError = "error"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	// CheckMigrations 数据库版本落后时返回带版本信息的错误
+	CheckMigrations(ctx context.Context) error
+}
+
+type HealthService struct {
+	healthRepository HealthRepository
+	fileStorage      FileStorage
+	timeout          time.Duration
+	storageCacheTTL  time.Duration
+
+	storageMu    sync.Mutex
+	storageCheck *storageCheck
+}
+
+// storageCheck 一次存储检查，done关闭后err和checkedAt才可读
+type storageCheck struct {
+	done      chan struct{}
+	err       error
+	checkedAt time.Time
+}
+
+// NewHealthService timeout为每项检查的超时，storageCacheTTL为存储检查结果的缓存时间
+func NewHealthService(healthRepository HealthRepository, fileStorage FileStorage, timeout, storageCacheTTL time.Duration) *HealthService {
+	return &HealthService{
+		healthRepository: healthRepository,
+		fileStorage:      fileStorage,
+		timeout:          timeout,
+		storageCacheTTL:  storageCacheTTL,
+	}
+}
+
+// Readiness 并发检查数据库、迁移版本和上传存储，任意一项失败时整体为error
+// 数据库和迁移每次都实时检查，存储用缓存的结果
+func (s *HealthService) Readiness(ctx context.Context) dto.ReadinessData {
+	checks := map[string]func(ctx context.Context) error{
+		"database":   s.healthRepository.Ping,
+		"migrations": s.healthRepository.CheckMigrations,
+		"storage":    s.cachedStorageCheck,
+	}
+
+	data := dto.ReadinessData{Status: HealthStatusOK, Checks: make(map[string]dto.DependencyStatus, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Go(func() {
+			result := s.runCheck(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			data.Checks[name] = result
+			if result.Status != HealthStatusOK {
+				data.Status = HealthStatusError
+			}
+		})
+	}
+	wg.Wait()
+	return data
+}
+
+// runCheck 检查卡住时不等它，超时直接算失败
+func (s *HealthService) runCheck(ctx context.Context, check func(ctx context.Context) error) dto.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := dto.DependencyStatus{Status: HealthStatusOK, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = HealthStatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// cachedStorageCheck 同一时间最多一次真正的存储检查，结果在storageCacheTTL内复用，失败的结果也一样
+// 检查还没做完时后来的请求等同一次的结果，存储卡住时也不会越堆越多
+func (s *HealthService) cachedStorageCheck(ctx context.Context) error {
+	s.storageMu.Lock()
+	check := s.storageCheck
+	if check == nil || (isClosed(check.done) && time.Since(check.checkedAt) >= s.storageCacheTTL) {
+		check = &storageCheck{done: make(chan struct{})}
+		s.storageCheck = check
+		go func() {
+			check.err = s.checkStorage()
+			check.checkedAt = time.Now()
+			close(check.done)
+		}()
+	}
+	s.storageMu.Unlock()
+
+	select {
+	case <-check.done:
+		return check.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkStorage 写一个探测文件读回来再删掉，放在上传目录下才能测到真正存头像的位置
+func (s *HealthService) checkStorage() error {
+	key := config.UploadKeyPrefix + ".healthcheck/" + uuid.NewString()
+	if err := s.fileStorage.Put(key, []byte("ok"), "text/plain"); err != nil {
+		return err
+	}
+	data, err := s.fileStorage.Get(key)
+	if err == nil && string(data) != "ok" {
+		err = errors.New("读回的探测文件内容不一致")
+	}
+	if deleteErr := s.fileStorage.Delete(key); err == nil {
+		err = deleteErr
+	}
+	return err
+}
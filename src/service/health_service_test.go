@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeHealthRepository struct {
+	pings atomic.Int32
+}
+
+func (r *fakeHealthRepository) Ping(ctx context.Context) error {
+	r.pings.Add(1)
+	return nil
+}
+
+func (r *fakeHealthRepository) CheckMigrations(ctx context.Context) error {
+	return nil
+}
+
+// countingFileStorage 记录写入次数，release不为nil时Put要等它关闭才返回
+type countingFileStorage struct {
+	FileStorage
+	puts    atomic.Int32
+	release chan struct{}
+	err     error
+	mu      sync.Mutex
+	data    map[string][]byte
+}
+
+func (s *countingFileStorage) Put(key string, data []byte, contentType string) error {
+	s.puts.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		s.data = map[string][]byte{}
+	}
+	s.data[key] = data
+	return nil
+}
+
+func (s *countingFileStorage) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data[key], nil
+}
+
+func (s *countingFileStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func TestReadinessStorageCache(t *testing.T) {
+	ctx := context.Background()
+	errStorage := errors.New("存储不可用")
+
+	tests := []struct {
+		name       string
+		ttl        time.Duration
+		err        error
+		calls      int
+		wantPuts   int32
+		wantPings  int32
+		wantStatus string
+	}{
+		{"缓存期内只检查一次存储", time.Minute, nil, 3, 1, 3, HealthStatusOK},
+		{"失败的结果也缓存", time.Minute, errStorage, 3, 1, 3, HealthStatusError},
+		{"缓存过期后重新检查", 0, nil, 3, 3, 3, HealthStatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeHealthRepository{}
+			storage := &countingFileStorage{err: tt.err}
+			s := NewHealthService(repo, storage, time.Second, tt.ttl)
+
+			for range tt.calls {
+				if data := s.Readiness(ctx); data.Status != tt.wantStatus {
+					t.Fatalf("状态为%s，期望%s", data.Status, tt.wantStatus)
+				}
+			}
+			if got := storage.puts.Load(); got != tt.wantPuts {
+				t.Fatalf("存储检查了%d次，期望%d次", got, tt.wantPuts)
+			}
+			if got := repo.pings.Load(); got != tt.wantPings {
+				t.Fatalf("数据库检查了%d次，期望%d次", got, tt.wantPings)
+			}
+		})
+	}
+}
+
+func TestReadinessStorageCheckInFlight(t *testing.T) {
+	storage := &countingFileStorage{release: make(chan struct{})}
+	s := NewHealthService(&fakeHealthRepository{}, storage, 50*time.Millisecond, time.Minute)
+
+	//存储卡住时每次就绪检查都超时，但只有第一次真正去写存储
+	for range 3 {
+		data := s.Readiness(context.Background())
+		if data.Checks["storage"].Status != HealthStatusError {
+			t.Fatalf("存储检查状态为%s，期望超时失败", data.Checks["storage"].Status)
+		}
+	}
+	if got := storage.puts.Load(); got != 1 {
+		t.Fatalf("存储检查了%d次，期望1次", got)
+	}
+
+	//卡住的那次完成后，后面的请求直接用它的结果
+	close(storage.release)
+	deadline := time.Now().Add(time.Second)
+	for s.Readiness(context.Background()).Checks["storage"].Status != HealthStatusOK {
+		if time.Now().After(deadline) {
+			t.Fatal("存储恢复后就绪检查仍然失败")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := storage.puts.Load(); got != 1 {
+		t.Fatalf("存储检查了%d次，期望1次", got)
+	}
+}
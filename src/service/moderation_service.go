@@ -0,0 +1,144 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrModerationRecordNotFound = errors.New("审核记录不存在或已处理")
+	ErrInvalidModerationStatus  = errors.New("status参数仅支持pending/approved/rejected")
+	ErrInvalidModerationField   = errors.New("field参数仅支持username/head_image")
+)
+
+// 审核的字段
+const (
+	ModerationFieldUsername  = "username"
+	ModerationFieldHeadImage = "head_image"
+)
+
+// 审核记录状态
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// EventModerationRejected 用户名/头像被驳回，客户端需要重新拉取资料
+const EventModerationRejected = "moderation_rejected"
+
+// ModerationOptions 用户名/头像审核相关的配置，零值表示不审核、不过滤敏感词
+type ModerationOptions struct {
+	//为true时改用户名、上传头像会生成待审核记录，修改先生效
+	Enabled        bool
+	UsernameFilter *UsernameFilter
+}
+
+// ModerationRepository 的Approve/Reject先锁用户行再锁审核记录，和用户修改资料的事务加锁顺序一致
+// 记录不存在或已经处理过时返回ErrModerationRecordNotFound
+type ModerationRepository interface {
+	// List status必填，field为空表示不限，userID为0表示不限
+	List(ctx context.Context, status, field string, userID uint, pagination models.Pagination) ([]models.ModerationRecord, models.PageInfo, error)
+	// Approve 头像记录持有的旧头像引用在这里释放
+	Approve(ctx context.Context, moderationID, reviewerID uint) (*models.ModerationRecord, error)
+	// Reject 用户当前的值还是待审核的值时恢复成PreviousValue，第二个返回值为是否恢复了
+	// 原用户名已被别人占用时改成DefaultUsername，也被占用时返回ErrUsernameTaken
+	Reject(ctx context.Context, moderationID, reviewerID uint, reason string) (*models.ModerationRecord, bool, error)
+}
+
+type ModerationService struct {
+	moderationRepository ModerationRepository
+	eventPublisher       EventPublisher
+	logger               *slog.Logger
+}
+
+// NewModerationService eventPublisher可以传nil，此时不推送事件
+func NewModerationService(moderationRepository ModerationRepository, eventPublisher EventPublisher, logger *slog.Logger) *ModerationService {
+	return &ModerationService{
+		moderationRepository: moderationRepository,
+		eventPublisher:       eventPublisherOrNoop(eventPublisher),
+		logger:               logger,
+	}
+}
+
+// List status为空时只看待审核的
+func (s *ModerationService) List(ctx context.Context, status, field string, userID uint, pagination models.Pagination) ([]dto.ModerationRecordData, models.PageInfo, error) {
+	switch status {
+	case "":
+		status = ModerationPending
+	case ModerationPending, ModerationApproved, ModerationRejected:
+	default:
+		return nil, models.PageInfo{}, ErrInvalidModerationStatus
+	}
+	switch field {
+	case "", ModerationFieldUsername, ModerationFieldHeadImage:
+	default:
+		return nil, models.PageInfo{}, ErrInvalidModerationField
+	}
+
+	records, info, err := s.moderationRepository.List(ctx, status, field, userID, pagination)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+	list := make([]dto.ModerationRecordData, 0, len(records))
+	for i := range records {
+		list = append(list, buildModerationRecordData(&records[i]))
+	}
+	return list, info, nil
+}
+
+func (s *ModerationService) Approve(ctx context.Context, reviewerID, moderationID uint) (dto.ModerationRecordData, error) {
+	record, err := s.moderationRepository.Approve(ctx, moderationID, reviewerID)
+	if err != nil {
+		return dto.ModerationRecordData{}, err
+	}
+	return buildModerationRecordData(record), nil
+}
+
+func (s *ModerationService) Reject(ctx context.Context, reviewerID uint, req dto.AdminModerationRejectRequest) (dto.ModerationRejectData, error) {
+	record, reverted, err := s.moderationRepository.Reject(ctx, req.ModerationID, reviewerID, req.Reason)
+	if err != nil {
+		return dto.ModerationRejectData{}, err
+	}
+	if !reverted {
+		s.logger.InfoContext(ctx, "驳回时用户已经改成了别的值，不恢复原值", "moderation_id", record.ID, "user_id", record.UserID, "field", record.Field)
+	}
+	s.eventPublisher.Publish(record.UserID, newPushEvent(EventModerationRejected, dto.ModerationEventData{
+		Field:    record.Field,
+		Reason:   record.Reason,
+		Reverted: reverted,
+	}))
+	return dto.ModerationRejectData{Record: buildModerationRecordData(record), Reverted: reverted}, nil
+}
+
+// DefaultUsername 管理员重置用户名、驳回后原用户名已被占用时使用的用户名
+func DefaultUsername(userID uint) string {
+	return fmt.Sprintf("player_%d", userID)
+}
+
+func buildModerationRecordData(record *models.ModerationRecord) dto.ModerationRecordData {
+	data := dto.ModerationRecordData{
+		ModerationID:  record.ID,
+		UserID:        record.UserID,
+		Field:         record.Field,
+		Status:        record.Status,
+		PreviousValue: record.PreviousValue,
+		NewValue:      record.NewValue,
+		Reason:        record.Reason,
+		ReviewerID:    record.ReviewerID,
+		ReviewedAt:    record.ReviewedAt,
+		CreatedAt:     record.CreatedAt,
+		UpdatedAt:     record.UpdatedAt,
+	}
+	if record.Field == ModerationFieldHeadImage {
+		data.PreviousValue = dto.FileURL(record.PreviousValue)
+		data.PreviousHeadThumbnails = dto.FileURLs(record.PreviousHeadThumbnails)
+		data.NewValue = dto.FileURL(record.NewValue)
+		data.NewHeadThumbnails = dto.FileURLs(record.NewHeadThumbnails)
+	}
+	return data
+}
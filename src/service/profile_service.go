@@ -4,12 +4,17 @@ import (
 	config "MuXi/2026-MuxiShooter-Backend/config"
 	"MuXi/2026-MuxiShooter-Backend/dto"
 	"MuXi/2026-MuxiShooter-Backend/models"
+	"MuXi/2026-MuxiShooter-Backend/utils"
+	"context"
 	"errors"
+	"log/slog"
 	"time"
 )
 
 var (
 	ErrMissingUserContext         = errors.New("解析后token中缺少用户信息")
+	ErrPreconditionFailed         = errors.New("记录已被修改，请重新获取后再更新")
+	ErrVersionConflict            = errors.New("数据已被其他请求修改，请刷新后重试")
 	ErrPasswordTooFrequent        = errors.New("修改密码间隔过短")
 	ErrUsernameTooFrequent        = errors.New("修改用户名间隔过短")
 	ErrHeadImageTooFrequent       = errors.New("修改头像间隔过短")
@@ -46,55 +51,107 @@ func ParseUserRelationType(val string) (UserRelationType, error) {
 }
 
 type ProfileUserRepository interface {
-	FindByID(userID uint) (*models.User, bool, error)
-	UpdatePassword(userID uint, hashedPassword string, updatedAt time.Time) error
-	UpdateUsername(userID uint, newUsername string, updatedAt time.Time) error
-	UpdateHeadImage(userID uint, newHeadImagePath string, updatedAt time.Time) error
-	UpdateCoinByField(userID uint, field string, coin uint) error
-	IncrementTokenVersion(userID uint) error
+	FindByID(ctx context.Context, userID uint) (*models.User, bool, error)
+	//以下Update*都是带版本号的条件更新，version为读到的版本号，被别人抢先修改时返回ErrVersionConflict
+	UpdatePassword(ctx context.Context, userID uint, hashedPassword string, updatedAt time.Time, version uint64) error
+	//moderate为true时在同一个事务里生成/更新待审核记录
+	UpdateUsername(ctx context.Context, userID uint, newUsername string, updatedAt time.Time, version uint64, moderate bool) error
+	// UpdateHeadImage 同时释放旧头像文件的引用，updatedAt为nil时不更新头像修改时间(选用预设头像)
+	UpdateHeadImage(ctx context.Context, userID uint, newHeadImagePath string, thumbnails models.HeadImageThumbnails, updatedAt *time.Time, version uint64, moderate bool) error
+	UpdateCoinByField(ctx context.Context, userID uint, field string, coin uint, version uint64) error
+	IncrementTokenVersion(ctx context.Context, userID uint) error
+	// FindStamp 只查版本号和更新时间，不加载整行
+	FindStamp(ctx context.Context, userID uint) (ContentStamp, bool, error)
 }
 
 type ProfileRelationRepository interface {
-	CreateUserRelation(userID uint, relationType UserRelationType, resourceID uint) (dto.CommonUserRelationData, error)
-	UpdateUserRelation(userID uint, relationType UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error)
-	DeleteUserRelation(userID uint, relationType UserRelationType, resourceID uint) error
-	QueryUserRelationsByType(userID uint, relationType UserRelationType, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error)
+	CreateUserRelation(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) (dto.CommonUserRelationData, error)
+	UpdateUserRelation(ctx context.Context, userID uint, relationType UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error)
+	DeleteUserRelation(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) error
+	QueryUserRelationsByType(ctx context.Context, userID uint, relationType UserRelationType, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error)
+	// RelationListStamp 返回用户某类关联的数量和最后修改时间，删除没有updated_at，按变更流水的时间算
+	RelationListStamp(ctx context.Context, userID uint, relationType UserRelationType) (ContentStamp, error)
 }
 
+// ContentStamp 不加载内容就能查到的版本信息，条件请求用它在查询前判断内容有没有变
+type ContentStamp struct {
+	Version uint64
+	//列表的记录数，删除记录时会变
+	Count     int64
+	UpdatedAt time.Time
+}
+
+// ProfileLimits 用户自己修改资料的最小间隔，零值表示不限制
+type ProfileLimits struct {
+	PasswordUpdateInterval  time.Duration
+	UsernameUpdateInterval  time.Duration
+	HeadImageUpdateInterval time.Duration
+}
+
+// exportBatchSize 导出用户数据时每次查询的关联数量
+const exportBatchSize = 100
+
 type ProfileService struct {
-	userRepository     ProfileUserRepository
-	relationRepository ProfileRelationRepository
-	passwordHasher     PasswordHasher
+	userRepository       ProfileUserRepository
+	relationRepository   ProfileRelationRepository
+	passwordHasher       PasswordHasher
+	fileStorage          FileStorage
+	storedFileRepository StoredFileRepository
+	eventPublisher       EventPublisher
+	moderation           ModerationOptions
+	limits               ProfileLimits
+	metrics              GameMetrics
+	logger               *slog.Logger
 }
 
-func NewProfileService(userRepository ProfileUserRepository, relationRepository ProfileRelationRepository, passwordHasher PasswordHasher) *ProfileService {
+// NewProfileService eventPublisher可以传nil，此时不推送事件；metrics可以传nil，此时不记录指标
+// ProfileServiceOptions 按场景可选的依赖，EventPublisher/Metrics为nil时不推事件、不记指标
+// 导出数据这类不涉及头像的场景可以不传FileStorage和StoredFileRepository
+type ProfileServiceOptions struct {
+	FileStorage          FileStorage
+	StoredFileRepository StoredFileRepository
+	EventPublisher       EventPublisher
+	Moderation           ModerationOptions
+	Limits               ProfileLimits
+	Metrics              GameMetrics
+}
+
+func NewProfileService(userRepository ProfileUserRepository, relationRepository ProfileRelationRepository, passwordHasher PasswordHasher, options ProfileServiceOptions, logger *slog.Logger) *ProfileService {
 	return &ProfileService{
-		userRepository:     userRepository,
-		relationRepository: relationRepository,
-		passwordHasher:     passwordHasher,
+		userRepository:       userRepository,
+		relationRepository:   relationRepository,
+		passwordHasher:       passwordHasher,
+		fileStorage:          options.FileStorage,
+		storedFileRepository: options.StoredFileRepository,
+		eventPublisher:       eventPublisherOrNoop(options.EventPublisher),
+		moderation:           options.Moderation,
+		limits:               options.Limits,
+		metrics:              gameMetricsOrNoop(options.Metrics),
+		logger:               logger,
 	}
 }
 
-func (s *ProfileService) Logout(userID uint) error {
-	_, existed, err := s.userRepository.FindByID(userID)
+func (s *ProfileService) Logout(ctx context.Context, userID uint) error {
+	_, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
 	if !existed {
 		return ErrUserNotFound
 	}
-	if err = s.userRepository.IncrementTokenVersion(userID); err != nil {
+	if err = s.userRepository.IncrementTokenVersion(ctx, userID); err != nil {
 		return err
 	}
+	s.eventPublisher.Publish(userID, forcedLogoutEvent("账号已登出"))
 	return nil
 }
 
-func (s *ProfileService) UpdatePassword(userID uint, req dto.UpdatePasswordRequest) error {
+func (s *ProfileService) UpdatePassword(ctx context.Context, userID uint, req dto.UpdatePasswordRequest) error {
 	if req.NewPassword == req.OldPassword {
 		return ErrSamePassword
 	}
 
-	user, existed, err := s.userRepository.FindByID(userID)
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -102,7 +159,7 @@ func (s *ProfileService) UpdatePassword(userID uint, req dto.UpdatePasswordReque
 		return ErrUserNotFound
 	}
 
-	if user.PasswordUpdatedAt != nil && time.Since(*user.PasswordUpdatedAt) <= config.PasswordUpdatedInterval {
+	if user.PasswordUpdatedAt != nil && time.Since(*user.PasswordUpdatedAt) <= s.limits.PasswordUpdateInterval {
 		return ErrPasswordTooFrequent
 	}
 
@@ -116,72 +173,96 @@ func (s *ProfileService) UpdatePassword(userID uint, req dto.UpdatePasswordReque
 	}
 
 	now := time.Now()
-	if err = s.userRepository.UpdatePassword(userID, hashedPassword, now); err != nil {
+	if err = s.userRepository.UpdatePassword(ctx, userID, hashedPassword, now, user.Version); err != nil {
 		return err
 	}
 
-	_ = s.userRepository.IncrementTokenVersion(userID)
+	s.logger.InfoContext(ctx, "用户修改密码", "user_id", userID)
+	//密码已经改了，吊销失败也不回滚，旧token到期前仍然有效
+	if err = s.userRepository.IncrementTokenVersion(ctx, userID); err != nil {
+		s.logger.ErrorContext(ctx, "修改密码后吊销旧token失败", "user_id", userID, "error", err)
+	}
+	s.eventPublisher.Publish(userID, forcedLogoutEvent("密码已修改，请重新登录"))
 	return nil
 }
 
-func (s *ProfileService) UpdateUsername(userID uint, req dto.UpdateUsernameRequest) error {
-	user, existed, err := s.userRepository.FindByID(userID)
+// UpdateUsername 第一个返回值为是否进入了审核队列
+func (s *ProfileService) UpdateUsername(ctx context.Context, userID uint, req dto.UpdateUsernameRequest) (bool, error) {
+	if err := s.moderation.UsernameFilter.Check(req.NewUsername); err != nil {
+		return false, err
+	}
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if !existed || user == nil {
-		return ErrUserNotFound
+		return false, ErrUserNotFound
 	}
 
-	if user.UsernameUpdatedAt != nil && time.Since(*user.UsernameUpdatedAt) <= config.UsernameUpdatedInterval {
-		return ErrUsernameTooFrequent
+	if err = checkExpectedVersion(req.Version, user.Version); err != nil {
+		return false, err
+	}
+	if user.UsernameUpdatedAt != nil && time.Since(*user.UsernameUpdatedAt) <= s.limits.UsernameUpdateInterval {
+		return false, ErrUsernameTooFrequent
 	}
 
 	now := time.Now()
-	if err = s.userRepository.UpdateUsername(userID, req.NewUsername, now); err != nil {
-		return err
+	if err = s.userRepository.UpdateUsername(ctx, userID, req.NewUsername, now, user.Version, s.moderation.Enabled); err != nil {
+		return false, err
 	}
+	s.logger.InfoContext(ctx, "用户修改用户名", "user_id", userID, "from", user.Username, "to", req.NewUsername, "moderated", s.moderation.Enabled)
 
-	return nil
+	return s.moderation.Enabled, nil
 }
 
-func (s *ProfileService) UpdateHeadImage(userID uint, newHeadImagePath string) (string, error) {
-	user, existed, err := s.userRepository.FindByID(userID)
+// UpdateHeadImage 检查通过后才写存储，旧头像的引用在更新用户的事务里释放，文件由垃圾回收删除
+func (s *ProfileService) UpdateHeadImage(ctx context.Context, userID uint, processed *utils.ProcessedImage, expectedVersion *uint64) (dto.HeadImageData, error) {
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
-		return "", err
+		return dto.HeadImageData{}, err
 	}
 	if !existed || user == nil {
-		return "", ErrUserNotFound
+		return dto.HeadImageData{}, ErrUserNotFound
+	}
+	if err = checkExpectedVersion(expectedVersion, user.Version); err != nil {
+		return dto.HeadImageData{}, err
+	}
+
+	if user.HeadImageUpdatedAt != nil && time.Since(*user.HeadImageUpdatedAt) <= s.limits.HeadImageUpdateInterval {
+		return dto.HeadImageData{}, ErrHeadImageTooFrequent
 	}
 
-	if user.HeadImageUpdatedAt != nil && time.Since(*user.HeadImageUpdatedAt) <= config.HeadImageUpdatedInterval {
-		return "", ErrHeadImageTooFrequent
+	headImagePath, thumbnails, keys, err := storeHeadImage(ctx, s.fileStorage, s.storedFileRepository, processed, s.logger)
+	if err != nil {
+		return dto.HeadImageData{}, err
 	}
 
 	now := time.Now()
-	if err = s.userRepository.UpdateHeadImage(userID, newHeadImagePath, now); err != nil {
-		return "", err
+	if err = s.userRepository.UpdateHeadImage(ctx, userID, headImagePath, thumbnails, &now, user.Version, s.moderation.Enabled); err != nil {
+		if releaseErr := s.storedFileRepository.Release(ctx, keys); releaseErr != nil {
+			s.logger.WarnContext(ctx, "撤销头像文件引用失败，引用计数等垃圾回收修正", "keys", keys, "error", releaseErr)
+		}
+		return dto.HeadImageData{}, err
 	}
 
-	return user.HeadImagePath, nil
+	return dto.HeadImageData{
+		HeadImagePath:       dto.FileURL(headImagePath),
+		HeadImageThumbnails: dto.FileURLs(thumbnails),
+		PendingReview:       s.moderation.Enabled,
+	}, nil
 }
 
-func (s *ProfileService) UpdateCoinByType(userID uint, coinType string, coin uint) (dto.CommonUserData, error) {
+func (s *ProfileService) UpdateCoinByType(ctx context.Context, userID uint, coinType string, coin uint, expectedVersion *uint64) (dto.CommonUserData, error) {
 	if coinType == "" {
 		return dto.CommonUserData{}, ErrMissingCoinType
 	}
 
-	updateField := ""
-	switch coinType {
-	case "strength", "strength_coin":
-		updateField = "strength_coin"
-	case "select", "select_coin":
-		updateField = "select_coin"
-	default:
-		return dto.CommonUserData{}, ErrUnsupportedCoinType
+	updateField, err := coinFieldByType(coinType)
+	if err != nil {
+		return dto.CommonUserData{}, err
 	}
 
-	user, existed, err := s.userRepository.FindByID(userID)
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
 		return dto.CommonUserData{}, err
 	}
@@ -189,65 +270,170 @@ func (s *ProfileService) UpdateCoinByType(userID uint, coinType string, coin uin
 		return dto.CommonUserData{}, ErrUserNotFound
 	}
 
-	if err = s.userRepository.UpdateCoinByField(userID, updateField, coin); err != nil {
+	if err = checkExpectedVersion(expectedVersion, user.Version); err != nil {
+		return dto.CommonUserData{}, err
+	}
+	if err = s.userRepository.UpdateCoinByField(ctx, userID, updateField, coin, user.Version); err != nil {
 		return dto.CommonUserData{}, err
 	}
+	user.Version++
 
+	var previous uint
 	if updateField == "strength_coin" {
-		user.StrengthCoin = coin
+		previous, user.StrengthCoin = user.StrengthCoin, coin
 	} else {
-		user.SelectCoin = coin
+		previous, user.SelectCoin = user.SelectCoin, coin
 	}
+	recordPlayerCoinChange(s.metrics, updateField, int64(coin)-int64(previous))
+
+	data := dto.CommonUserData{
+		UserID:              user.ID,
+		Username:            user.Username,
+		Group:               user.Group,
+		HeadImagePath:       dto.FileURL(user.HeadImagePath),
+		HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+		StrengthCoin:        user.StrengthCoin,
+		SelectCoin:          user.SelectCoin,
+		Version:             user.Version,
+	}
+	s.eventPublisher.Publish(userID, coinsChangedEvent(data))
+	return data, nil
+}
 
-	return dto.CommonUserData{
-		UserID:        user.ID,
-		Username:      user.Username,
-		Group:         user.Group,
-		HeadImagePath: user.HeadImagePath,
-		StrengthCoin:  user.StrengthCoin,
-		SelectCoin:    user.SelectCoin,
-	}, nil
+// checkExpectedVersion 客户端带了版本号时先比对一次，不带则只靠条件更新防止并发覆盖
+func checkExpectedVersion(expected *uint64, current uint64) error {
+	if expected != nil && *expected != current {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func coinFieldByType(coinType string) (string, error) {
+	switch coinType {
+	case "strength", "strength_coin":
+		return "strength_coin", nil
+	case "select", "select_coin":
+		return "select_coin", nil
+	default:
+		return "", ErrUnsupportedCoinType
+	}
 }
 
-func (s *ProfileService) CreateSelfRelationByType(userID uint, relationType UserRelationType, resourceID uint) (dto.CommonUserRelationData, error) {
-	return s.relationRepository.CreateUserRelation(userID, relationType, resourceID)
+func (s *ProfileService) CreateSelfRelationByType(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) (dto.CommonUserRelationData, error) {
+	data, err := s.relationRepository.CreateUserRelation(ctx, userID, relationType, resourceID)
+	if err != nil {
+		return data, err
+	}
+	s.eventPublisher.Publish(userID, relationEvent(EventRelationCreated, relationType, resourceID, &data))
+	return data, nil
 }
 
-func (s *ProfileService) UpdateSelfRelationByType(userID uint, relationType UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error) {
-	return s.relationRepository.UpdateUserRelation(userID, relationType, req)
+func (s *ProfileService) UpdateSelfRelationByType(ctx context.Context, userID uint, relationType UserRelationType, req dto.UserRelationUpdateRequest) (dto.CommonUserRelationData, error) {
+	data, err := s.relationRepository.UpdateUserRelation(ctx, userID, relationType, req)
+	if err != nil {
+		return data, err
+	}
+	s.eventPublisher.Publish(userID, relationEvent(EventRelationUpdated, relationType, req.ResourceID, &data))
+	return data, nil
 }
 
-func (s *ProfileService) DeleteSelfRelationByType(userID uint, relationType UserRelationType, resourceID uint) error {
-	return s.relationRepository.DeleteUserRelation(userID, relationType, resourceID)
+func (s *ProfileService) DeleteSelfRelationByType(ctx context.Context, userID uint, relationType UserRelationType, resourceID uint) error {
+	if err := s.relationRepository.DeleteUserRelation(ctx, userID, relationType, resourceID); err != nil {
+		return err
+	}
+	s.eventPublisher.Publish(userID, relationEvent(EventRelationDeleted, relationType, resourceID, nil))
+	return nil
 }
 
-func (s *ProfileService) GetSelfProfile(userID uint) (dto.CommonUserData, error) {
-	user, existed, err := s.userRepository.FindByID(userID)
+// GetSelfProfile 第二个返回值是用户记录的更新时间，用作Last-Modified
+func (s *ProfileService) GetSelfProfile(ctx context.Context, userID uint) (dto.CommonUserData, time.Time, error) {
+	user, existed, err := s.userRepository.FindByID(ctx, userID)
 	if err != nil {
-		return dto.CommonUserData{}, err
+		return dto.CommonUserData{}, time.Time{}, err
 	}
 	if !existed || user == nil {
-		return dto.CommonUserData{}, ErrUserNotFound
+		return dto.CommonUserData{}, time.Time{}, ErrUserNotFound
 	}
 
 	return dto.CommonUserData{
-		UserID:        user.ID,
-		Username:      user.Username,
-		Group:         user.Group,
-		HeadImagePath: user.HeadImagePath,
-		StrengthCoin:  user.StrengthCoin,
-		SelectCoin:    user.SelectCoin,
-	}, nil
+		UserID:              user.ID,
+		Username:            user.Username,
+		Group:               user.Group,
+		HeadImagePath:       dto.FileURL(user.HeadImagePath),
+		HeadImageThumbnails: dto.FileURLs(user.HeadImageThumbnails),
+		StrengthCoin:        user.StrengthCoin,
+		SelectCoin:          user.SelectCoin,
+		Version:             user.Version,
+	}, user.UpdatedAt, nil
 }
 
-func (s *ProfileService) GetSelfRelationsByType(userID uint, relationTypeStr string, pagination models.Pagination) ([]dto.CommonUserRelationData, int64, error) {
+// GetSelfProfileStamp 和GetSelfProfile返回的内容对应，内容变了版本号或更新时间一定会变
+func (s *ProfileService) GetSelfProfileStamp(ctx context.Context, userID uint) (ContentStamp, error) {
+	stamp, existed, err := s.userRepository.FindStamp(ctx, userID)
+	if err != nil {
+		return ContentStamp{}, err
+	}
+	if !existed {
+		return ContentStamp{}, ErrUserNotFound
+	}
+	return stamp, nil
+}
+
+// GetSelfRelationsStamp 和GetSelfRelationsByType返回的内容对应，不区分分页参数
+func (s *ProfileService) GetSelfRelationsStamp(ctx context.Context, userID uint, relationTypeStr string) (ContentStamp, error) {
 	if relationTypeStr == "" {
-		return nil, 0, ErrMissingRelationType
+		return ContentStamp{}, ErrMissingRelationType
 	}
 	relationType, err := ParseUserRelationType(relationTypeStr)
 	if err != nil {
-		return nil, 0, err
+		return ContentStamp{}, err
+	}
+	return s.relationRepository.RelationListStamp(ctx, userID, relationType)
+}
+
+func (s *ProfileService) GetSelfRelationsByType(ctx context.Context, userID uint, relationTypeStr string, pagination models.Pagination) ([]dto.CommonUserRelationData, models.PageInfo, error) {
+	if relationTypeStr == "" {
+		return nil, models.PageInfo{}, ErrMissingRelationType
+	}
+	relationType, err := ParseUserRelationType(relationTypeStr)
+	if err != nil {
+		return nil, models.PageInfo{}, err
+	}
+
+	return s.relationRepository.QueryUserRelationsByType(ctx, userID, relationType, pagination)
+}
+
+// ExportUserData 导出用户的基础信息和全部关联数据，供运维导出/排查使用
+func (s *ProfileService) ExportUserData(ctx context.Context, userID uint) (dto.UserExportData, error) {
+	user, _, err := s.GetSelfProfile(ctx, userID)
+	if err != nil {
+		return dto.UserExportData{}, err
+	}
+
+	relationTypes := []UserRelationType{UserRelationAchievement, UserRelationSkill, UserRelationItem, UserRelationCard}
+	relations := make(map[string][]dto.CommonUserRelationData, len(relationTypes))
+	for _, relationType := range relationTypes {
+		all := make([]dto.CommonUserRelationData, 0)
+		//用游标翻页，不需要每页都COUNT
+		pagination := models.Pagination{
+			Page:      config.DefaultPage,
+			PageSize:  exportBatchSize,
+			Limit:     exportBatchSize,
+			UseCursor: true,
+		}
+		for {
+			list, info, err := s.relationRepository.QueryUserRelationsByType(ctx, userID, relationType, pagination)
+			if err != nil {
+				return dto.UserExportData{}, err
+			}
+			all = append(all, list...)
+			if info.Next == nil {
+				break
+			}
+			pagination.After = info.Next
+		}
+		relations[string(relationType)] = all
 	}
 
-	return s.relationRepository.QueryUserRelationsByType(userID, relationType, pagination)
+	return dto.UserExportData{User: user, Relations: relations}, nil
 }
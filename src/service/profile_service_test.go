@@ -0,0 +1,85 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+)
+
+// fakeProfileUserRepository 只实现改金币用到的方法，version不一致时和gorm实现一样返回ErrVersionConflict
+type fakeProfileUserRepository struct {
+	ProfileUserRepository
+	user    models.User
+	updates int
+}
+
+func (r *fakeProfileUserRepository) FindByID(ctx context.Context, userID uint) (*models.User, bool, error) {
+	if userID != r.user.ID {
+		return nil, false, nil
+	}
+	user := r.user
+	return &user, true, nil
+}
+
+func (r *fakeProfileUserRepository) UpdateCoinByField(ctx context.Context, userID uint, field string, coin uint, version uint64) error {
+	if version != r.user.Version {
+		return ErrVersionConflict
+	}
+	r.updates++
+	r.user.Version++
+	return nil
+}
+
+// racingProfileUserRepository 在读出用户之后模拟另一个请求抢先改掉了这一行
+type racingProfileUserRepository struct {
+	*fakeProfileUserRepository
+}
+
+func (r *racingProfileUserRepository) FindByID(ctx context.Context, userID uint) (*models.User, bool, error) {
+	user, existed, err := r.fakeProfileUserRepository.FindByID(ctx, userID)
+	r.user.Version++
+	return user, existed, err
+}
+
+func TestUpdateCoinByTypeVersion(t *testing.T) {
+	version := func(v uint64) *uint64 { return &v }
+
+	tests := []struct {
+		name            string
+		expectedVersion *uint64
+		//读到用户之后、写入之前被别的请求改掉
+		concurrentWrite bool
+		wantErr         error
+		wantVersion     uint64
+		wantUpdates     int
+	}{
+		{"不带版本号", nil, false, nil, 6, 1},
+		{"版本号一致", version(5), false, nil, 6, 1},
+		{"版本号过期", version(4), false, ErrVersionConflict, 5, 0},
+		{"不带版本号但被并发修改", nil, true, ErrVersionConflict, 6, 0},
+		{"版本号一致但被并发修改", version(5), true, ErrVersionConflict, 6, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeProfileUserRepository{user: models.User{ID: 1, SelectCoin: 10, Version: 5}}
+			s := NewProfileService(repo, nil, nil, ProfileServiceOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.concurrentWrite {
+				s.userRepository = &racingProfileUserRepository{repo}
+			}
+
+			data, err := s.UpdateCoinByType(context.Background(), 1, "select", 7, tt.expectedVersion)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if repo.user.Version != tt.wantVersion || repo.updates != tt.wantUpdates {
+				t.Fatalf("存储的版本号为%d、写入%d次，期望%d、%d次", repo.user.Version, repo.updates, tt.wantVersion, tt.wantUpdates)
+			}
+			if err == nil && (data.Version != tt.wantVersion || data.SelectCoin != 7) {
+				t.Fatalf("返回%+v，期望版本号%d、select_coin 7", data, tt.wantVersion)
+			}
+		})
+	}
+}
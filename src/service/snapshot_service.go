@@ -0,0 +1,131 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	ErrSnapshotRevisionConflict = errors.New("存档已在其他地方被修改，请重新拉取后再保存")
+	ErrSnapshotDuplicateEntry   = errors.New("存档中同一类型的资源重复")
+	ErrSnapshotResourceNotFound = errors.New("存档中的资源不存在")
+)
+
+// 变更流水的实体和操作，关联的实体就是关联类型本身
+const (
+	ChangeEntityProfile = "profile"
+	ChangeOpUpsert      = "upsert"
+	ChangeOpDelete      = "delete"
+)
+
+// CoinDeltas 保存存档时金币的变化，key为strength_coin/select_coin，没变的不出现
+type CoinDeltas map[string]int64
+
+// SnapshotPatch 校验过的存档，nil的金币和没出现的关联类型不修改
+type SnapshotPatch struct {
+	StrengthCoin *uint
+	SelectCoin   *uint
+	Relations    map[UserRelationType][]dto.SnapshotRelationEntry
+}
+
+type SnapshotRepository interface {
+	// LoadSnapshot 在同一个事务里读出用户和全部关联，保证和revision对应
+	LoadSnapshot(ctx context.Context, userID uint) (dto.ProfileSnapshotData, error)
+	// ApplySnapshot 锁住用户行比对revision，一致时把patch和当前状态的差异写进去，有变化时revision+1
+	// revision不一致返回ErrSnapshotRevisionConflict，返回值为保存后的完整存档和这次金币的变化
+	ApplySnapshot(ctx context.Context, userID uint, baseRevision uint64, patch SnapshotPatch) (dto.ProfileSnapshotData, CoinDeltas, error)
+	// LoadChanges 返回since之后变过的实体的当前状态，已删除的关联返回墓碑
+	// 变过的实体超过limit个时只返回full_resync=true
+	LoadChanges(ctx context.Context, userID uint, since uint64, limit int) (dto.ProfileChangesData, error)
+}
+
+type SnapshotService struct {
+	snapshotRepository    SnapshotRepository
+	maxChangeFeedEntities int
+	metrics               GameMetrics
+	logger                *slog.Logger
+}
+
+// NewSnapshotService maxChangeFeedEntities为增量同步一次最多返回的实体数；metrics可以传nil，此时不记录指标
+func NewSnapshotService(snapshotRepository SnapshotRepository, maxChangeFeedEntities int, metrics GameMetrics, logger *slog.Logger) *SnapshotService {
+	return &SnapshotService{
+		snapshotRepository:    snapshotRepository,
+		maxChangeFeedEntities: maxChangeFeedEntities,
+		metrics:               gameMetricsOrNoop(metrics),
+		logger:                logger,
+	}
+}
+
+func (s *SnapshotService) GetSnapshot(ctx context.Context, userID uint) (dto.ProfileSnapshotData, error) {
+	if userID == 0 {
+		return dto.ProfileSnapshotData{}, ErrMissingUserContext
+	}
+	return s.snapshotRepository.LoadSnapshot(ctx, userID)
+}
+
+// GetChanges since为客户端上次同步拿到的revision，客户端应用完返回的变更后把revision记为新的since
+func (s *SnapshotService) GetChanges(ctx context.Context, userID uint, since uint64) (dto.ProfileChangesData, error) {
+	if userID == 0 {
+		return dto.ProfileChangesData{}, ErrMissingUserContext
+	}
+	changes, err := s.snapshotRepository.LoadChanges(ctx, userID, since, s.maxChangeFeedEntities)
+	if err != nil {
+		return dto.ProfileChangesData{}, err
+	}
+	if changes.FullResync {
+		s.logger.InfoContext(ctx, "增量同步无法覆盖，要求客户端重新拉取存档", "user_id", userID, "since", since, "revision", changes.Revision)
+	}
+	return changes, nil
+}
+
+func (s *SnapshotService) SaveSnapshot(ctx context.Context, userID uint, req dto.ProfileSnapshotRequest) (dto.ProfileSnapshotData, error) {
+	if userID == 0 {
+		return dto.ProfileSnapshotData{}, ErrMissingUserContext
+	}
+	if req.StrengthCoin == nil && req.SelectCoin == nil && len(req.Relations) == 0 {
+		return dto.ProfileSnapshotData{}, ErrNoUpdateFields
+	}
+
+	patch := SnapshotPatch{
+		StrengthCoin: req.StrengthCoin,
+		SelectCoin:   req.SelectCoin,
+		Relations:    make(map[UserRelationType][]dto.SnapshotRelationEntry, len(req.Relations)),
+	}
+	for typeStr, entries := range req.Relations {
+		relationType, err := ParseUserRelationType(typeStr)
+		if err != nil {
+			return dto.ProfileSnapshotData{}, fmt.Errorf("%w:%s", err, typeStr)
+		}
+		seen := make(map[uint]bool, len(entries))
+		for _, entry := range entries {
+			if seen[entry.ResourceID] {
+				return dto.ProfileSnapshotData{}, fmt.Errorf("%w:%s %d", ErrSnapshotDuplicateEntry, typeStr, entry.ResourceID)
+			}
+			seen[entry.ResourceID] = true
+			if entry.SkillGrade != 0 && relationType != UserRelationSkill {
+				return dto.ProfileSnapshotData{}, ErrSkillGradeOnlyForSkills
+			}
+		}
+		if entries == nil {
+			entries = []dto.SnapshotRelationEntry{}
+		}
+		patch.Relations[relationType] = entries
+	}
+
+	snapshot, coinDeltas, err := s.snapshotRepository.ApplySnapshot(ctx, userID, *req.BaseRevision, patch)
+	if errors.Is(err, ErrSnapshotRevisionConflict) {
+		s.logger.InfoContext(ctx, "存档保存冲突", "user_id", userID, "base_revision", *req.BaseRevision)
+	}
+	if err != nil {
+		return dto.ProfileSnapshotData{}, err
+	}
+	//存档里的金币和单独改金币一样计入指标
+	for _, field := range []string{"strength_coin", "select_coin"} {
+		recordPlayerCoinChange(s.metrics, field, coinDeltas[field])
+	}
+	s.logger.InfoContext(ctx, "保存存档", "user_id", userID, "base_revision", *req.BaseRevision, "revision", snapshot.Revision)
+	return snapshot, nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/dto"
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+type fakeSnapshotRepository struct {
+	deltas CoinDeltas
+	err    error
+}
+
+func (r *fakeSnapshotRepository) LoadSnapshot(ctx context.Context, userID uint) (dto.ProfileSnapshotData, error) {
+	return dto.ProfileSnapshotData{}, nil
+}
+
+func (r *fakeSnapshotRepository) ApplySnapshot(ctx context.Context, userID uint, baseRevision uint64, patch SnapshotPatch) (dto.ProfileSnapshotData, CoinDeltas, error) {
+	if r.err != nil {
+		return dto.ProfileSnapshotData{}, nil, r.err
+	}
+	return dto.ProfileSnapshotData{Revision: baseRevision + 1}, r.deltas, nil
+}
+
+func (r *fakeSnapshotRepository) LoadChanges(ctx context.Context, userID uint, since uint64, limit int) (dto.ProfileChangesData, error) {
+	return dto.ProfileChangesData{}, nil
+}
+
+type coinChange struct {
+	coinType string
+	source   string
+	delta    int64
+}
+
+type recordingMetrics struct {
+	coins []coinChange
+	draws int
+}
+
+func (m *recordingMetrics) Registered()     {}
+func (m *recordingMetrics) LoginSucceeded() {}
+func (m *recordingMetrics) LoginFailed()    {}
+func (m *recordingMetrics) Drew()           { m.draws++ }
+func (m *recordingMetrics) CoinsChanged(coinType, source string, delta int64) {
+	m.coins = append(m.coins, coinChange{coinType, source, delta})
+}
+
+func TestSaveSnapshotCoinMetrics(t *testing.T) {
+	coin := uint(10)
+	baseRevision := uint64(3)
+
+	tests := []struct {
+		name      string
+		deltas    CoinDeltas
+		err       error
+		wantCoins []coinChange
+		wantDraws int
+	}{
+		{"金币没变", CoinDeltas{}, nil, nil, 0},
+		{"体力币增加", CoinDeltas{"strength_coin": 5}, nil, []coinChange{{"strength_coin", CoinSourcePlayer, 5}}, 0},
+		{"抽卡币减少记一次抽卡", CoinDeltas{"select_coin": -3}, nil, []coinChange{{"select_coin", CoinSourcePlayer, -3}}, 1},
+		{"抽卡币增加不算抽卡", CoinDeltas{"select_coin": 2}, nil, []coinChange{{"select_coin", CoinSourcePlayer, 2}}, 0},
+		{"两种金币都变", CoinDeltas{"strength_coin": -1, "select_coin": -2}, nil,
+			[]coinChange{{"strength_coin", CoinSourcePlayer, -1}, {"select_coin", CoinSourcePlayer, -2}}, 1},
+		{"revision冲突不记录", CoinDeltas{"select_coin": -3}, ErrSnapshotRevisionConflict, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &recordingMetrics{}
+			repo := &fakeSnapshotRepository{deltas: tt.deltas, err: tt.err}
+			s := NewSnapshotService(repo, 100, metrics, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			_, err := s.SaveSnapshot(context.Background(), 1, dto.ProfileSnapshotRequest{
+				BaseRevision: &baseRevision,
+				StrengthCoin: &coin,
+				SelectCoin:   &coin,
+			})
+			if err != tt.err {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(metrics.coins, tt.wantCoins) {
+				t.Fatalf("金币指标为%v，期望%v", metrics.coins, tt.wantCoins)
+			}
+			if metrics.draws != tt.wantDraws {
+				t.Fatalf("抽卡次数为%d，期望%d", metrics.draws, tt.wantDraws)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrUsernameBanned = errors.New("用户名包含不允许使用的词")
+
+// UsernameFilter 用户名敏感词过滤，按子串匹配
+// 比较前统一转小写并去掉字母数字以外的字符，a_b-c、A B C都能匹配到abc
+type UsernameFilter struct {
+	words []string
+}
+
+func NewUsernameFilter(words []string) *UsernameFilter {
+	filter := &UsernameFilter{}
+	for _, word := range words {
+		if normalized := normalizeForFilter(word); normalized != "" {
+			filter.words = append(filter.words, normalized)
+		}
+	}
+	return filter
+}
+
+// Check 命中敏感词时返回ErrUsernameBanned，filter为nil时不检查
+func (f *UsernameFilter) Check(username string) error {
+	if f == nil || len(f.words) == 0 {
+		return nil
+	}
+	normalized := normalizeForFilter(username)
+	for _, word := range f.words {
+		if strings.Contains(normalized, word) {
+			return ErrUsernameBanned
+		}
+	}
+	return nil
+}
+
+func normalizeForFilter(s string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// WeakETag 按响应体内容生成弱ETag，JSON字段顺序等细节变化不影响语义，所以用弱校验
+func WeakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// StampETag 按不加载内容就能拿到的版本信息生成弱ETag，parts要包含所有影响响应内容的值(用户、版本号、数量、更新时间、查询参数等)
+func StampETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// VersionETag 按记录的乐观锁版本号生成强ETag，供If-Match做并发控制
+func VersionETag(version uint64) string {
+	return `"v` + strconv.FormatUint(version, 10) + `"`
+}
+
+// ETagMatches header可以是*或逗号分隔的多个ETag
+// weak=true时用弱比较(If-None-Match)，忽略W/前缀；weak=false时用强比较(If-Match)，弱ETag一律不匹配
+func ETagMatches(header string, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
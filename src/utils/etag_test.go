@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	weakETag := WeakETag([]byte(`{"code":200}`))
+	strongETag := VersionETag(3)
+
+	tests := []struct {
+		name   string
+		header string
+		etag   string
+		weak   bool
+		want   bool
+	}{
+		{"弱比较相同", weakETag, weakETag, true, true},
+		{"弱比较忽略W/前缀", weakETag[2:], weakETag, true, true},
+		{"弱比较不同", WeakETag([]byte("other")), weakETag, true, false},
+		{"多个候选之一匹配", `"a", ` + weakETag + `, "b"`, weakETag, true, true},
+		{"通配符", "*", weakETag, true, true},
+		{"强比较相同", strongETag, strongETag, false, true},
+		{"强比较版本不同", VersionETag(2), strongETag, false, false},
+		{"强比较不接受弱ETag", "W/" + strongETag, strongETag, false, false},
+		{"强比较通配符", "*", strongETag, false, true},
+		{"空header", "", strongETag, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.header, tt.etag, tt.weak); got != tt.want {
+				t.Fatalf("ETagMatches(%q, %q, %v) = %v, want %v", tt.header, tt.etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+var (
+	ErrImageTooLarge           = errors.New("图片文件过大")
+	ErrUnsupportedImageType    = errors.New("仅支持jpeg/png/gif格式的图片")
+	ErrImageDimensionsTooLarge = errors.New("图片尺寸过大")
+	ErrInvalidImage            = errors.New("图片已损坏或无法解析")
+)
+
+// ImageProcessOptions 上传图片的限制和输出规格
+type ImageProcessOptions struct {
+	//原始文件最大字节数
+	MaxBytes int64
+	//原图宽高的上限，解码前按文件头检查，防止小文件解压出超大图片
+	MaxDimension int
+	//输出主图的最长边，超过时等比缩小
+	MaxSide int
+	//缩略图边长，居中裁成正方形后缩放
+	ThumbnailSizes []int
+	JPEGQuality    int
+}
+
+// ProcessedImage 重新编码后的图片，都是不带EXIF等元数据的jpeg
+type ProcessedImage struct {
+	Main []byte
+	//key为缩略图边长
+	Thumbnails map[int][]byte
+}
+
+// allowedImageTypes 按文件内容嗅探出的类型，不看扩展名和客户端给的Content-Type
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// ProcessImage 校验并重新编码上传的图片：嗅探类型、检查大小和尺寸、解码后铺白底重新编码并生成缩略图
+// 重新编码会丢掉原文件里除像素以外的所有内容(EXIF、注释、夹带的脚本等)，gif只保留第一帧
+func ProcessImage(data []byte, options ImageProcessOptions) (*ProcessedImage, error) {
+	if int64(len(data)) > options.MaxBytes {
+		return nil, ErrImageTooLarge
+	}
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedImageType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > options.MaxDimension || cfg.Height > options.MaxDimension {
+		return nil, fmt.Errorf("%w:最大%dx%d", ErrImageDimensionsTooLarge, options.MaxDimension, options.MaxDimension)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	canvas := flattenImage(src)
+	width, height := canvas.Bounds().Dx(), canvas.Bounds().Dy()
+
+	mainImage := canvas
+	if width > options.MaxSide || height > options.MaxSide {
+		mainWidth, mainHeight := options.MaxSide, options.MaxSide
+		if width > height {
+			mainHeight = max(1, height*options.MaxSide/width)
+		} else {
+			mainWidth = max(1, width*options.MaxSide/height)
+		}
+		mainImage = resizeArea(canvas, canvas.Bounds(), mainWidth, mainHeight)
+	}
+
+	processed := &ProcessedImage{Thumbnails: make(map[int][]byte, len(options.ThumbnailSizes))}
+	if processed.Main, err = encodeJPEG(mainImage, options.JPEGQuality); err != nil {
+		return nil, err
+	}
+
+	//居中裁成正方形
+	side := min(width, height)
+	crop := image.Rect((width-side)/2, (height-side)/2, (width-side)/2+side, (height-side)/2+side)
+	for _, size := range options.ThumbnailSizes {
+		thumbnail, err := encodeJPEG(resizeArea(canvas, crop, size, size), options.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		processed.Thumbnails[size] = thumbnail
+	}
+	return processed, nil
+}
+
+// flattenImage 转成从(0,0)开始的RGBA并铺上白底，透明的png/gif输出成jpeg后不会变黑
+func flattenImage(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+	return dst
+}
+
+// resizeArea 把src中rect区域缩放到width*height，缩小时取对应区域的平均值，放大时退化为最近邻
+// src必须是flattenImage的结果(不透明)
+func resizeArea(src *image.RGBA, rect image.Rectangle, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := rect.Dx(), rect.Dy()
+	for y := 0; y < height; y++ {
+		y0 := rect.Min.Y + y*srcHeight/height
+		y1 := max(rect.Min.Y+(y+1)*srcHeight/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := rect.Min.X + x*srcWidth/width
+			x1 := max(rect.Min.X+(x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, count uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					i += 4
+					count++
+				}
+			}
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / count)
+			dst.Pix[j+1] = uint8(g / count)
+			dst.Pix[j+2] = uint8(b / count)
+			dst.Pix[j+3] = 0xff
+		}
+	}
+	return dst
+}
+
+func encodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
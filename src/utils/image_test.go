@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImageOptions() ImageProcessOptions {
+	return ImageProcessOptions{
+		MaxBytes:       1 << 20,
+		MaxDimension:   1000,
+		MaxSide:        100,
+		ThumbnailSizes: []int{16, 32},
+		JPEGQuality:    90,
+	}
+}
+
+func encodeTestPNG(t *testing.T, width, height int, fill color.Color) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeTestGIF(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewPaletted(image.Rect(0, 0, width, height), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodeTestJPEG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("输出不是合法的jpeg：%v", err)
+	}
+	return img
+}
+
+func TestProcessImage(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		options  func(*ImageProcessOptions)
+		wantErr  error
+		wantMain image.Point
+	}{
+		{"小图不缩放", encodeTestPNG(t, 40, 20, color.Black), nil, nil, image.Pt(40, 20)},
+		{"横图按长边缩小", encodeTestPNG(t, 400, 100, color.Black), nil, nil, image.Pt(100, 25)},
+		{"竖图按长边缩小", encodeTestPNG(t, 50, 200, color.Black), nil, nil, image.Pt(25, 100)},
+		{"gif取第一帧", encodeTestGIF(t, 60, 60), nil, nil, image.Pt(60, 60)},
+		{"超过字节上限", encodeTestPNG(t, 40, 40, color.Black), func(o *ImageProcessOptions) { o.MaxBytes = 10 }, ErrImageTooLarge, image.Point{}},
+		{"超过尺寸上限", encodeTestPNG(t, 1001, 10, color.Black), nil, ErrImageDimensionsTooLarge, image.Point{}},
+		{"不支持的类型", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), nil, ErrUnsupportedImageType, image.Point{}},
+		{"文件头正确但内容损坏", encodeTestPNG(t, 40, 40, color.Black)[:30], nil, ErrInvalidImage, image.Point{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := testImageOptions()
+			if tt.options != nil {
+				tt.options(&options)
+			}
+			processed, err := ProcessImage(tt.data, options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if size := decodeTestJPEG(t, processed.Main).Bounds().Size(); size != tt.wantMain {
+				t.Fatalf("主图尺寸为%v，期望%v", size, tt.wantMain)
+			}
+			if len(processed.Thumbnails) != len(options.ThumbnailSizes) {
+				t.Fatalf("生成了%d张缩略图，期望%d张", len(processed.Thumbnails), len(options.ThumbnailSizes))
+			}
+			for _, side := range options.ThumbnailSizes {
+				if size := decodeTestJPEG(t, processed.Thumbnails[side]).Bounds().Size(); size != image.Pt(side, side) {
+					t.Fatalf("缩略图%d尺寸为%v", side, size)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessImageFlattensTransparency(t *testing.T) {
+	processed, err := ProcessImage(encodeTestPNG(t, 20, 20, color.Transparent), testImageOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, g, b, _ := decodeTestJPEG(t, processed.Main).At(10, 10).RGBA()
+	//jpeg有损，接近白色即可
+	if r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Fatalf("透明区域应铺成白底，实际为(%d,%d,%d)", r>>8, g>>8, b>>8)
+	}
+}
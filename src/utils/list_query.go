@@ -0,0 +1,207 @@
+package utils
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidSort        = errors.New("sort参数无效")
+	ErrInvalidQueryFilter = errors.New("筛选参数无效")
+)
+
+// FilterKind 筛选条件的类型，决定query参数怎么解析、拼成什么条件
+type FilterKind int
+
+const (
+	//模糊搜索，query参数名就是Param
+	FilterLike FilterKind = iota
+	//字符串精确匹配
+	FilterExact
+	//无符号整数精确匹配
+	FilterUint
+	//布尔精确匹配，接受true/false/1/0
+	FilterBool
+	//时间范围，query参数为<Param>_from和<Param>_to
+	//格式为RFC3339或2006-01-02，只写日期的_to包含当天
+	FilterTimeRange
+	//数值范围(闭区间)，query参数为<Param>_min和<Param>_max
+	FilterUintRange
+)
+
+// QueryFilter 一个允许的筛选条件，Column是SQL列名，生成SQL时会按方言加引号
+type QueryFilter struct {
+	Param  string
+	Column string
+	Kind   FilterKind
+}
+
+// QuerySort 一个允许的排序，Param是sort参数的取值，Field是结构体字段名(生成游标用)
+type QuerySort struct {
+	Param  string
+	Column string
+	Field  string
+}
+
+// ListQuerySpec 列表接口的筛选/排序白名单，不在白名单里的列一律不会进SQL
+// Key是唯一键，不传sort时按它正序，排序列相同时也按它排
+type ListQuerySpec struct {
+	Filters []QueryFilter
+	Sorts   []QuerySort
+	Key     KeysetKey
+}
+
+// ListQuery 解析好的筛选条件和排序
+type ListQuery struct {
+	conditions []clause.Expression
+	order      KeysetOrder
+}
+
+// Parse 从query参数里解析筛选和排序，空值视为未传
+// sort取值为白名单里的Param或id，前面加-表示倒序，例如sort=-created_at
+func (spec ListQuerySpec) Parse(values url.Values) (ListQuery, error) {
+	query := ListQuery{order: KeysetOrder{Key: spec.Key}}
+
+	if sortParam := values.Get("sort"); sortParam != "" {
+		name := strings.TrimPrefix(sortParam, "-")
+		query.order.Desc = name != sortParam
+		if name != "id" {
+			matched := false
+			for _, sort := range spec.Sorts {
+				if sort.Param == name {
+					query.order.By = &KeysetKey{Column: sort.Column, Field: sort.Field}
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return ListQuery{}, fmt.Errorf("%w:不支持按%s排序", ErrInvalidSort, name)
+			}
+		}
+	}
+
+	for _, filter := range spec.Filters {
+		conditions, err := filter.parse(values)
+		if err != nil {
+			return ListQuery{}, err
+		}
+		query.conditions = append(query.conditions, conditions...)
+	}
+	return query, nil
+}
+
+// Apply 只把筛选条件加到db上，不排序也不分页
+func (q ListQuery) Apply(db *gorm.DB) *gorm.DB {
+	for _, condition := range q.conditions {
+		db = db.Where(condition)
+	}
+	return db
+}
+
+// Paginate 加上筛选条件后按解析出的排序分页查询，dest必须是结构体切片的指针
+func (q ListQuery) Paginate(db *gorm.DB, pagination models.Pagination, dest interface{}) (models.PageInfo, error) {
+	return PaginateQueryOrdered(q.Apply(db), pagination, q.order, dest)
+}
+
+func (f QueryFilter) parse(values url.Values) ([]clause.Expression, error) {
+	column := clause.Column{Name: f.Column}
+	switch f.Kind {
+	case FilterLike:
+		keyword := SqlSafeLikeKeyword(values.Get(f.Param))
+		if keyword == "" {
+			return nil, nil
+		}
+		return []clause.Expression{clause.Like{Column: column, Value: "%" + keyword + "%"}}, nil
+	case FilterExact:
+		if value := values.Get(f.Param); value != "" {
+			return []clause.Expression{clause.Eq{Column: column, Value: value}}, nil
+		}
+		return nil, nil
+	case FilterUint:
+		value, ok, err := parseUintParam(values, f.Param)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return []clause.Expression{clause.Eq{Column: column, Value: value}}, nil
+	case FilterBool:
+		raw := values.Get(f.Param)
+		if raw == "" {
+			return nil, nil
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w:%s格式错误", ErrInvalidQueryFilter, f.Param)
+		}
+		return []clause.Expression{clause.Eq{Column: column, Value: value}}, nil
+	case FilterTimeRange:
+		var conditions []clause.Expression
+		if raw := values.Get(f.Param + "_from"); raw != "" {
+			from, _, err := parseTimeParam(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w:%s_from格式错误", ErrInvalidQueryFilter, f.Param)
+			}
+			conditions = append(conditions, clause.Gte{Column: column, Value: from})
+		}
+		if raw := values.Get(f.Param + "_to"); raw != "" {
+			to, dateOnly, err := parseTimeParam(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w:%s_to格式错误", ErrInvalidQueryFilter, f.Param)
+			}
+			if dateOnly {
+				conditions = append(conditions, clause.Lt{Column: column, Value: to.AddDate(0, 0, 1)})
+			} else {
+				conditions = append(conditions, clause.Lte{Column: column, Value: to})
+			}
+		}
+		return conditions, nil
+	case FilterUintRange:
+		var conditions []clause.Expression
+		if min, ok, err := parseUintParam(values, f.Param+"_min"); err != nil {
+			return nil, err
+		} else if ok {
+			conditions = append(conditions, clause.Gte{Column: column, Value: min})
+		}
+		if max, ok, err := parseUintParam(values, f.Param+"_max"); err != nil {
+			return nil, err
+		} else if ok {
+			conditions = append(conditions, clause.Lte{Column: column, Value: max})
+		}
+		return conditions, nil
+	}
+	return nil, fmt.Errorf("未知的筛选类型:%d", f.Kind)
+}
+
+func parseUintParam(values url.Values, param string) (uint64, bool, error) {
+	raw := values.Get(param)
+	if raw == "" {
+		return 0, false, nil
+	}
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("%w:%s格式错误", ErrInvalidQueryFilter, param)
+	}
+	return value, true, nil
+}
+
+// parseTimeParam 第二个返回值表示传入的是否只有日期，只有日期时按本地时区的0点算
+func parseTimeParam(raw string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, raw, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
+
+// IsListQueryError 是否是客户端传的筛选/排序/游标参数有问题，调用方据此返回400
+func IsListQueryError(err error) bool {
+	return errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidQueryFilter) ||
+		errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrCursorMismatch)
+}
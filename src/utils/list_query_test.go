@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"errors"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+type listRow struct {
+	ID        uint
+	Name      string
+	Group     string
+	Coin      uint
+	Banned    bool
+	CreatedAt time.Time
+}
+
+var listRowSpec = ListQuerySpec{
+	Filters: []QueryFilter{
+		{Param: "name", Column: "name", Kind: FilterLike},
+		{Param: "group", Column: "group", Kind: FilterLike},
+		{Param: "group_exact", Column: "group", Kind: FilterExact},
+		{Param: "coin", Column: "coin", Kind: FilterUintRange},
+		{Param: "banned", Column: "banned", Kind: FilterBool},
+		{Param: "created_at", Column: "created_at", Kind: FilterTimeRange},
+	},
+	Sorts: []QuerySort{
+		{Param: "coin", Column: "coin", Field: "Coin"},
+	},
+	Key: KeysetKey{Column: "id", Field: "ID"},
+}
+
+func TestListQuerySpecParse(t *testing.T) {
+	withCursorSecret(t, "0123456789abcdef0123456789abcdef")
+	db := openKeysetDB(t)
+	if err := db.AutoMigrate(&listRow{}); err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.Local) }
+	rows := []listRow{
+		{Name: "alice", Group: "user", Coin: 10, CreatedAt: day(1)},
+		{Name: "bob", Group: "admin", Coin: 50, CreatedAt: day(2)},
+		{Name: "carol", Group: "superadmin", Coin: 30, Banned: true, CreatedAt: day(3)},
+		{Name: "dave", Group: "user", Coin: 50, CreatedAt: day(4)},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		want    []uint
+		wantErr error
+	}{
+		{"不带参数", "", []uint{1, 2, 3, 4}, nil},
+		{"名字模糊", "name=o", []uint{2, 3}, nil},
+		{"权限组模糊", "group=admin", []uint{2, 3}, nil},
+		{"权限组精确", "group_exact=admin", []uint{2}, nil},
+		{"数值范围", "coin_min=20&coin_max=50", []uint{2, 3, 4}, nil},
+		{"布尔", "banned=true", []uint{3}, nil},
+		{"日期包含当天", "created_at_from=2026-03-02&created_at_to=2026-03-03", []uint{2, 3}, nil},
+		{"条件叠加", "group=user&coin_min=20", []uint{4}, nil},
+		{"按数值倒序", "sort=-coin", []uint{4, 2, 3, 1}, nil},
+		{"按id倒序", "sort=-id", []uint{4, 3, 2, 1}, nil},
+		{"不在白名单的排序", "sort=name", nil, ErrInvalidSort},
+		{"数值格式错误", "coin_min=abc", nil, ErrInvalidQueryFilter},
+		{"布尔格式错误", "banned=maybe", nil, ErrInvalidQueryFilter},
+		{"日期格式错误", "created_at_to=03/02", nil, ErrInvalidQueryFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, err := listRowSpec.Parse(values)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !IsListQueryError(err) {
+					t.Fatalf("got %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var page []listRow
+			if _, err = query.Paginate(db.Model(&listRow{}), models.Pagination{Page: 1, PageSize: 10, Limit: 10}, &page); err != nil {
+				t.Fatalf("Paginate: %v", err)
+			}
+			var got []uint
+			for _, row := range page {
+				got = append(got, row.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("cursor参数无效")
+	ErrCursorMismatch = errors.New("cursor与当前查询不匹配")
+)
+
+// cursorSecret 用于给游标签名，防止客户端伪造
+// 启动时会换成配置的游标密钥，这里的随机值只是兜底
+var cursorSecret = mustGenerateCursorSecret()
+
+func mustGenerateCursorSecret() []byte {
+	secret, err := GenerateSercet(32)
+	if err != nil {
+		panic(err)
+	}
+	return secret
+}
+
+// SetCursorSecret 设置游标签名密钥，密钥由config单独配置或派生，不要直接传JWT密钥
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+// EncodeCursor 格式为 base64url(json).base64url(hmac前16字节)，nil返回空串
+// 签名覆盖json和cursor.Scope，换个列表或换个用户拿来用都会验签失败
+func EncodeCursor(cursor *models.PageCursor) string {
+	if cursor == nil {
+		return ""
+	}
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signCursor(encoded, cursor.Scope)
+}
+
+// DecodeCursor scope为当前请求所在的列表和用户，要和发出游标时一致
+func DecodeCursor(raw string, scope models.CursorScope) (models.PageCursor, error) {
+	encoded, signature, found := strings.Cut(raw, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signCursor(encoded, scope))) {
+		return models.PageCursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return models.PageCursor{}, ErrInvalidCursor
+	}
+	var cursor models.PageCursor
+	if err = json.Unmarshal(payload, &cursor); err != nil || cursor.Sort == "" {
+		return models.PageCursor{}, ErrInvalidCursor
+	}
+	cursor.Scope = scope
+	return cursor, nil
+}
+
+// signCursor 各部分用\x00隔开，列表名里不会出现\x00，拼接不会有歧义
+func signCursor(encoded string, scope models.CursorScope) string {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(scope.List))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatUint(uint64(scope.UserID), 10)))
+	mac.Write([]byte{0})
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// KeysetKey 游标分页使用的排序键
+// Column是SQL列名，Field是dest元素里对应的结构体字段名
+type KeysetKey struct {
+	Column string
+	Field  string
+}
+
+// KeysetOrder 分页的排序方式，Key必须唯一且为无符号整数
+// By为nil时只按Key排序；否则先按By排序，By相同时再按Key排序，此时游标里会带上By的值
+type KeysetOrder struct {
+	By   *KeysetKey
+	Key  KeysetKey
+	Desc bool
+}
+
+// signature 写进游标的排序标识，只按Key正序时和旧游标保持一致
+func (o KeysetOrder) signature() string {
+	signature := o.Key.Column
+	if o.By != nil {
+		signature = o.By.Column + "," + signature
+	}
+	if o.Desc {
+		signature = "-" + signature
+	}
+	return signature
+}
+
+// PaginateQuery 按唯一键正序分页，见PaginateQueryOrdered
+func PaginateQuery(baseQuery *gorm.DB, pagination models.Pagination, key KeysetKey, dest interface{}) (models.PageInfo, error) {
+	return PaginateQueryOrdered(baseQuery, pagination, KeysetOrder{Key: key}, dest)
+}
+
+// PaginateQueryOrdered 按pagination执行分页查询，dest必须是结构体切片的指针
+// 页码模式：LIMIT/OFFSET，WithTotal时统计总数
+// 游标模式：WHERE (by,key) 在上一页最后一条之后，多查一条判断是否还有下一页，不做OFFSET
+func PaginateQueryOrdered(baseQuery *gorm.DB, pagination models.Pagination, order KeysetOrder, dest interface{}) (models.PageInfo, error) {
+	var info models.PageInfo
+	if pagination.WithTotal {
+		var total int64
+		if err := baseQuery.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return models.PageInfo{}, err
+		}
+		info.Total = &total
+	}
+
+	query := baseQuery.Session(&gorm.Session{})
+	if order.By != nil {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: order.By.Column}, Desc: order.Desc})
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: order.Key.Column}, Desc: order.Desc})
+	if !pagination.UseCursor {
+		return info, query.Limit(pagination.Limit).Offset(pagination.Offset).Find(dest).Error
+	}
+
+	if pagination.After != nil {
+		condition, err := order.afterCondition(*pagination.After, dest)
+		if err != nil {
+			return models.PageInfo{}, err
+		}
+		query = query.Where(condition)
+	}
+	if err := query.Limit(pagination.Limit + 1).Find(dest).Error; err != nil {
+		return models.PageInfo{}, err
+	}
+
+	list := reflect.ValueOf(dest).Elem()
+	if list.Len() > pagination.Limit {
+		list.SetLen(pagination.Limit)
+		last := list.Index(pagination.Limit - 1)
+		next := &models.PageCursor{Sort: order.signature(), ID: uint(last.FieldByName(order.Key.Field).Uint()), Scope: pagination.CursorScope}
+		if order.By != nil {
+			value, err := encodeKeysetValue(last.FieldByName(order.By.Field))
+			if err != nil {
+				return models.PageInfo{}, err
+			}
+			next.Value = value
+		}
+		info.Next = next
+	}
+	return info, nil
+}
+
+// afterCondition 生成"排在游标之后"的条件：by > v OR (by = v AND key > id)，倒序时反过来
+func (o KeysetOrder) afterCondition(after models.PageCursor, dest interface{}) (clause.Expression, error) {
+	if after.Sort != o.signature() {
+		return nil, ErrCursorMismatch
+	}
+	beyond := func(column string, value interface{}) clause.Expression {
+		if o.Desc {
+			return clause.Lt{Column: clause.Column{Name: column}, Value: value}
+		}
+		return clause.Gt{Column: clause.Column{Name: column}, Value: value}
+	}
+
+	keyCondition := beyond(o.Key.Column, after.ID)
+	if o.By == nil {
+		return keyCondition, nil
+	}
+
+	field, ok := reflect.TypeOf(dest).Elem().Elem().FieldByName(o.By.Field)
+	if !ok {
+		return nil, fmt.Errorf("排序字段%s不存在", o.By.Field)
+	}
+	value, err := decodeKeysetValue(after.Value, field.Type)
+	if err != nil {
+		return nil, err
+	}
+	return clause.Or(
+		beyond(o.By.Column, value),
+		clause.And(clause.Eq{Column: clause.Column{Name: o.By.Column}, Value: value}, keyCondition),
+	), nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func encodeKeysetValue(value reflect.Value) (string, error) {
+	if value.Type() == timeType {
+		return value.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	}
+	return "", fmt.Errorf("不支持按%s类型的字段做游标分页", value.Type())
+}
+
+// decodeKeysetValue 按字段类型还原游标里的值，游标已经验过签，这里出错只可能是排序字段类型变了
+func decodeKeysetValue(raw string, fieldType reflect.Type) (interface{}, error) {
+	if fieldType == timeType {
+		t, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	}
+	switch fieldType.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("不支持按%s类型的字段做游标分页", fieldType)
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"MuXi/2026-MuxiShooter-Backend/models"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func withCursorSecret(t *testing.T, secret string) {
+	t.Helper()
+	previous := cursorSecret
+	SetCursorSecret([]byte(secret))
+	t.Cleanup(func() { cursorSecret = previous })
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	withCursorSecret(t, strings.Repeat("k", 32))
+
+	tests := []struct {
+		name   string
+		cursor models.PageCursor
+	}{
+		{"只有主键", models.PageCursor{Sort: "id", ID: 42}},
+		{"带排序值", models.PageCursor{Sort: "-created_at,id", Value: "2026-01-02T03:04:05.123456789Z", ID: 7}},
+		{"带列表和用户", models.PageCursor{Sort: "id", ID: 1, Scope: models.CursorScope{List: "/api/profile/friends", UserID: 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(EncodeCursor(&tt.cursor), tt.cursor.Scope)
+			if err != nil {
+				t.Fatalf("DecodeCursor: %v", err)
+			}
+			if got != tt.cursor {
+				t.Fatalf("got %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+
+	if EncodeCursor(nil) != "" {
+		t.Fatal("nil游标应该编码为空串")
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	withCursorSecret(t, strings.Repeat("k", 32))
+	scope := models.CursorScope{List: "/api/profile/friends", UserID: 3}
+	valid := EncodeCursor(&models.PageCursor{Sort: "id", ID: 42, Scope: scope})
+	encoded, signature, _ := strings.Cut(valid, ".")
+	otherPayload, _, _ := strings.Cut(EncodeCursor(&models.PageCursor{Sort: "id", ID: 43, Scope: scope}), ".")
+
+	tests := []struct {
+		name  string
+		raw   string
+		scope models.CursorScope
+	}{
+		{"没有签名", encoded, scope},
+		{"签名为空", encoded + ".", scope},
+		{"改了内容", otherPayload + "." + signature, scope},
+		{"改了签名", encoded + "." + strings.Repeat("A", len(signature)), scope},
+		{"不是base64", "!!!." + signature, scope},
+		{"别的列表", valid, models.CursorScope{List: "/api/profile/blocks", UserID: 3}},
+		{"别的用户", valid, models.CursorScope{List: "/api/profile/friends", UserID: 4}},
+		{"未登录", valid, models.CursorScope{List: "/api/profile/friends"}},
+		//列表名和用户ID拼接后相同也不能通过
+		{"拼接歧义", valid, models.CursorScope{List: "/api/profile/friends3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.raw, tt.scope); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("got %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	t.Run("换了密钥", func(t *testing.T) {
+		withCursorSecret(t, strings.Repeat("x", 32))
+		if _, err := DecodeCursor(valid, scope); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("got %v, want ErrInvalidCursor", err)
+		}
+	})
+
+	t.Run("签名正确但没有排序标识", func(t *testing.T) {
+		raw := EncodeCursor(&models.PageCursor{ID: 1, Scope: scope})
+		if _, err := DecodeCursor(raw, scope); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("got %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+type keysetRow struct {
+	ID    uint
+	Score int
+	Name  string
+}
+
+func openKeysetDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//内存库每个连接是独立的，只用一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(&keysetRow{}); err != nil {
+		t.Fatal(err)
+	}
+	//分数有大量重复，页边界会落在相同分数的中间
+	rows := []keysetRow{
+		{Score: 10, Name: "a"}, {Score: 20, Name: "b"}, {Score: 10, Name: "c"}, {Score: 30, Name: "d"},
+		{Score: 20, Name: "e"}, {Score: 10, Name: "f"}, {Score: 20, Name: "g"}, {Score: 30, Name: "h"},
+	}
+	if err = db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// collectPages 用游标一页页翻到底，每一页的游标都走一遍编码解码
+func collectPages(t *testing.T, db *gorm.DB, order KeysetOrder, pageSize int) []uint {
+	t.Helper()
+	scope := models.CursorScope{List: "/test", UserID: 1}
+	pagination := models.Pagination{UseCursor: true, Limit: pageSize, PageSize: pageSize, CursorScope: scope}
+	var ids []uint
+	for range 20 {
+		var page []keysetRow
+		info, err := PaginateQueryOrdered(db.Model(&keysetRow{}), pagination, order, &page)
+		if err != nil {
+			t.Fatalf("PaginateQueryOrdered: %v", err)
+		}
+		if len(page) > pageSize {
+			t.Fatalf("一页返回了%d条", len(page))
+		}
+		for _, row := range page {
+			ids = append(ids, row.ID)
+		}
+		if info.Next == nil {
+			return ids
+		}
+		after, err := DecodeCursor(EncodeCursor(info.Next), scope)
+		if err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+		pagination.After = &after
+	}
+	t.Fatal("翻页没有结束")
+	return nil
+}
+
+func TestPaginateQueryOrderedKeyset(t *testing.T) {
+	withCursorSecret(t, strings.Repeat("k", 32))
+	db := openKeysetDB(t)
+	score := &KeysetKey{Column: "score", Field: "Score"}
+	name := &KeysetKey{Column: "name", Field: "Name"}
+	id := KeysetKey{Column: "id", Field: "ID"}
+
+	tests := []struct {
+		name     string
+		order    KeysetOrder
+		pageSize int
+		want     []uint
+	}{
+		{"按主键", KeysetOrder{Key: id}, 3, []uint{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"按主键倒序", KeysetOrder{Key: id, Desc: true}, 3, []uint{8, 7, 6, 5, 4, 3, 2, 1}},
+		{"按分数，同分按主键", KeysetOrder{By: score, Key: id}, 2, []uint{1, 3, 6, 2, 5, 7, 4, 8}},
+		{"按分数倒序", KeysetOrder{By: score, Key: id, Desc: true}, 3, []uint{8, 4, 7, 5, 2, 6, 3, 1}},
+		{"每页一条", KeysetOrder{By: score, Key: id}, 1, []uint{1, 3, 6, 2, 5, 7, 4, 8}},
+		{"正好一页", KeysetOrder{By: name, Key: id}, 8, []uint{1, 2, 3, 4, 5, 6, 7, 8}},
+		{"字符串排序", KeysetOrder{By: name, Key: id, Desc: true}, 3, []uint{8, 7, 6, 5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := collectPages(t, db, tt.order, tt.pageSize)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateQueryOrderedCursorMismatch(t *testing.T) {
+	db := openKeysetDB(t)
+	after := models.PageCursor{Sort: "id", ID: 3}
+	pagination := models.Pagination{UseCursor: true, Limit: 2, PageSize: 2, After: &after}
+	order := KeysetOrder{By: &KeysetKey{Column: "score", Field: "Score"}, Key: KeysetKey{Column: "id", Field: "ID"}}
+
+	var page []keysetRow
+	if _, err := PaginateQueryOrdered(db.Model(&keysetRow{}), pagination, order, &page); !errors.Is(err, ErrCursorMismatch) {
+		t.Fatalf("got %v, want ErrCursorMismatch", err)
+	}
+}
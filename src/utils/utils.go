@@ -5,13 +5,10 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
-	"mime/multipart"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -20,8 +17,6 @@ import (
 
 const (
 	DefualtSqlSafeLikeKeywordLen = 30
-	TokenExpirationTime          = 24 * time.Hour
-	uploadDir                    = "uploads"
 )
 
 var (
@@ -49,37 +44,6 @@ func ComparePassword(dbPassword, password string) error {
 	return nil
 }
 
-func SaveImages(c *gin.Context, file *multipart.FileHeader, prefix string) (string, error) {
-	if _, err := os.Stat(uploadDir); os.IsNotExist(err) {
-		os.Mkdir(uploadDir, 0755)
-	}
-	//其实也可以直接用限定创建
-	ext := filepath.Ext(file.Filename)
-	//ext可以提取扩展名
-	timestamp := time.Now().Unix()
-	randomStr := uuid.New().String()[:8]
-	newFileName := fmt.Sprintf("%s_%d_%s%s", prefix, timestamp, randomStr, ext)
-
-	dst := filepath.Join(uploadDir, newFileName)
-	//连接路径
-
-	if err := c.SaveUploadedFile(file, dst); err != nil {
-		return "", err
-	}
-	//没想到gin本身就有保存文件啊，好好gin
-	return filepath.ToSlash(dst), nil
-	//统一路径分隔符,会根据系统选择\还是/
-}
-
-func RemoveFile(filePath string) error {
-	if filePath != "" {
-		err := os.Remove(filePath)
-		return err
-	}
-	return nil
-	//没有也不用管，反正本身就没
-}
-
 func SqlSafeLikeKeyword(input string) string {
 	//默认最多三十字
 	if len(input) > DefualtSqlSafeLikeKeywordLen {
@@ -114,9 +78,9 @@ func GenerateSercet(keyLength int) ([]byte, error) {
 	return key, err
 }
 
-func GenerateToken(user models.User, jwtSecret []byte) (tokenStr string, expirationTime time.Time, err error) {
-	//Token过期时间,24h
-	expirationTime = time.Now().Add(TokenExpirationTime)
+// GenerateToken ttl为token有效期，见config.AuthSettings.TokenTTL
+func GenerateToken(user models.User, jwtSecret []byte, ttl time.Duration) (tokenStr string, expirationTime time.Time, err error) {
+	expirationTime = time.Now().Add(ttl)
 
 	//创建claims
 	//版号按照对应的来